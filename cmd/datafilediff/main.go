@@ -0,0 +1,100 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// The datafilediff command prints the changes between two datafiles, computed the way the config managers compute
+// the diff of their update notifications:
+//
+//	go run ./cmd/datafilediff old.json new.json
+//
+// Like diff, it exits with status 1 when the datafiles differ.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
+	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/notification"
+)
+
+var changeSymbols = map[notification.ChangeType]string{
+	notification.Added:   "+",
+	notification.Removed: "-",
+	notification.Changed: "~",
+}
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the diff as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-json] old-datafile new-datafile\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	logging.SetLogLevel(logging.LogLevelError)
+
+	oldConfig, err := loadProjectConfig(flag.Arg(0))
+	exitOnError(err)
+	newConfig, err := loadProjectConfig(flag.Arg(1))
+	exitOnError(err)
+	diff := config.DiffProjectConfigs(oldConfig, newConfig)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		exitOnError(encoder.Encode(diff))
+	} else {
+		fmt.Printf("revision %s -> %s\n", diff.OldRevision, diff.NewRevision)
+		for _, change := range diff.Changes {
+			if change.Type == notification.Changed {
+				fmt.Printf("%s %s %s %s: %q -> %q\n", changeSymbols[change.Type], change.Entity, change.Key, change.Field, change.OldValue, change.NewValue)
+				continue
+			}
+			fmt.Printf("%s %s %s\n", changeSymbols[change.Type], change.Entity, change.Key)
+		}
+	}
+
+	if len(diff.Changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+func loadProjectConfig(path string) (config.ProjectConfig, error) {
+	datafile, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	projectConfig, err := datafileprojectconfig.NewDatafileProjectConfig(datafile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return projectConfig, nil
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
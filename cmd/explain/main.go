@@ -0,0 +1,119 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// The explain command prints the decision path of a user for a feature or an experiment, to tell why the user gets
+// a variation:
+//
+//	go run ./cmd/explain -sdk-key <key> -user user123 -attributes '{"country": "us"}' -flag checkout_flow
+//
+// The decisions are made by the decision service of the SDK, so they match the ones of the SDK in production.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/decision/userprofile"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/explain"
+	"github.com/optimizely/go-sdk/pkg/logging"
+)
+
+func main() {
+	datafilePath := flag.String("datafile", "", "path of the datafile")
+	sdkKey := flag.String("sdk-key", "", "SDK key to download the datafile with, instead of -datafile")
+	userID := flag.String("user", "", "ID of the user")
+	attributesJSON := flag.String("attributes", "{}", "attributes of the user, as a JSON object")
+	featureKey := flag.String("flag", "", "key of the feature flag to explain")
+	experimentKey := flag.String("experiment", "", "key of the experiment to explain, instead of -flag")
+	profileDir := flag.String("ups-dir", "", "directory of a file user profile service to look the saved decisions up in")
+	profileRedis := flag.String("ups-redis", "", "address of a Redis user profile service to look the saved decisions up in")
+	asJSON := flag.Bool("json", false, "print the explanation as JSON")
+	flag.Parse()
+
+	if (*datafilePath == "") == (*sdkKey == "") || *userID == "" || (*featureKey == "") == (*experimentKey == "") {
+		fmt.Fprintln(os.Stderr, "one of -datafile and -sdk-key, -user and one of -flag and -experiment are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	logging.SetLogLevel(logging.LogLevelError)
+
+	projectConfig, err := loadProjectConfig(*datafilePath, *sdkKey)
+	exitOnError(err)
+
+	userContext := entities.UserContext{ID: *userID}
+	if err := json.Unmarshal([]byte(*attributesJSON), &userContext.Attributes); err != nil {
+		exitOnError(fmt.Errorf("invalid attributes: %v", err))
+	}
+
+	var options []explain.OptionFunc
+	switch {
+	case *profileDir != "":
+		fileService, err := userprofile.NewFileService(*profileDir)
+		exitOnError(err)
+		options = append(options, explain.WithUserProfileService(fileService))
+	case *profileRedis != "":
+		options = append(options, explain.WithUserProfileService(userprofile.NewRedisService(*profileRedis)))
+	}
+	explainer := explain.NewExplainer(projectConfig, options...)
+
+	var explanation explain.Explanation
+	if *featureKey != "" {
+		explanation, err = explainer.ExplainFeature(*featureKey, userContext)
+	} else {
+		explanation, err = explainer.ExplainExperiment(*experimentKey, userContext)
+	}
+	exitOnError(err)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		exitOnError(encoder.Encode(explanation))
+		return
+	}
+	fmt.Print(explanation)
+}
+
+func loadProjectConfig(datafilePath, sdkKey string) (config.ProjectConfig, error) {
+	if sdkKey != "" {
+		configManager, err := config.NewStaticProjectConfigManagerFromURL(sdkKey)
+		if err != nil {
+			return nil, err
+		}
+		return configManager.GetConfig()
+	}
+
+	datafile, err := ioutil.ReadFile(datafilePath)
+	if err != nil {
+		return nil, err
+	}
+	configManager, err := config.NewStaticProjectConfigManagerFromPayload(datafile)
+	if err != nil {
+		return nil, err
+	}
+	return configManager.GetConfig()
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,127 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// The replay command re-dispatches log events captured as newline delimited JSON, for instance by a dispatcher
+// saving the batches it failed to send while the log endpoint was down:
+//
+//	go run ./cmd/replay -rate 5 -report report.json failed-events.ndjson
+//
+// Snapshot events already replayed, by UUID, are dropped. It exits with status 1 when a log event failed.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/optimizely/go-sdk/pkg/event"
+	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/utils"
+)
+
+// fileReport is the replay report of one captured file
+type fileReport struct {
+	Path   string             `json:"path"`
+	Report event.ReplayReport `json:"report"`
+}
+
+func main() {
+	rate := flag.Float64("rate", event.DefaultReplayRate, "log events replayed per second, no limit if zero")
+	burst := flag.Int("burst", 1, "log events replayed in a burst")
+	gzipThreshold := flag.Int("gzip", 0, "gzip the payloads of at least this many bytes, never if zero")
+	reportPath := flag.String("report", "", "path of the JSON report of every replayed log event")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] captured-file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	logging.SetLogLevel(logging.LogLevelWarning)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		cancel()
+	}()
+
+	var requesterOptions []func(*utils.HTTPRequester)
+	if *gzipThreshold > 0 {
+		requesterOptions = append(requesterOptions, utils.GzipThreshold(*gzipThreshold))
+	}
+	dispatcher := event.NewHTTPEventDispatcher(utils.NewHTTPRequester(requesterOptions...))
+	replayer := event.NewReplayer(dispatcher, event.WithReplayRate(*rate, *burst))
+
+	var reports []fileReport
+	var replayErr error
+	failed := false
+	for _, path := range flag.Args() {
+		report, err := replayFile(ctx, replayer, path)
+		reports = append(reports, fileReport{Path: path, Report: report})
+		fmt.Printf("%s: %d dispatched, %d failed, %d skipped, %d duplicate snapshot events\n",
+			path, report.Dispatched, report.Failed, report.Skipped, report.Duplicates)
+		failed = failed || report.Failed > 0
+		if err != nil {
+			replayErr = fmt.Errorf("%s: %v", path, err)
+			break
+		}
+	}
+
+	if *reportPath != "" {
+		exitOnError(writeReport(*reportPath, reports))
+	}
+	exitOnError(replayErr)
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func replayFile(ctx context.Context, replayer *event.Replayer, path string) (event.ReplayReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return event.ReplayReport{}, err
+	}
+	defer file.Close()
+	return replayer.Replay(ctx, file)
+}
+
+func writeReport(path string, reports []fileReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(reports); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,92 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// The sidecar command serves the decisions of the SDK over HTTP, for services which can't embed it:
+//
+//	go run ./cmd/sidecar -addr :8080 -sdk-keys <key>
+//
+// Every request names its project with the X-Optimizely-SDK-Key header, one of the SDK keys given with -sdk-keys unless
+// -any-sdk-key is set. On SIGINT or SIGTERM the server stops
+// accepting requests, waits for the ongoing ones and closes the clients, flushing their queued events.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/sidecar"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	sdkKeys := flag.String("sdk-keys", "", "comma separated SDK keys to load on startup, the server is ready once their datafiles are loaded")
+	anySDKKey := flag.Bool("any-sdk-key", false, "serve any SDK key, not only the ones of -sdk-keys")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "time to wait for the ongoing requests on shutdown")
+	idleTimeout := flag.Duration("idle-timeout", client.DefaultIdleTimeout, "time after which the client of an unused SDK key is closed, 0 to keep the clients")
+	flag.Parse()
+
+	options := []sidecar.OptionFunc{sidecar.WithClientCacheOptions(client.WithIdleTimeout(*idleTimeout))}
+	if *sdkKeys != "" {
+		options = append(options, sidecar.WithSDKKeys(strings.Split(*sdkKeys, ",")...))
+	}
+	if *anySDKKey {
+		options = append(options, sidecar.WithAnySDKKey())
+	}
+	server := sidecar.NewServer(options...)
+	if err := server.Preload(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	if *idleTimeout > 0 {
+		go func() {
+			for range time.Tick(*idleTimeout / 2) {
+				server.EvictIdle()
+			}
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		fmt.Fprintln(os.Stderr, err)
+		server.Close()
+		os.Exit(1)
+	case <-signals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	server.Close()
+}
@@ -0,0 +1,203 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// The simulate command audits the traffic allocations of a datafile by bucketing synthetic users:
+//
+//	go run ./cmd/simulate -datafile datafile.json -users 100000 -attribute country=choice:us,ca -attribute age=int:18:65
+//
+// It exits with status 1 when an allocation deviates from its configuration or a mutually exclusive group overlaps.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
+	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/simulation"
+)
+
+// attributeFlags collects the repeated -attribute flags
+type attributeFlags []simulation.OptionFunc
+
+func (a *attributeFlags) String() string {
+	return fmt.Sprintf("%d attributes", len(*a))
+}
+
+// Set parses key=choice:a,b,c, key=int:min:max or key=bool:probability
+func (a *attributeFlags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf(`expected key=generator, got "%s"`, value)
+	}
+	spec := strings.Split(parts[1], ":")
+
+	var generator simulation.AttributeGenerator
+	switch {
+	case spec[0] == "choice" && len(spec) == 2:
+		var values []interface{}
+		for _, choice := range strings.Split(spec[1], ",") {
+			values = append(values, choice)
+		}
+		generator = simulation.Choice(values...)
+	case spec[0] == "int" && len(spec) == 3:
+		min, minErr := strconv.Atoi(spec[1])
+		max, maxErr := strconv.Atoi(spec[2])
+		if minErr != nil || maxErr != nil || min > max {
+			return fmt.Errorf(`invalid int range "%s"`, parts[1])
+		}
+		generator = simulation.IntBetween(min, max)
+	case spec[0] == "bool" && len(spec) == 2:
+		probability, err := strconv.ParseFloat(spec[1], 64)
+		if err != nil || probability < 0 || probability > 1 {
+			return fmt.Errorf(`invalid bool probability "%s"`, spec[1])
+		}
+		generator = simulation.Bool(probability)
+	default:
+		return fmt.Errorf(`unknown generator "%s", expected choice:a,b,c, int:min:max or bool:probability`, parts[1])
+	}
+
+	*a = append(*a, simulation.WithAttribute(parts[0], generator))
+	return nil
+}
+
+func main() {
+	datafilePath := flag.String("datafile", "", "path of the datafile to simulate")
+	users := flag.Int("users", simulation.DefaultUsers, "number of synthetic users")
+	seed := flag.Int64("seed", 0, "seed of the attribute generators")
+	alpha := flag.Float64("alpha", 0.001, "significance level below which an allocation deviates")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	var attributes attributeFlags
+	flag.Var(&attributes, "attribute", "attribute generator as key=choice:a,b,c, key=int:min:max or key=bool:probability (repeatable)")
+	flag.Parse()
+
+	if *datafilePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	logging.SetLogLevel(logging.LogLevelWarning)
+
+	datafile, err := ioutil.ReadFile(*datafilePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	projectConfig, err := datafileprojectconfig.NewDatafileProjectConfig(datafile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	options := append([]simulation.OptionFunc{simulation.WithUsers(*users), simulation.WithSeed(*seed)}, attributes...)
+	report := simulation.NewSimulator(projectConfig, options...).Run()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else {
+		printReport(report, *alpha)
+	}
+
+	if failed(report, *alpha) {
+		os.Exit(1)
+	}
+}
+
+func failed(report simulation.Report, alpha float64) bool {
+	for _, allocations := range [][]simulation.AllocationReport{report.Experiments, report.Rollouts} {
+		for _, allocation := range allocations {
+			if allocation.Deviates(alpha) {
+				return true
+			}
+		}
+	}
+	for _, group := range report.Groups {
+		if group.Overlaps > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func printReport(report simulation.Report, alpha float64) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%d users\n", report.Users)
+
+	printAllocations := func(title string, allocations []simulation.AllocationReport) {
+		if len(allocations) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s\tVARIATION\tUSERS\tEXPECTED\tSHARE\tCHI-SQUARE\tP-VALUE\tSTATUS\n", title)
+		for _, allocation := range allocations {
+			name := allocation.Key
+			if allocation.FeatureKey != "" {
+				name = allocation.FeatureKey + " (rule " + allocation.Key + ")"
+			}
+			status := "ok"
+			if allocation.Deviates(alpha) {
+				status = "DEVIATES"
+			}
+			fmt.Fprintf(w, "%s\t\t%d\t\t\t%.2f (df %d)\t%.4f\t%s\n", name, allocation.Eligible,
+				allocation.ChiSquare, allocation.DegreesOfFreedom, allocation.PValue, status)
+
+			keys := make([]string, 0, len(allocation.Counts))
+			for key := range allocation.Counts {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				share := 0.0
+				if allocation.Eligible > 0 {
+					share = 100 * float64(allocation.Counts[key]) / float64(allocation.Eligible)
+				}
+				fmt.Fprintf(w, "\t%s\t%d\t%.1f\t%.2f%%\t\t\t\n", key, allocation.Counts[key], allocation.Expected[key], share)
+			}
+		}
+	}
+	printAllocations("EXPERIMENT", report.Experiments)
+	printAllocations("ROLLOUT", report.Rollouts)
+
+	if len(report.Groups) > 0 {
+		fmt.Fprintf(w, "\nGROUP\tPOLICY\tEXPERIMENT\tUSERS\tOVERLAPS\tSTATUS\n")
+		for _, group := range report.Groups {
+			status := "ok"
+			if group.Overlaps > 0 {
+				status = "OVERLAPS"
+			}
+			fmt.Fprintf(w, "%s\t%s\t\t\t%d\t%s\n", group.ID, group.Policy, group.Overlaps, status)
+			keys := make([]string, 0, len(group.Counts))
+			for key := range group.Counts {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Fprintf(w, "\t\t%s\t%d\t\t\n", key, group.Counts[key])
+			}
+		}
+	}
+	w.Flush()
+}
@@ -3,13 +3,17 @@ module github.com/optimizely/go-sdk
 go 1.12
 
 require (
+	github.com/alicebob/miniredis/v2 v2.11.0
+	github.com/golang/protobuf v1.3.3
 	github.com/google/uuid v1.1.1
 	github.com/json-iterator/go v1.1.7
+	github.com/mattn/go-sqlite3 v1.14.0
 	github.com/pkg/errors v0.8.1
 	github.com/pkg/profile v1.3.0
 	github.com/stretchr/testify v1.4.0
 	github.com/twmb/murmur3 v1.0.0
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
+	google.golang.org/grpc v1.27.0
 )
 
 // Work around issue wtih git.apache.org/thrift.git
@@ -18,6 +18,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -28,7 +29,9 @@ import (
 	"github.com/optimizely/go-sdk/pkg/decision"
 	"github.com/optimizely/go-sdk/pkg/entities"
 	"github.com/optimizely/go-sdk/pkg/event"
+	"github.com/optimizely/go-sdk/pkg/explain"
 	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/metrics"
 	"github.com/optimizely/go-sdk/pkg/notification"
 	"github.com/optimizely/go-sdk/pkg/utils"
 )
@@ -40,8 +43,14 @@ type OptimizelyClient struct {
 	ConfigManager      config.ProjectConfigManager
 	DecisionService    decision.Service
 	EventProcessor     event.Processor
+	eventFactory       *event.Factory
 	notificationCenter notification.Center
+	userProfileService decision.UserProfileServiceV2
 	execGroup          *utils.ExecGroup
+
+	userProfileLookupErrors metrics.Counter
+	userProfileSaveErrors   metrics.Counter
+	pipelineBuilder         decision.PipelineBuilder
 }
 
 // Activate returns the key of the variation the user is bucketed into and queues up an impression event to be sent to
@@ -73,9 +82,10 @@ func (o *OptimizelyClient) Activate(experimentKey string, userContext entities.U
 	if experimentDecision.Variation != nil && decisionContext.Experiment != nil {
 		// send an impression event
 		result = experimentDecision.Variation.Key
-		impressionEvent := event.CreateImpressionUserEvent(decisionContext.ProjectConfig, *decisionContext.Experiment, *experimentDecision.Variation, userContext)
+		impressionEvent := o.eventFactory.CreateImpressionUserEvent(decisionContext.ProjectConfig, *decisionContext.Experiment, *experimentDecision.Variation, userContext)
 		o.EventProcessor.ProcessEvent(impressionEvent)
 	}
+	o.sendHoldoutImpression(heldOut{projectConfig: decisionContext.ProjectConfig, holdout: experimentDecision.Holdout}, userContext)
 
 	return result, err
 }
@@ -83,6 +93,18 @@ func (o *OptimizelyClient) Activate(experimentKey string, userContext entities.U
 // IsFeatureEnabled returns true if the feature is enabled for the given user. If the user is part of a feature test
 // then an impression event will be queued up to be sent to the Optimizely log endpoint for results processing.
 func (o *OptimizelyClient) IsFeatureEnabled(featureKey string, userContext entities.UserContext) (result bool, err error) {
+	tracker := o.newUserProfileTracker(userContext.ID)
+	defer o.saveUserProfile(tracker)
+
+	var held heldOut
+	result, err = o.isFeatureEnabled(featureKey, userContext, tracker, &held)
+	o.sendHoldoutImpression(held, userContext)
+	return result, err
+}
+
+// isFeatureEnabled decides the feature with the tracker of the call, and records the holdout the user is in, if any,
+// for the caller to send its impression once per call
+func (o *OptimizelyClient) isFeatureEnabled(featureKey string, userContext entities.UserContext, tracker *decision.UserProfileTracker, held *heldOut) (result bool, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -100,7 +122,7 @@ func (o *OptimizelyClient) IsFeatureEnabled(featureKey string, userContext entit
 		}
 	}()
 
-	decisionContext, featureDecision, err := o.getFeatureDecision(featureKey, "", userContext)
+	decisionContext, featureDecision, err := o.getTrackedFeatureDecision(featureKey, "", userContext, tracker)
 	if err != nil {
 		logger.Error("received an error while computing feature decision", err)
 		return result, err
@@ -120,12 +142,32 @@ func (o *OptimizelyClient) IsFeatureEnabled(featureKey string, userContext entit
 
 	if featureDecision.Source == decision.FeatureTest && featureDecision.Variation != nil {
 		// send impression event for feature tests
-		impressionEvent := event.CreateImpressionUserEvent(decisionContext.ProjectConfig, featureDecision.Experiment, *featureDecision.Variation, userContext)
+		impressionEvent := o.eventFactory.CreateImpressionUserEvent(decisionContext.ProjectConfig, featureDecision.Experiment, *featureDecision.Variation, userContext)
 		o.EventProcessor.ProcessEvent(impressionEvent)
 	}
+	if featureDecision.Holdout != nil && held.holdout == nil {
+		*held = heldOut{projectConfig: decisionContext.ProjectConfig, holdout: featureDecision.Holdout}
+	}
 	return result, err
 }
 
+// heldOut is the holdout a user was found in during a call, with the project config it was decided with
+type heldOut struct {
+	projectConfig config.ProjectConfig
+	holdout       *decision.Holdout
+}
+
+// sendHoldoutImpression sends the impression of the holdout the user is in, if any. It is sent once per call, however
+// many decisions found the user in the holdout.
+func (o *OptimizelyClient) sendHoldoutImpression(held heldOut, userContext entities.UserContext) {
+	if held.holdout == nil {
+		return
+	}
+	holdout := *held.holdout
+	impressionEvent := o.eventFactory.CreateImpressionUserEvent(held.projectConfig, holdout.Experiment(), holdout.Variation(), userContext)
+	o.EventProcessor.ProcessEvent(impressionEvent)
+}
+
 // GetEnabledFeatures returns an array containing the keys of all features in the project that are enabled for the given
 // user. For features tests, impression events will be queued up to be sent to the Optimizely log endpoint for results processing.
 func (o *OptimizelyClient) GetEnabledFeatures(userContext entities.UserContext) (enabledFeatures []string, err error) {
@@ -152,12 +194,18 @@ func (o *OptimizelyClient) GetEnabledFeatures(userContext entities.UserContext)
 		return enabledFeatures, err
 	}
 
+	// the user profile is looked up and saved once for all the features
+	tracker := o.newUserProfileTracker(userContext.ID)
+	defer o.saveUserProfile(tracker)
+
+	var held heldOut
 	featureList := projectConfig.GetFeatureList()
 	for _, feature := range featureList {
-		if isEnabled, _ := o.IsFeatureEnabled(feature.Key, userContext); isEnabled {
+		if isEnabled, _ := o.isFeatureEnabled(feature.Key, userContext, tracker, &held); isEnabled {
 			enabledFeatures = append(enabledFeatures, feature.Key)
 		}
 	}
+	o.sendHoldoutImpression(held, userContext)
 	return enabledFeatures, err
 }
 
@@ -349,7 +397,7 @@ func (o *OptimizelyClient) Track(eventKey string, userContext entities.UserConte
 		return nil
 	}
 
-	userEvent := event.CreateConversionUserEvent(projectConfig, configEvent, userContext, eventTags)
+	userEvent := o.eventFactory.CreateConversionUserEvent(projectConfig, configEvent, userContext, eventTags)
 	if o.EventProcessor.ProcessEvent(userEvent) && o.notificationCenter != nil {
 		trackNotification := notification.TrackNotification{EventKey: eventKey, UserContext: userContext, EventTags: eventTags, ConversionEvent: *userEvent.Conversion}
 		if err = o.notificationCenter.Send(notification.Track, trackNotification); err != nil {
@@ -361,6 +409,13 @@ func (o *OptimizelyClient) Track(eventKey string, userContext entities.UserConte
 }
 
 func (o *OptimizelyClient) getFeatureDecision(featureKey, variableKey string, userContext entities.UserContext) (decisionContext decision.FeatureDecisionContext, featureDecision decision.FeatureDecision, err error) {
+	tracker := o.newUserProfileTracker(userContext.ID)
+	defer o.saveUserProfile(tracker)
+
+	return o.getTrackedFeatureDecision(featureKey, variableKey, userContext, tracker)
+}
+
+func (o *OptimizelyClient) getTrackedFeatureDecision(featureKey, variableKey string, userContext entities.UserContext, tracker *decision.UserProfileTracker) (decisionContext decision.FeatureDecisionContext, featureDecision decision.FeatureDecision, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -406,6 +461,7 @@ func (o *OptimizelyClient) getFeatureDecision(featureKey, variableKey string, us
 		Feature:       &feature,
 		ProjectConfig: projectConfig,
 		Variable:      variable,
+		UserProfile:   tracker,
 	}
 
 	featureDecision, err = o.DecisionService.GetFeatureDecision(decisionContext, userContext)
@@ -418,6 +474,8 @@ func (o *OptimizelyClient) getFeatureDecision(featureKey, variableKey string, us
 }
 
 func (o *OptimizelyClient) getExperimentDecision(experimentKey string, userContext entities.UserContext) (decisionContext decision.ExperimentDecisionContext, experimentDecision decision.ExperimentDecision, err error) {
+	tracker := o.newUserProfileTracker(userContext.ID)
+	defer o.saveUserProfile(tracker)
 
 	userID := userContext.ID
 	logger.Debug(fmt.Sprintf(`Evaluating experiment "%s" for user "%s".`, experimentKey, userID))
@@ -436,6 +494,7 @@ func (o *OptimizelyClient) getExperimentDecision(experimentKey string, userConte
 	decisionContext = decision.ExperimentDecisionContext{
 		Experiment:    &experiment,
 		ProjectConfig: projectConfig,
+		UserProfile:   tracker,
 	}
 
 	experimentDecision, err = o.DecisionService.GetExperimentDecision(decisionContext, userContext)
@@ -492,6 +551,122 @@ func (o *OptimizelyClient) RemoveOnTrack(id int) error {
 	return nil
 }
 
+// OnDecision registers a handler for Decision notifications. The notification carries typed details of the decision
+// in the info field matching its type.
+func (o *OptimizelyClient) OnDecision(callback func(notification.DecisionNotification)) (int, error) {
+	if isNil(o.DecisionService) {
+		return 0, errors.New("decision service is not initialized")
+	}
+	return o.DecisionService.OnDecision(callback)
+}
+
+// RemoveOnDecision removes handler for Decision notification with given id
+func (o *OptimizelyClient) RemoveOnDecision(id int) error {
+	if isNil(o.DecisionService) {
+		return errors.New("decision service is not initialized")
+	}
+	return o.DecisionService.RemoveOnDecision(id)
+}
+
+// OnConfigUpdate registers a handler for ProjectConfigUpdate notifications
+func (o *OptimizelyClient) OnConfigUpdate(callback func(notification.ProjectConfigUpdateNotification)) (int, error) {
+	if isNil(o.ConfigManager) {
+		return 0, errors.New("project config manager is not initialized")
+	}
+	return o.ConfigManager.OnProjectConfigUpdate(callback)
+}
+
+// RemoveOnConfigUpdate removes handler for ProjectConfigUpdate notification with given id
+func (o *OptimizelyClient) RemoveOnConfigUpdate(id int) error {
+	if isNil(o.ConfigManager) {
+		return errors.New("project config manager is not initialized")
+	}
+	return o.ConfigManager.RemoveOnProjectConfigUpdate(id)
+}
+
+// OnLogEvent registers a handler for LogEvent notifications, sent before a batch of events is dispatched
+func (o *OptimizelyClient) OnLogEvent(callback func(logEvent event.LogEvent)) (int, error) {
+	if isNil(o.EventProcessor) {
+		return 0, errors.New("event processor is not initialized")
+	}
+	return o.EventProcessor.OnEventDispatch(callback)
+}
+
+// RemoveOnLogEvent removes handler for LogEvent notification with given id
+func (o *OptimizelyClient) RemoveOnLogEvent(id int) error {
+	if isNil(o.EventProcessor) {
+		return errors.New("event processor is not initialized")
+	}
+	return o.EventProcessor.RemoveOnEventDispatch(id)
+}
+
+// OnEventDrop registers a handler for EventDrop notifications, sent when the event processor drops an event because
+// its queue is full
+func (o *OptimizelyClient) OnEventDrop(callback func(notification.EventDropNotification)) (int, error) {
+	if o.notificationCenter == nil {
+		return 0, fmt.Errorf("no notification center found")
+	}
+
+	handler := func(payload interface{}) {
+		if eventDropNotification, ok := payload.(notification.EventDropNotification); ok {
+			callback(eventDropNotification)
+		} else {
+			logger.Warning(fmt.Sprintf("Unable to convert notification payload %v into EventDropNotification", payload))
+		}
+	}
+	id, err := o.notificationCenter.AddHandler(notification.EventDrop, handler)
+	if err != nil {
+		logger.Warning("Problem with adding notification handler")
+		return 0, err
+	}
+	return id, nil
+}
+
+// RemoveOnEventDrop removes handler for EventDrop notification with given id
+func (o *OptimizelyClient) RemoveOnEventDrop(id int) error {
+	if o.notificationCenter == nil {
+		return fmt.Errorf("no notification center found")
+	}
+	if err := o.notificationCenter.RemoveHandler(id, notification.EventDrop); err != nil {
+		logger.Warning("Problem with removing notification handler")
+		return err
+	}
+	return nil
+}
+
+// newUserProfileTracker returns a tracker sharing the profile lookup and save between the decisions of a call,
+// or nil if there is no user profile service
+func (o *OptimizelyClient) newUserProfileTracker(userID string) *decision.UserProfileTracker {
+	if o.userProfileService == nil {
+		return nil
+	}
+	return decision.NewUserProfileTracker(userID, o.userProfileService, o.userProfileLookupErrors)
+}
+
+// saveUserProfile saves the decisions recorded in the tracker, logging any error or panic of the user profile service
+func (o *OptimizelyClient) saveUserProfile(tracker *decision.UserProfileTracker) {
+	if tracker == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Unable to save the user profile", fmt.Errorf("%v", r))
+			o.countUserProfileSaveError()
+		}
+	}()
+	if err := tracker.Save(context.Background()); err != nil {
+		logger.Error("Unable to save the user profile", err)
+		o.countUserProfileSaveError()
+	}
+}
+
+// countUserProfileSaveError counts the failed saves in the same metric as the decision services saving profiles
+func (o *OptimizelyClient) countUserProfileSaveError() {
+	if o.userProfileSaveErrors != nil {
+		o.userProfileSaveErrors.Add(1)
+	}
+}
+
 func (o *OptimizelyClient) getProjectConfig() (projectConfig config.ProjectConfig, err error) {
 
 	if isNil(o.ConfigManager) {
@@ -512,6 +687,19 @@ func (o *OptimizelyClient) GetOptimizelyConfig() (optimizelyConfig *config.Optim
 
 }
 
+// Explainer returns an explainer of the decisions of the client with its current project config. The decisions are
+// explained through the stages of the decision service of the client, and are neither saved nor reported.
+func (o *OptimizelyClient) Explainer() (*explain.Explainer, error) {
+	if o.pipelineBuilder == nil {
+		return nil, errors.New("the decisions of a custom decision service cannot be explained")
+	}
+	projectConfig, err := o.getProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+	return explain.NewExplainer(projectConfig, explain.WithPipelineBuilder(o.pipelineBuilder)), nil
+}
+
 // Close closes the Optimizely instance and stops any ongoing tasks from its children components.
 func (o *OptimizelyClient) Close() {
 	o.execGroup.TerminateAndWait()
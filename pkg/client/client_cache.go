@@ -0,0 +1,321 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package client has client facing factories
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/optimizely/go-sdk/pkg/metrics"
+)
+
+// DefaultIdleTimeout is the default time after which an unused client is evicted from an OptimizelyClientCache
+const DefaultIdleTimeout = 30 * time.Minute
+
+// ErrClientCacheClosed is returned by an OptimizelyClientCache once it is closed
+var ErrClientCacheClosed = errors.New("client cache is closed")
+
+// ClientCacheOptionFunc is used to configure the OptimizelyClientCache
+type ClientCacheOptionFunc func(c *OptimizelyClientCache)
+
+// WithSharedClientOptions sets the options of all the clients
+func WithSharedClientOptions(options ...OptionFunc) ClientCacheOptionFunc {
+	return func(c *OptimizelyClientCache) {
+		c.clientOptions = append(c.clientOptions, options...)
+	}
+}
+
+// WithClientConstructor sets the function creating the client of an SDK key, instead of an OptimizelyFactory with
+// the shared options
+func WithClientConstructor(constructor func(sdkKey string) (*OptimizelyClient, error)) ClientCacheOptionFunc {
+	return func(c *OptimizelyClientCache) {
+		c.constructor = constructor
+	}
+}
+
+// WithIdleTimeout sets the time after which an unused client is evicted. Zero disables the eviction.
+func WithIdleTimeout(idleTimeout time.Duration) ClientCacheOptionFunc {
+	return func(c *OptimizelyClientCache) {
+		c.idleTimeout = idleTimeout
+	}
+}
+
+// WithClientCacheMetricsRegistry sets the registry of the metrics of the cache
+func WithClientCacheMetricsRegistry(metricsRegistry metrics.Registry) ClientCacheOptionFunc {
+	return func(c *OptimizelyClientCache) {
+		c.metricsRegistry = metricsRegistry
+	}
+}
+
+type cachedClient struct {
+	client   *OptimizelyClient
+	lastUsed time.Time
+	// refs counts the Get calls not released yet, a removed client is closed once it drops to zero
+	refs    int
+	removed bool
+}
+
+// OptimizelyClientCache lazily creates a client per SDK key, sharing the same options. Concurrent requests for the
+// client of an SDK key create it once. Clients unused for the idle timeout are closed by the eviction loop run with
+// Start. A client got from the cache is never closed before it is released, so it should be released at the end of
+// the request it was got for.
+type OptimizelyClientCache struct {
+	clientOptions   []OptionFunc
+	constructor     func(sdkKey string) (*OptimizelyClient, error)
+	idleTimeout     time.Duration
+	metricsRegistry metrics.Registry
+
+	mutex    sync.Mutex
+	clients  map[string]*cachedClient
+	closed   bool
+	creation singleflight.Group
+	now      func() time.Time
+
+	sizeGauge          metrics.Gauge
+	createdCounter     metrics.Counter
+	createErrorCounter metrics.Counter
+	evictedCounter     metrics.Counter
+}
+
+// NewOptimizelyClientCache returns a new OptimizelyClientCache
+func NewOptimizelyClientCache(options ...ClientCacheOptionFunc) *OptimizelyClientCache {
+	c := &OptimizelyClientCache{
+		idleTimeout: DefaultIdleTimeout,
+		clients:     map[string]*cachedClient{},
+		now:         time.Now,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	if c.constructor == nil {
+		c.constructor = func(sdkKey string) (*OptimizelyClient, error) {
+			factory := OptimizelyFactory{SDKKey: sdkKey}
+			return factory.Client(c.clientOptions...)
+		}
+	}
+	if c.metricsRegistry == nil {
+		c.metricsRegistry = metrics.NewNoopRegistry()
+	}
+
+	c.sizeGauge = c.metricsRegistry.GetGauge(metrics.ClientCacheSize)
+	c.createdCounter = c.metricsRegistry.GetCounter(metrics.ClientCacheCreated)
+	c.createErrorCounter = c.metricsRegistry.GetCounter(metrics.ClientCacheCreateError)
+	c.evictedCounter = c.metricsRegistry.GetCounter(metrics.ClientCacheEvicted)
+	return c
+}
+
+// Get returns the client of the SDK key, creating it if it is not cached, and the function releasing it. The client
+// is neither evicted nor closed until it is released. Failed creations are not cached.
+func (c *OptimizelyClientCache) Get(sdkKey string) (*OptimizelyClient, func(), error) {
+	for {
+		c.mutex.Lock()
+		if c.closed {
+			c.mutex.Unlock()
+			return nil, func() {}, ErrClientCacheClosed
+		}
+		if cached, ok := c.clients[sdkKey]; ok {
+			cached.lastUsed = c.now()
+			cached.refs++
+			c.mutex.Unlock()
+			return cached.client, c.releaseFunc(cached), nil
+		}
+		c.mutex.Unlock()
+
+		// the created client is taken from the cache on the next iteration
+		if err := c.create(sdkKey); err != nil {
+			return nil, func() {}, err
+		}
+	}
+}
+
+// releaseFunc returns the function releasing the client once, and closing it if it was removed meanwhile
+func (c *OptimizelyClientCache) releaseFunc(cached *cachedClient) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mutex.Lock()
+			cached.refs--
+			cached.lastUsed = c.now()
+			closeClient := cached.removed && cached.refs == 0
+			c.mutex.Unlock()
+
+			if closeClient {
+				cached.client.Close()
+			}
+		})
+	}
+}
+
+// create creates and caches the client of the SDK key, once for concurrent calls
+func (c *OptimizelyClientCache) create(sdkKey string) error {
+	_, err, _ := c.creation.Do(sdkKey, func() (interface{}, error) {
+		// another creation may have completed since the cache was checked
+		c.mutex.Lock()
+		_, ok := c.clients[sdkKey]
+		c.mutex.Unlock()
+		if ok {
+			return nil, nil
+		}
+
+		client, err := c.constructor(sdkKey)
+		if err != nil {
+			c.createErrorCounter.Add(1)
+			return nil, err
+		}
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		if c.closed {
+			client.Close()
+			return nil, ErrClientCacheClosed
+		}
+		c.clients[sdkKey] = &cachedClient{client: client, lastUsed: c.now()}
+		c.createdCounter.Add(1)
+		c.sizeGauge.Set(float64(len(c.clients)))
+		return nil, nil
+	})
+	return err
+}
+
+// remove removes the cached client and reports whether it can be closed now. Clients in use are closed on their
+// last release instead. It must be called with the mutex held.
+func (c *OptimizelyClientCache) remove(sdkKey string, cached *cachedClient) bool {
+	delete(c.clients, sdkKey)
+	cached.removed = true
+	return cached.refs == 0
+}
+
+// Remove removes the client of the SDK key, if it is cached, and closes it once it is released
+func (c *OptimizelyClientCache) Remove(sdkKey string) {
+	c.mutex.Lock()
+	cached, ok := c.clients[sdkKey]
+	closeClient := ok && c.remove(sdkKey, cached)
+	c.sizeGauge.Set(float64(len(c.clients)))
+	c.mutex.Unlock()
+
+	if closeClient {
+		cached.client.Close()
+	}
+}
+
+// SDKKeys returns the sorted SDK keys of the cached clients
+func (c *OptimizelyClientCache) SDKKeys() []string {
+	c.mutex.Lock()
+	sdkKeys := make([]string, 0, len(c.clients))
+	for sdkKey := range c.clients {
+		sdkKeys = append(sdkKeys, sdkKey)
+	}
+	c.mutex.Unlock()
+
+	sort.Strings(sdkKeys)
+	return sdkKeys
+}
+
+// Ready returns an error naming the cached clients whose project config is not loaded, if any
+func (c *OptimizelyClientCache) Ready() error {
+	c.mutex.Lock()
+	clients := make(map[string]*OptimizelyClient, len(c.clients))
+	for sdkKey, cached := range c.clients {
+		clients[sdkKey] = cached.client
+	}
+	c.mutex.Unlock()
+
+	var notReady []string
+	for sdkKey, client := range clients {
+		if _, err := client.getProjectConfig(); err != nil {
+			notReady = append(notReady, fmt.Sprintf("%s (%v)", sdkKey, err))
+		}
+	}
+	if len(notReady) > 0 {
+		sort.Strings(notReady)
+		return fmt.Errorf("project config not loaded for SDK keys: %s", strings.Join(notReady, ", "))
+	}
+	return nil
+}
+
+// EvictIdle closes and removes the clients unused for the idle timeout, and returns how many were evicted. Clients
+// which are not released are in use, so they are never idle.
+func (c *OptimizelyClientCache) EvictIdle() int {
+	if c.idleTimeout <= 0 {
+		return 0
+	}
+
+	var evicted []*OptimizelyClient
+	c.mutex.Lock()
+	now := c.now()
+	for sdkKey, cached := range c.clients {
+		if cached.refs == 0 && now.Sub(cached.lastUsed) >= c.idleTimeout {
+			c.remove(sdkKey, cached)
+			evicted = append(evicted, cached.client)
+		}
+	}
+	c.sizeGauge.Set(float64(len(c.clients)))
+	c.mutex.Unlock()
+
+	for _, client := range evicted {
+		client.Close()
+	}
+	c.evictedCounter.Add(float64(len(evicted)))
+	return len(evicted)
+}
+
+// Start evicts the idle clients periodically until the context is done, and then closes the cache
+func (c *OptimizelyClientCache) Start(ctx context.Context) {
+	if c.idleTimeout > 0 {
+		ticker := time.NewTicker(c.idleTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.EvictIdle()
+			case <-ctx.Done():
+				c.Close()
+				return
+			}
+		}
+	}
+
+	<-ctx.Done()
+	c.Close()
+}
+
+// Close closes all the clients, the ones in use once they are released. The cache returns ErrClientCacheClosed
+// afterwards.
+func (c *OptimizelyClientCache) Close() {
+	var released []*OptimizelyClient
+	c.mutex.Lock()
+	c.closed = true
+	for sdkKey, cached := range c.clients {
+		if c.remove(sdkKey, cached) {
+			released = append(released, cached.client)
+		}
+	}
+	c.sizeGauge.Set(0)
+	c.mutex.Unlock()
+
+	for _, client := range released {
+		client.Close()
+	}
+}
@@ -0,0 +1,379 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/optimizely/go-sdk/pkg/utils"
+)
+
+type cacheTestCounter struct {
+	value float64
+}
+
+func (c *cacheTestCounter) Add(value float64) {
+	c.value += value
+}
+
+type cacheTestGauge struct {
+	value float64
+}
+
+func (g *cacheTestGauge) Set(value float64) {
+	g.value = value
+}
+
+type cacheTestRegistry struct {
+	counters map[string]*cacheTestCounter
+	gauges   map[string]*cacheTestGauge
+}
+
+func (r *cacheTestRegistry) GetCounter(key string) metrics.Counter {
+	counter := &cacheTestCounter{}
+	r.counters[key] = counter
+	return counter
+}
+
+func (r *cacheTestRegistry) GetGauge(key string) metrics.Gauge {
+	gauge := &cacheTestGauge{}
+	r.gauges[key] = gauge
+	return gauge
+}
+
+type testConstructor struct {
+	calls int32
+	fail  map[string]error
+	ready bool
+}
+
+func (c *testConstructor) construct(sdkKey string) (*OptimizelyClient, error) {
+	atomic.AddInt32(&c.calls, 1)
+	// gives concurrent callers the time to pile up on the creation
+	time.Sleep(10 * time.Millisecond)
+	if err := c.fail[sdkKey]; err != nil {
+		return nil, err
+	}
+
+	configManager := &MockProjectConfigManager{}
+	if c.ready {
+		configManager.projectConfig = &TestConfig{}
+	} else {
+		configManager.On("GetConfig").Return((*TestConfig)(nil), errors.New("datafile not fetched"))
+	}
+	return &OptimizelyClient{
+		ConfigManager: configManager,
+		execGroup:     utils.NewExecGroup(context.Background()),
+	}, nil
+}
+
+func (c *testConstructor) callCount() int {
+	return int(atomic.LoadInt32(&c.calls))
+}
+
+// closedSignal returns a channel closed once the client is closed
+func closedSignal(client *OptimizelyClient) <-chan struct{} {
+	closed := make(chan struct{})
+	client.execGroup.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(closed)
+	})
+	return closed
+}
+
+func isClosed(closed <-chan struct{}) bool {
+	select {
+	case <-closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestClientCacheGetCreatesClientsLazily(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct))
+	defer cache.Close()
+
+	assert.Equal(t, 0, constructor.callCount())
+	assert.Empty(t, cache.SDKKeys())
+
+	first, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	second, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	assert.True(t, first == second)
+	assert.Equal(t, 1, constructor.callCount())
+
+	other, release, err := cache.Get("key_2")
+	assert.NoError(t, err)
+	release()
+	assert.False(t, first == other)
+	assert.Equal(t, 2, constructor.callCount())
+	assert.Equal(t, []string{"key_1", "key_2"}, cache.SDKKeys())
+}
+
+func TestClientCacheGetCreatesClientOnceForConcurrentCalls(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct))
+	defer cache.Close()
+
+	clients := make([]*OptimizelyClient, 20)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var release func()
+			clients[i], release, _ = cache.Get("key_1")
+			release()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, constructor.callCount())
+	for _, client := range clients {
+		assert.NotNil(t, client)
+		assert.True(t, client == clients[0])
+	}
+}
+
+func TestClientCacheGetDoesNotCacheFailures(t *testing.T) {
+	expectedErr := errors.New("invalid SDK key")
+	constructor := &testConstructor{ready: true, fail: map[string]error{"key_1": expectedErr}}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct))
+	defer cache.Close()
+
+	client, release, err := cache.Get("key_1")
+	assert.Equal(t, expectedErr, err)
+	assert.Nil(t, client)
+	assert.NotNil(t, release)
+	assert.Empty(t, cache.SDKKeys())
+
+	delete(constructor.fail, "key_1")
+	client, release, err = cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	assert.NotNil(t, client)
+	assert.Equal(t, 2, constructor.callCount())
+}
+
+func TestClientCacheGetUsesSharedOptions(t *testing.T) {
+	configManager := &MockProjectConfigManager{projectConfig: &TestConfig{}}
+	cache := NewOptimizelyClientCache(WithSharedClientOptions(WithConfigManager(configManager)))
+	defer cache.Close()
+
+	client, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	defer release()
+	assert.Equal(t, configManager, client.ConfigManager)
+}
+
+func TestClientCacheEvictIdle(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	registry := &cacheTestRegistry{counters: map[string]*cacheTestCounter{}, gauges: map[string]*cacheTestGauge{}}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct), WithIdleTimeout(time.Minute),
+		WithClientCacheMetricsRegistry(registry))
+	defer cache.Close()
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	client, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	closed := closedSignal(client)
+	_, release, err = cache.Get("key_2")
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, float64(2), registry.gauges[metrics.ClientCacheSize].value)
+
+	now = now.Add(45 * time.Second)
+	_, release, err = cache.Get("key_2")
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, 0, cache.EvictIdle())
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 1, cache.EvictIdle())
+	assert.True(t, isClosed(closed))
+	assert.Equal(t, []string{"key_2"}, cache.SDKKeys())
+	assert.Equal(t, float64(1), registry.gauges[metrics.ClientCacheSize].value)
+	assert.Equal(t, float64(1), registry.counters[metrics.ClientCacheEvicted].value)
+
+	// an evicted client is created again when needed
+	_, release, err = cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, 3, constructor.callCount())
+}
+
+func TestClientCacheDoesNotEvictClientsInUse(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct), WithIdleTimeout(time.Minute))
+	defer cache.Close()
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	client, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	closed := closedSignal(client)
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, 0, cache.EvictIdle())
+	assert.Equal(t, []string{"key_1"}, cache.SDKKeys())
+
+	// the idle time starts with the release
+	release()
+	release()
+	assert.Equal(t, 0, cache.EvictIdle())
+	now = now.Add(time.Minute)
+	assert.Equal(t, 1, cache.EvictIdle())
+	assert.True(t, isClosed(closed))
+}
+
+func TestClientCacheEvictIdleDisabled(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct), WithIdleTimeout(0))
+	defer cache.Close()
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	_, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+
+	now = now.Add(24 * time.Hour)
+	assert.Equal(t, 0, cache.EvictIdle())
+	assert.Equal(t, []string{"key_1"}, cache.SDKKeys())
+}
+
+func TestClientCacheRemove(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct))
+	defer cache.Close()
+
+	client, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	closed := closedSignal(client)
+	cache.Remove("key_1")
+	cache.Remove("key_unknown")
+	assert.Empty(t, cache.SDKKeys())
+
+	// the client is closed once the request using it releases it
+	assert.False(t, isClosed(closed))
+	release()
+	assert.True(t, isClosed(closed))
+
+	client, release, err = cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	closed = closedSignal(client)
+	cache.Remove("key_1")
+	assert.True(t, isClosed(closed))
+}
+
+func TestClientCacheReady(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct))
+	defer cache.Close()
+
+	assert.NoError(t, cache.Ready())
+	_, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	assert.NoError(t, cache.Ready())
+
+	constructor.ready = false
+	_, release, err = cache.Get("key_2")
+	assert.NoError(t, err)
+	release()
+	err = cache.Ready()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "key_2")
+		assert.NotContains(t, err.Error(), "key_1")
+	}
+}
+
+func TestClientCacheMetrics(t *testing.T) {
+	constructor := &testConstructor{ready: true, fail: map[string]error{"key_bad": errors.New("invalid SDK key")}}
+	registry := &cacheTestRegistry{counters: map[string]*cacheTestCounter{}, gauges: map[string]*cacheTestGauge{}}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct), WithClientCacheMetricsRegistry(registry))
+
+	for _, sdkKey := range []string{"key_1", "key_1", "key_2", "key_bad"} {
+		_, release, _ := cache.Get(sdkKey)
+		release()
+	}
+
+	assert.Equal(t, float64(2), registry.counters[metrics.ClientCacheCreated].value)
+	assert.Equal(t, float64(1), registry.counters[metrics.ClientCacheCreateError].value)
+	assert.Equal(t, float64(2), registry.gauges[metrics.ClientCacheSize].value)
+
+	cache.Close()
+	assert.Equal(t, float64(0), registry.gauges[metrics.ClientCacheSize].value)
+}
+
+func TestClientCacheClose(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct))
+
+	client, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	closed := closedSignal(client)
+	cache.Close()
+	assert.False(t, isClosed(closed))
+	release()
+	assert.True(t, isClosed(closed))
+
+	assert.Empty(t, cache.SDKKeys())
+	client, _, err = cache.Get("key_1")
+	assert.Equal(t, ErrClientCacheClosed, err)
+	assert.Nil(t, client)
+	assert.Equal(t, 1, constructor.callCount())
+}
+
+func TestClientCacheStartClosesCacheWhenDone(t *testing.T) {
+	constructor := &testConstructor{ready: true}
+	cache := NewOptimizelyClientCache(WithClientConstructor(constructor.construct), WithIdleTimeout(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cache.Start(ctx)
+		close(done)
+	}()
+
+	_, release, err := cache.Get("key_1")
+	assert.NoError(t, err)
+	release()
+	cancel()
+	<-done
+
+	_, _, err = cache.Get("key_1")
+	assert.Equal(t, ErrClientCacheClosed, err)
+}
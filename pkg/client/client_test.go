@@ -26,9 +26,11 @@ import (
 
 	"github.com/optimizely/go-sdk/pkg/config"
 	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
 	"github.com/optimizely/go-sdk/pkg/entities"
 	"github.com/optimizely/go-sdk/pkg/event"
 	"github.com/optimizely/go-sdk/pkg/notification"
+	"github.com/optimizely/go-sdk/pkg/registry"
 	"github.com/optimizely/go-sdk/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
@@ -1680,6 +1682,29 @@ func (s *ClientTestSuiteAB) TestActivate() {
 	s.mockEventProcessor.AssertExpectations(s.T())
 }
 
+func (s *ClientTestSuiteAB) TestActivateSendsHoldoutImpression() {
+	testUserContext := entities.UserContext{ID: "test_user_1"}
+	testExperiment := makeTestExperiment("test_exp_1")
+	s.mockConfig.On("GetExperimentByKey", "test_exp_1").Return(testExperiment, nil)
+
+	holdout := decision.Holdout{ID: "holdout_1", Key: "global_holdout"}
+	heldOutDecision := decision.ExperimentDecision{Decision: decision.Decision{Reason: reasons.InHoldout}, Holdout: &holdout}
+	s.mockDecisionService.On("GetExperimentDecision", mock.Anything, testUserContext).Return(heldOutDecision, nil)
+	s.mockEventProcessor.On("ProcessEvent", mock.MatchedBy(func(userEvent event.UserEvent) bool {
+		return userEvent.Impression.ExperimentID == holdout.ID && userEvent.Impression.VariationID == holdout.ID
+	}))
+
+	testClient := OptimizelyClient{
+		ConfigManager:   s.mockConfigManager,
+		DecisionService: s.mockDecisionService,
+		EventProcessor:  s.mockEventProcessor,
+	}
+	variationKey, err := testClient.Activate("test_exp_1", testUserContext)
+	s.NoError(err)
+	s.Equal("", variationKey)
+	s.mockEventProcessor.AssertNumberOfCalls(s.T(), "ProcessEvent", 1)
+}
+
 func (s *ClientTestSuiteAB) TestActivatePanics() {
 	// ensure that we recover if the SDK panics while getting variation
 	testUserContext := entities.UserContext{}
@@ -1831,6 +1856,74 @@ func (s *ClientTestSuiteFM) TestIsFeatureEnabled() {
 	s.mockDecisionService.AssertExpectations(s.T())
 }
 
+func (s *ClientTestSuiteFM) TestIsFeatureEnabledSendsHoldoutImpression() {
+	testUserContext := entities.UserContext{ID: "test_user_1"}
+	testVariation := makeTestVariation("green", true)
+	testExperiment := makeTestExperimentWithVariations("number_1", []entities.Variation{testVariation})
+	testFeature := makeTestFeatureWithExperiment("feature_1", testExperiment)
+	testVariable := entities.Variable{ID: "var_1", Key: "var_1", DefaultValue: "default", Type: entities.String}
+	s.mockConfig.On("GetFeatureByKey", testFeature.Key).Return(testFeature, nil)
+	s.mockConfig.On("GetVariableByKey", testFeature.Key, testVariable.Key).Return(testVariable, nil)
+
+	holdout := decision.Holdout{ID: "holdout_1", Key: "global_holdout"}
+	heldOutDecision := decision.FeatureDecision{
+		Decision: decision.Decision{Reason: reasons.InHoldout},
+		Source:   decision.HoldoutSource,
+		Holdout:  &holdout,
+	}
+	s.mockDecisionService.On("GetFeatureDecision", mock.Anything, testUserContext).Return(heldOutDecision, nil)
+	s.mockEventProcessor.On("ProcessEvent", mock.MatchedBy(func(userEvent event.UserEvent) bool {
+		return userEvent.Impression.ExperimentID == holdout.ID && userEvent.Impression.VariationID == holdout.ID
+	}))
+
+	client := OptimizelyClient{
+		ConfigManager:   s.mockConfigManager,
+		DecisionService: s.mockDecisionService,
+		EventProcessor:  s.mockEventProcessor,
+	}
+	result, err := client.IsFeatureEnabled(testFeature.Key, testUserContext)
+	s.NoError(err)
+	s.False(result)
+
+	// the variables of held out users have their default values and send no impression
+	value, err := client.GetFeatureVariableString(testFeature.Key, testVariable.Key, testUserContext)
+	s.NoError(err)
+	s.Equal("default", value)
+	s.mockEventProcessor.AssertNumberOfCalls(s.T(), "ProcessEvent", 1)
+}
+
+func (s *ClientTestSuiteFM) TestGetEnabledFeaturesSendsOneHoldoutImpression() {
+	testUserContext := entities.UserContext{ID: "test_user_1"}
+	testFeature1 := makeTestFeatureWithExperiment("feature_1", makeTestExperimentWithVariations("number_1", []entities.Variation{makeTestVariation("a", true)}))
+	testFeature2 := makeTestFeatureWithExperiment("feature_2", makeTestExperimentWithVariations("number_2", []entities.Variation{makeTestVariation("b", true)}))
+	s.mockConfig.On("GetFeatureByKey", testFeature1.Key).Return(testFeature1, nil)
+	s.mockConfig.On("GetFeatureByKey", testFeature2.Key).Return(testFeature2, nil)
+	s.mockConfig.On("GetFeatureList").Return([]entities.Feature{testFeature1, testFeature2})
+	s.mockConfigManager.On("GetConfig").Return(s.mockConfig, nil)
+
+	holdout := decision.Holdout{ID: "holdout_1", Key: "global_holdout"}
+	heldOutDecision := decision.FeatureDecision{
+		Decision: decision.Decision{Reason: reasons.InHoldout},
+		Source:   decision.HoldoutSource,
+		Holdout:  &holdout,
+	}
+	s.mockDecisionService.On("GetFeatureDecision", mock.Anything, testUserContext).Return(heldOutDecision, nil)
+	s.mockEventProcessor.On("ProcessEvent", mock.MatchedBy(func(userEvent event.UserEvent) bool {
+		return userEvent.Impression.ExperimentID == holdout.ID && userEvent.Impression.VariationID == holdout.ID
+	}))
+
+	client := OptimizelyClient{
+		ConfigManager:   s.mockConfigManager,
+		DecisionService: s.mockDecisionService,
+		EventProcessor:  s.mockEventProcessor,
+	}
+	result, err := client.GetEnabledFeatures(testUserContext)
+	s.NoError(err)
+	s.Empty(result)
+	s.mockDecisionService.AssertNumberOfCalls(s.T(), "GetFeatureDecision", 2)
+	s.mockEventProcessor.AssertNumberOfCalls(s.T(), "ProcessEvent", 1)
+}
+
 func (s *ClientTestSuiteFM) TestIsFeatureEnabledWithDecisionError() {
 	testUserContext := entities.UserContext{ID: "test_user_1"}
 
@@ -1964,6 +2057,92 @@ func (s *ClientTestSuiteFM) TestGetEnabledFeatures() {
 	s.mockDecisionService.AssertExpectations(s.T())
 }
 
+func (s *ClientTestSuiteFM) TestGetEnabledFeaturesSharesUserProfile() {
+	testUserContext := entities.UserContext{ID: "test_user_1"}
+	testVariationEnabled := makeTestVariation("a", true)
+	testVariationDisabled := makeTestVariation("b", false)
+	testExperimentEnabled := makeTestExperimentWithVariations("enabled_exp", []entities.Variation{testVariationEnabled})
+	testExperimentDisabled := makeTestExperimentWithVariations("disabled_exp", []entities.Variation{testVariationDisabled})
+	testFeatureEnabled := makeTestFeatureWithExperiment("enabled_feat", testExperimentEnabled)
+	testFeatureDisabled := makeTestFeatureWithExperiment("disabled_feat", testExperimentDisabled)
+
+	s.mockConfig.On("GetFeatureByKey", testFeatureEnabled.Key).Return(testFeatureEnabled, nil)
+	s.mockConfig.On("GetFeatureByKey", testFeatureDisabled.Key).Return(testFeatureDisabled, nil)
+	s.mockConfig.On("GetFeatureList").Return([]entities.Feature{testFeatureEnabled, testFeatureDisabled})
+	s.mockConfigManager.On("GetConfig").Return(s.mockConfig, nil)
+
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", testUserContext.ID).Return(decision.UserProfile{ID: testUserContext.ID}, nil).Once()
+	mockUserProfileService.On("Save", decision.UserProfile{
+		ID: testUserContext.ID,
+		ExperimentBucketMap: map[decision.UserDecisionKey]string{
+			decision.NewUserDecisionKey(testExperimentEnabled.ID):  testVariationEnabled.ID,
+			decision.NewUserDecisionKey(testExperimentDisabled.ID): testVariationDisabled.ID,
+		},
+	}).Return(nil).Once()
+
+	client := OptimizelyClient{
+		ConfigManager:      s.mockConfigManager,
+		DecisionService:    &ProfilingDecisionService{},
+		userProfileService: mockUserProfileService,
+	}
+	result, err := client.GetEnabledFeatures(testUserContext)
+	s.NoError(err)
+	s.ElementsMatch(result, []string{testFeatureEnabled.Key})
+	mockUserProfileService.AssertExpectations(s.T())
+}
+
+func (s *ClientTestSuiteFM) TestGetEnabledFeaturesCountsUserProfileLookupErrorOnce() {
+	testUserContext := entities.UserContext{ID: "test_user_1"}
+	testFeature1 := makeTestFeatureWithExperiment("feature_1", makeTestExperimentWithVariations("number_1", []entities.Variation{makeTestVariation("a", true)}))
+	testFeature2 := makeTestFeatureWithExperiment("feature_2", makeTestExperimentWithVariations("number_2", []entities.Variation{makeTestVariation("b", true)}))
+	s.mockConfig.On("GetFeatureByKey", testFeature1.Key).Return(testFeature1, nil)
+	s.mockConfig.On("GetFeatureByKey", testFeature2.Key).Return(testFeature2, nil)
+	s.mockConfig.On("GetFeatureList").Return([]entities.Feature{testFeature1, testFeature2})
+	s.mockConfigManager.On("GetConfig").Return(s.mockConfig, nil)
+
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", testUserContext.ID).Return(decision.UserProfile{}, errors.New("store unavailable")).Once()
+
+	lookupErrors := &cacheTestCounter{}
+	client := OptimizelyClient{
+		ConfigManager:           s.mockConfigManager,
+		DecisionService:         &ProfilingDecisionService{},
+		userProfileService:      mockUserProfileService,
+		userProfileLookupErrors: lookupErrors,
+	}
+	_, err := client.GetEnabledFeatures(testUserContext)
+	s.NoError(err)
+	s.Equal(1.0, lookupErrors.value)
+	mockUserProfileService.AssertExpectations(s.T())
+}
+
+func (s *ClientTestSuiteFM) TestIsFeatureEnabledCountsUserProfileSaveErrors() {
+	testUserContext := entities.UserContext{ID: "test_user_1"}
+	testVariation := makeTestVariation("a", true)
+	testExperiment := makeTestExperimentWithVariations("enabled_exp", []entities.Variation{testVariation})
+	testFeature := makeTestFeatureWithExperiment("enabled_feat", testExperiment)
+
+	s.mockConfig.On("GetFeatureByKey", testFeature.Key).Return(testFeature, nil)
+	s.mockConfigManager.On("GetConfig").Return(s.mockConfig, nil)
+
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", testUserContext.ID).Return(decision.UserProfile{ID: testUserContext.ID}, nil)
+	mockUserProfileService.On("Save", mock.Anything).Return(errors.New("store unavailable"))
+
+	saveErrors := &cacheTestCounter{}
+	client := OptimizelyClient{
+		ConfigManager:         s.mockConfigManager,
+		DecisionService:       &ProfilingDecisionService{},
+		userProfileService:    mockUserProfileService,
+		userProfileSaveErrors: saveErrors,
+	}
+	result, err := client.IsFeatureEnabled(testFeature.Key, testUserContext)
+	s.NoError(err)
+	s.True(result)
+	s.Equal(1.0, saveErrors.value)
+}
+
 func (s *ClientTestSuiteFM) TestGetEnabledFeaturesErrorCases() {
 	testUserContext := entities.UserContext{ID: "test_user_1"}
 
@@ -2356,6 +2535,63 @@ func (s *ClientTestSuiteTrackNotification) TestRemoveOnTrackThrowsErrorWhenRemov
 	mockNotificationCenter.AssertExpectations(s.T())
 }
 
+func TestOnDecision(t *testing.T) {
+	sdkKey := "on_decision_sdk_key"
+	client := OptimizelyClient{
+		DecisionService: decision.NewCompositeService(sdkKey),
+	}
+
+	var note notification.DecisionNotification
+	id, err := client.OnDecision(func(decisionNotification notification.DecisionNotification) {
+		note = decisionNotification
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, id)
+
+	sent := notification.DecisionNotification{
+		Type:       notification.ABTest,
+		ABTestInfo: &notification.ABTestDecisionInfo{ExperimentKey: "test_exp_1", VariationKey: "var_1"},
+	}
+	assert.NoError(t, registry.GetNotificationCenter(sdkKey).Send(notification.Decision, sent))
+	assert.Equal(t, sent, note)
+
+	assert.NoError(t, client.RemoveOnDecision(id))
+	note = notification.DecisionNotification{}
+	assert.NoError(t, registry.GetNotificationCenter(sdkKey).Send(notification.Decision, sent))
+	assert.Nil(t, note.ABTestInfo)
+}
+
+func TestListenersWithoutComponents(t *testing.T) {
+	client := OptimizelyClient{}
+
+	_, err := client.OnDecision(func(notification.DecisionNotification) {})
+	assert.Error(t, err)
+	assert.Error(t, client.RemoveOnDecision(1))
+
+	_, err = client.OnConfigUpdate(func(notification.ProjectConfigUpdateNotification) {})
+	assert.Error(t, err)
+	assert.Error(t, client.RemoveOnConfigUpdate(1))
+
+	_, err = client.OnLogEvent(func(event.LogEvent) {})
+	assert.Error(t, err)
+	assert.Error(t, client.RemoveOnLogEvent(1))
+}
+
+func TestOnLogEventAndConfigUpdateDelegate(t *testing.T) {
+	client := OptimizelyClient{
+		ConfigManager:  ValidProjectConfigManager(),
+		EventProcessor: new(MockProcessor),
+	}
+
+	_, err := client.OnConfigUpdate(func(notification.ProjectConfigUpdateNotification) {})
+	assert.NoError(t, err)
+	assert.NoError(t, client.RemoveOnConfigUpdate(0))
+
+	_, err = client.OnLogEvent(func(event.LogEvent) {})
+	assert.NoError(t, err)
+	assert.NoError(t, client.RemoveOnLogEvent(0))
+}
+
 func TestClientTestSuite(t *testing.T) {
 	suite.Run(t, new(ClientTestSuiteAB))
 	suite.Run(t, new(ClientTestSuiteFM))
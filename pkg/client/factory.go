@@ -24,8 +24,10 @@ import (
 
 	"github.com/optimizely/go-sdk/pkg/config"
 	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/decision/userprofile"
 	"github.com/optimizely/go-sdk/pkg/event"
 	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/optimizely/go-sdk/pkg/notification"
 	"github.com/optimizely/go-sdk/pkg/registry"
 	"github.com/optimizely/go-sdk/pkg/utils"
 )
@@ -35,14 +37,30 @@ type OptimizelyFactory struct {
 	SDKKey   string
 	Datafile []byte
 
-	configManager      config.ProjectConfigManager
-	ctx                context.Context
-	decisionService    decision.Service
-	eventDispatcher    event.Dispatcher
-	eventProcessor     event.Processor
-	userProfileService decision.UserProfileService
-	overrideStore      decision.ExperimentOverrideStore
-	metricsRegistry    metrics.Registry
+	configManager         config.ProjectConfigManager
+	pollingOptions        []config.OptionFunc
+	ctx                   context.Context
+	decisionService       decision.Service
+	eventDispatcher       event.Dispatcher
+	eventProcessor        event.Processor
+	eventProcessorOptions []event.BPOptionConfig
+	eventFactoryOptions   []event.FactoryOptionFunc
+	userProfileService    decision.UserProfileService
+	userProfileServiceV2  decision.UserProfileServiceV2
+	writeBehindOptions    []userprofile.WriteBehindOptionFunc
+	writeBehind           bool
+	cachingOptions        []userprofile.CachingOptionFunc
+	caching               bool
+	stickyRollouts        bool
+	overrideStore         decision.ExperimentOverrideStore
+	metricsRegistry       metrics.Registry
+	notificationCenter    notification.Center
+	asyncNotifications    bool
+	asyncOptions          []notification.AsyncOptionFunc
+
+	experimentPipelineOptions []func(*decision.ExperimentPipeline)
+	featurePipelineOptions    []func(*decision.FeaturePipeline)
+	holdout                   *decision.Holdout
 }
 
 // OptionFunc is used to provide custom client configuration to the OptimizelyFactory.
@@ -55,7 +73,7 @@ func (f OptimizelyFactory) Client(clientOptions ...OptionFunc) (*OptimizelyClien
 		opt(&f)
 	}
 
-	if f.SDKKey == "" && f.Datafile == nil && f.configManager == nil {
+	if f.SDKKey == "" && f.Datafile == nil && f.configManager == nil && f.pollingOptions == nil {
 		return nil, errors.New("unable to instantiate client: no project config manager, SDK key, or a Datafile provided")
 	}
 
@@ -74,15 +92,23 @@ func (f OptimizelyFactory) Client(clientOptions ...OptionFunc) (*OptimizelyClien
 	}
 
 	eg := utils.NewExecGroup(ctx)
+	// the notification center is registered before any component looks it up
+	notificationCenter := f.notificationCenter
+	if notificationCenter == nil && f.asyncNotifications {
+		asyncOptions := append([]notification.AsyncOptionFunc{notification.WithNotificationMetrics(metricsRegistry)}, f.asyncOptions...)
+		notificationCenter = notification.NewAsyncNotificationCenter(asyncOptions...)
+	}
+	if notificationCenter != nil {
+		registry.SetNotificationCenter(f.SDKKey, notificationCenter)
+	}
 	appClient := &OptimizelyClient{execGroup: eg, notificationCenter: registry.GetNotificationCenter(f.SDKKey)}
+	appClient.eventFactory = event.NewFactory(f.eventFactoryOptions...)
 
 	if f.configManager != nil {
 		appClient.ConfigManager = f.configManager
 	} else {
-		appClient.ConfigManager = config.NewPollingProjectConfigManager(
-			f.SDKKey,
-			config.WithInitialDatafile(f.Datafile),
-		)
+		pollingOptions := append([]config.OptionFunc{config.WithInitialDatafile(f.Datafile)}, f.pollingOptions...)
+		appClient.ConfigManager = config.NewPollingProjectConfigManager(f.SDKKey, pollingOptions...)
 	}
 
 	if f.eventProcessor != nil {
@@ -95,25 +121,52 @@ func (f OptimizelyFactory) Client(clientOptions ...OptionFunc) (*OptimizelyClien
 			eventProcessorOptions = append(eventProcessorOptions, event.WithEventDispatcher(f.eventDispatcher))
 		}
 		eventProcessorOptions = append(eventProcessorOptions, event.WithEventDispatcherMetrics(metricsRegistry))
+		eventProcessorOptions = append(eventProcessorOptions, event.WithEventFactory(appClient.eventFactory))
+		eventProcessorOptions = append(eventProcessorOptions, f.eventProcessorOptions...)
 		appClient.EventProcessor = event.NewBatchEventProcessor(eventProcessorOptions...)
 	}
 
+	userProfileService := f.userProfileServiceV2
+	if userProfileService == nil {
+		userProfileService = decision.NewUserProfileServiceAdapter(f.userProfileService)
+	}
+	var writeBehindService *userprofile.WriteBehindService
+	if f.writeBehind && userProfileService != nil {
+		writeBehindService = userprofile.NewWriteBehindService(userProfileService, f.writeBehindOptions...)
+		userProfileService = writeBehindService
+	}
+	if f.caching && userProfileService != nil {
+		cachingOptions := append([]userprofile.CachingOptionFunc{userprofile.WithCacheMetricsRegistry(metricsRegistry)}, f.cachingOptions...)
+		userProfileService = userprofile.NewCachingService(userProfileService, cachingOptions...)
+	}
+	appClient.userProfileService = userProfileService
+	appClient.userProfileLookupErrors = metricsRegistry.GetCounter(metrics.UserProfileLookupError)
+	appClient.userProfileSaveErrors = metricsRegistry.GetCounter(metrics.UserProfileSaveError)
+
 	if f.decisionService != nil {
 		appClient.DecisionService = f.decisionService
 	} else {
-		var experimentServiceOptions []decision.CESOptionFunc
-		if f.userProfileService != nil {
-			experimentServiceOptions = append(experimentServiceOptions, decision.WithUserProfileService(f.userProfileService))
+		appClient.pipelineBuilder = func(instrumentation decision.Instrumentation) (*decision.CompositeExperimentService, *decision.CompositeFeatureService, error) {
+			return f.buildPipelines(userProfileService, metricsRegistry, instrumentation)
 		}
-		if f.overrideStore != nil {
-			experimentServiceOptions = append(experimentServiceOptions, decision.WithOverrideStore(f.overrideStore))
+		compositeExperimentService, compositeFeatureService, err := appClient.pipelineBuilder(decision.Instrumentation{})
+		if err != nil {
+			return nil, err
+		}
+
+		compositeServiceOptions := []decision.CSOptionFunc{
+			decision.WithCompositeExperimentService(compositeExperimentService),
+			decision.WithCompositeFeatureService(compositeFeatureService),
 		}
-		compositeExperimentService := decision.NewCompositeExperimentService(experimentServiceOptions...)
-		compositeService := decision.NewCompositeService(f.SDKKey, decision.WithCompositeExperimentService(compositeExperimentService))
+		compositeService := decision.NewCompositeService(f.SDKKey, compositeServiceOptions...)
 		appClient.DecisionService = compositeService
 	}
 
 	// Initialize the default services with the execution context
+	if defaultCenter, ok := appClient.notificationCenter.(*notification.DefaultCenter); ok {
+		eg.Go(defaultCenter.Start)
+	}
+
 	if pollingConfigManager, ok := appClient.ConfigManager.(*config.PollingProjectConfigManager); ok {
 		eg.Go(pollingConfigManager.Start)
 	}
@@ -122,14 +175,76 @@ func (f OptimizelyFactory) Client(clientOptions ...OptionFunc) (*OptimizelyClien
 		eg.Go(batchProcessor.Start)
 	}
 
+	if writeBehindService != nil {
+		eg.Go(writeBehindService.Start)
+	}
+
 	return appClient, nil
 }
 
-// WithPollingConfigManager sets polling config manager on a client.
+// buildPipelines builds the experiment and feature decision services configured by the options, with their stages
+// instrumented by the given instrumentation
+func (f OptimizelyFactory) buildPipelines(userProfileService decision.UserProfileServiceV2, metricsRegistry metrics.Registry,
+	instrumentation decision.Instrumentation) (*decision.CompositeExperimentService, *decision.CompositeFeatureService, error) {
+	if userProfileService != nil && instrumentation.UserProfileService != nil {
+		userProfileService = instrumentation.UserProfileService(userProfileService)
+	}
+
+	var experimentServiceOptions []decision.CESOptionFunc
+	if userProfileService != nil {
+		experimentServiceOptions = append(experimentServiceOptions, decision.WithUserProfileServiceV2(userProfileService))
+	}
+	experimentServiceOptions = append(experimentServiceOptions, decision.WithMetricsRegistry(metricsRegistry))
+	if f.overrideStore != nil {
+		experimentServiceOptions = append(experimentServiceOptions, decision.WithOverrideStore(f.overrideStore))
+	}
+	experimentServiceOptions = append(experimentServiceOptions, decision.WithExperimentBucketerOptions(instrumentation.BucketerOptions...))
+	experimentPipeline := decision.NewExperimentPipeline(experimentServiceOptions...)
+	if f.holdout != nil {
+		experimentPipeline.InsertBefore(decision.BucketingStage, decision.HoldoutStage,
+			decision.NewExperimentHoldoutService(*f.holdout))
+	}
+	for _, configure := range f.experimentPipelineOptions {
+		configure(experimentPipeline)
+	}
+	compositeExperimentService, err := experimentPipeline.Wrap(instrumentation.ExperimentStage).Build()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rolloutOptions []decision.RSOptionFunc
+	if len(instrumentation.BucketerOptions) > 0 {
+		rolloutOptions = append(rolloutOptions, decision.WithRolloutBucketerOptions(instrumentation.BucketerOptions...))
+	}
+	if f.stickyRollouts && userProfileService != nil {
+		rolloutOptions = append(rolloutOptions,
+			decision.WithRolloutUserProfileService(userProfileService),
+			decision.WithRolloutMetricsRegistry(metricsRegistry),
+		)
+	}
+	featurePipeline := decision.NewFeaturePipeline(compositeExperimentService,
+		decision.WithRolloutService(decision.NewRolloutService(rolloutOptions...)))
+	if f.holdout != nil {
+		featurePipeline.InsertBefore(decision.RolloutStage, decision.HoldoutStage,
+			decision.NewFeatureHoldoutService(*f.holdout))
+	}
+	for _, configure := range f.featurePipelineOptions {
+		configure(featurePipeline)
+	}
+	compositeFeatureService, err := featurePipeline.Wrap(instrumentation.FeatureStage).Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return compositeExperimentService, compositeFeatureService, nil
+}
+
+// WithPollingConfigManager sets polling config manager on a client. The config manager is created with the client,
+// once its notification center is set.
 func WithPollingConfigManager(pollingInterval time.Duration, initDataFile []byte) OptionFunc {
 	return func(f *OptimizelyFactory) {
-		f.configManager = config.NewPollingProjectConfigManager(f.SDKKey, config.WithInitialDatafile(initDataFile),
-			config.WithPollingInterval(pollingInterval))
+		f.configManager = nil
+		f.pollingOptions = []config.OptionFunc{config.WithInitialDatafile(initDataFile),
+			config.WithPollingInterval(pollingInterval)}
 	}
 }
 
@@ -137,6 +252,7 @@ func WithPollingConfigManager(pollingInterval time.Duration, initDataFile []byte
 func WithConfigManager(configManager config.ProjectConfigManager) OptionFunc {
 	return func(f *OptimizelyFactory) {
 		f.configManager = configManager
+		f.pollingOptions = nil
 	}
 }
 
@@ -154,6 +270,66 @@ func WithUserProfileService(userProfileService decision.UserProfileService) Opti
 	}
 }
 
+// WithUserProfileServiceV2 sets the user profile service reporting store failures on the decision service.
+// It takes precedence over WithUserProfileService.
+func WithUserProfileServiceV2(userProfileService decision.UserProfileServiceV2) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.userProfileServiceV2 = userProfileService
+	}
+}
+
+// WithUserProfileWriteBehind acknowledges user profile saves immediately and writes them to the user profile
+// service in the background. Pending profiles are written when the client is closed.
+func WithUserProfileWriteBehind(options ...userprofile.WriteBehindOptionFunc) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.writeBehind = true
+		f.writeBehindOptions = options
+	}
+}
+
+// WithUserProfileCache keeps the most recently used user profiles in memory in front of the user profile service.
+// Cache hits and misses are counted in the metrics registry of the client.
+func WithUserProfileCache(options ...userprofile.CachingOptionFunc) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.caching = true
+		f.cachingOptions = options
+	}
+}
+
+// WithStickyRollouts saves the feature rollout decisions in the user profile service, and reuses them when the rollout
+// traffic changes. It has no effect without a user profile service or when a custom decision service is set.
+func WithStickyRollouts() OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.stickyRollouts = true
+	}
+}
+
+// WithExperimentPipeline customizes the stages of the default experiment decision service, for instance to insert
+// a custom decision stage. It has no effect when a custom decision service is set.
+func WithExperimentPipeline(configure func(*decision.ExperimentPipeline)) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.experimentPipelineOptions = append(f.experimentPipelineOptions, configure)
+	}
+}
+
+// WithFeaturePipeline customizes the stages of the default feature decision service, for instance to insert
+// a custom decision stage. It has no effect when a custom decision service is set.
+func WithFeaturePipeline(configure func(*decision.FeaturePipeline)) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.featurePipelineOptions = append(f.featurePipelineOptions, configure)
+	}
+}
+
+// WithHoldout excludes a slice of the traffic from all experiments and feature flags. Held out users get no
+// variation and every feature off unless they are forced into a variation or whitelisted, and Activate and
+// IsFeatureEnabled send an impression of the holdout for them.
+// It has no effect when a custom decision service is set.
+func WithHoldout(holdout decision.Holdout) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.holdout = &holdout
+	}
+}
+
 // WithExperimentOverrides sets the experiment override store on the decision service.
 func WithExperimentOverrides(overrideStore decision.ExperimentOverrideStore) OptionFunc {
 	return func(f *OptimizelyFactory) {
@@ -161,10 +337,12 @@ func WithExperimentOverrides(overrideStore decision.ExperimentOverrideStore) Opt
 	}
 }
 
-// WithBatchEventProcessor sets event processor on a client.
+// WithBatchEventProcessor sets event processor on a client. The event processor is created with the client, once its
+// notification center is set.
 func WithBatchEventProcessor(batchSize, queueSize int, flushInterval time.Duration) OptionFunc {
 	return func(f *OptimizelyFactory) {
-		f.eventProcessor = event.NewBatchEventProcessor(event.WithBatchSize(batchSize),
+		f.eventProcessor = nil
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithBatchSize(batchSize),
 			event.WithQueueSize(queueSize), event.WithFlushInterval(flushInterval))
 	}
 }
@@ -176,6 +354,79 @@ func WithEventProcessor(eventProcessor event.Processor) OptionFunc {
 	}
 }
 
+// WithEventSink adds a sink receiving a copy of every user event to the default batch event processor.
+// It has no effect when a custom event processor is set.
+func WithEventSink(name string, sink event.Sink, options ...event.SinkOptionFunc) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithEventSink(name, sink, options...))
+	}
+}
+
+// WithEventCompression gzips event payloads of at least minBytes bytes sent by the default event dispatcher.
+// It has no effect when a custom event processor or dispatcher is set.
+func WithEventCompression(minBytes int) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithCompressionThreshold(minBytes))
+	}
+}
+
+// WithEventMaxPayloadBytes limits the serialized size of the event batches built by the default event processor.
+// It has no effect when a custom event processor is set.
+func WithEventMaxPayloadBytes(maxBytes int) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithMaxPayloadBytes(maxBytes))
+	}
+}
+
+// WithImpressionDeduplication drops the impressions of a user for an experiment and variation already sent within the
+// window, remembering the impressions of at most capacity such combinations. It has no effect when a custom event
+// processor is set.
+func WithImpressionDeduplication(capacity int, window time.Duration) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithImpressionDeduplication(capacity, window))
+	}
+}
+
+// WithImpressionSampling sends the impressions of a fraction of the users of the experiments, keyed by ID, with a
+// sampling rate between 0 and 1. It has no effect when a custom event processor is set.
+func WithImpressionSampling(rates map[string]float64) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithImpressionSampling(rates))
+	}
+}
+
+// WithImpressionRateLimit drops the impressions exceeding perSecond impressions per second, in bursts of up to burst
+// impressions. It has no effect when a custom event processor is set.
+func WithImpressionRateLimit(perSecond float64, burst int) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithImpressionRateLimit(perSecond, burst))
+	}
+}
+
+// WithEventOverflowPolicy sets what the default event processor does with new events when its queue is full.
+// It has no effect when a custom event processor is set.
+func WithEventOverflowPolicy(policy event.OverflowPolicy) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventProcessorOptions = append(f.eventProcessorOptions, event.WithOverflowPolicy(policy))
+	}
+}
+
+// WithEventClock sets the clock timestamping the events, for instance a fixed one to snapshot test the payloads.
+// Only the user events are affected when a custom event processor is set.
+func WithEventClock(clock event.Clock) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventFactoryOptions = append(f.eventFactoryOptions, event.WithClock(clock))
+	}
+}
+
+// WithEventIDGenerator sets the generator of the event UUIDs, for instance a sequential one to snapshot test the
+// payloads. Only the user events are affected when a custom event processor is set.
+func WithEventIDGenerator(idGenerator event.IDGenerator) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.eventFactoryOptions = append(f.eventFactoryOptions, event.WithIDGenerator(idGenerator))
+	}
+}
+
 // WithEventDispatcher sets event dispatcher on the factory.
 func WithEventDispatcher(eventDispatcher event.Dispatcher) OptionFunc {
 	return func(f *OptimizelyFactory) {
@@ -197,6 +448,25 @@ func WithMetricsRegistry(metricsRegistry metrics.Registry) OptionFunc {
 	}
 }
 
+// WithNotificationCenter sets the notification center used by the client and its components. Components created
+// before the client, such as a custom config manager, keep the notification center registered for the SDK key when
+// they were created.
+func WithNotificationCenter(notificationCenter notification.Center) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.notificationCenter = notificationCenter
+	}
+}
+
+// WithAsyncNotifications delivers the notifications off the calling goroutine, with a notification center created
+// by notification.NewAsyncNotificationCenter. The dropped notifications and the panics of the handlers are counted
+// in the metrics registry of the client. It has no effect when a notification center is set.
+func WithAsyncNotifications(options ...notification.AsyncOptionFunc) OptionFunc {
+	return func(f *OptimizelyFactory) {
+		f.asyncNotifications = true
+		f.asyncOptions = options
+	}
+}
+
 // StaticClient returns a client initialized with a static project config.
 func (f OptimizelyFactory) StaticClient() (*OptimizelyClient, error) {
 	var configManager config.ProjectConfigManager
@@ -27,8 +27,13 @@ import (
 	"github.com/optimizely/go-sdk/pkg/config"
 	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
 	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/decision/userprofile"
+	"github.com/optimizely/go-sdk/pkg/entities"
 	"github.com/optimizely/go-sdk/pkg/event"
 	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/optimizely/go-sdk/pkg/notification"
+	"github.com/optimizely/go-sdk/pkg/registry"
 	"github.com/optimizely/go-sdk/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
@@ -162,6 +167,147 @@ func TestClientWithCustomDecisionServiceOptions(t *testing.T) {
 	assert.NotNil(t, optimizelyClient.DecisionService)
 }
 
+func TestClientWithUserProfileServiceV2(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "1212"}
+
+	userProfileService := decision.NewUserProfileServiceAdapter(new(MockUserProfileService))
+	optimizelyClient, err := factory.Client(WithUserProfileServiceV2(userProfileService))
+	assert.NoError(t, err)
+	assert.NotNil(t, optimizelyClient.DecisionService)
+}
+
+func TestClientWithUserProfileWriteBehind(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "write_behind_sdk_key"}
+
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	profile := decision.UserProfile{ID: "test_user"}
+	mockUserProfileService.On("Save", profile).Return(nil).Once()
+	optimizelyClient, err := factory.Client(WithUserProfileServiceV2(mockUserProfileService),
+		WithUserProfileWriteBehind(userprofile.WithWriteBehindInterval(time.Hour)))
+	assert.NoError(t, err)
+
+	// the profile is only written when the client is closed
+	assert.NoError(t, optimizelyClient.userProfileService.Save(context.Background(), profile))
+	mockUserProfileService.AssertNotCalled(t, "Save", profile)
+	optimizelyClient.Close()
+	mockUserProfileService.AssertExpectations(t)
+}
+
+func TestClientWithUserProfileCache(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "cache_sdk_key"}
+
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	profile := decision.UserProfile{ID: "test_user"}
+	mockUserProfileService.On("Lookup", "test_user").Return(profile, nil).Once()
+	optimizelyClient, err := factory.Client(WithUserProfileServiceV2(mockUserProfileService), WithUserProfileCache())
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		cached, err := optimizelyClient.userProfileService.Lookup(context.Background(), "test_user")
+		assert.NoError(t, err)
+		assert.Equal(t, profile, cached)
+	}
+	mockUserProfileService.AssertExpectations(t)
+}
+
+func TestClientWithStickyRollouts(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "sticky_sdk_key"}
+
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	optimizelyClient, err := factory.Client(WithUserProfileServiceV2(mockUserProfileService), WithStickyRollouts())
+	assert.NoError(t, err)
+	assert.IsType(t, &decision.CompositeService{}, optimizelyClient.DecisionService)
+
+	// sticky rollouts have no effect without a user profile service
+	optimizelyClient, err = factory.Client(WithStickyRollouts())
+	assert.NoError(t, err)
+	assert.IsType(t, &decision.CompositeService{}, optimizelyClient.DecisionService)
+}
+
+type blocklistExperimentService struct {
+	decision.ExperimentService
+}
+
+type blocklistFeatureService struct {
+	decision.FeatureService
+}
+
+func TestClientWithDecisionPipelines(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "pipeline_sdk_key"}
+
+	var experimentStages, featureStages []string
+	optimizelyClient, err := factory.Client(
+		WithExperimentPipeline(func(pipeline *decision.ExperimentPipeline) {
+			experimentStages = pipeline.InsertBefore(decision.WhitelistStage, "blocklist", &blocklistExperimentService{}).Stages()
+		}),
+		WithFeaturePipeline(func(pipeline *decision.FeaturePipeline) {
+			featureStages = pipeline.Prepend("blocklist", &blocklistFeatureService{}).Stages()
+		}),
+	)
+	assert.NoError(t, err)
+	assert.IsType(t, &decision.CompositeService{}, optimizelyClient.DecisionService)
+	assert.Equal(t, []string{"blocklist", decision.WhitelistStage, decision.BucketingStage}, experimentStages)
+	assert.Equal(t, []string{"blocklist", decision.FeatureExperimentStage, decision.RolloutStage}, featureStages)
+
+	_, err = factory.Client(WithExperimentPipeline(func(pipeline *decision.ExperimentPipeline) {
+		pipeline.Remove("missing")
+	}))
+	assert.Error(t, err)
+}
+
+func TestClientWithHoldout(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "holdout_sdk_key"}
+
+	var experimentStages, featureStages []string
+	_, err := factory.Client(
+		WithHoldout(decision.Holdout{ID: "holdout_1", Key: "global_holdout", EndOfRange: 500}),
+		WithExperimentPipeline(func(pipeline *decision.ExperimentPipeline) {
+			experimentStages = pipeline.Stages()
+		}),
+		WithFeaturePipeline(func(pipeline *decision.FeaturePipeline) {
+			featureStages = pipeline.Stages()
+		}),
+	)
+	assert.NoError(t, err)
+	// whitelisted users are not held out
+	assert.Equal(t, []string{decision.WhitelistStage, decision.HoldoutStage, decision.BucketingStage}, experimentStages)
+	assert.Equal(t, []string{decision.FeatureExperimentStage, decision.HoldoutStage, decision.RolloutStage}, featureStages)
+}
+
+func TestClientExplainer(t *testing.T) {
+	datafile := []byte(`{"version": "4", "projectId": "1", "revision": "1", "experiments": [{"id": "11", "key": "exp_1",
+		"status": "Running", "layerId": "1", "audienceIds": [], "forcedVariations": {"vip": "var_1"},
+		"variations": [{"id": "111", "key": "var_1"}], "trafficAllocation": [{"entityId": "111", "endOfRange": 10000}]}]}`)
+	configManager, err := config.NewStaticProjectConfigManagerFromPayload(datafile)
+	assert.NoError(t, err)
+
+	factory := OptimizelyFactory{SDKKey: "explainer_sdk_key"}
+	optimizelyClient, err := factory.Client(
+		WithConfigManager(configManager),
+		WithEventDispatcher(new(MockDispatcher)),
+		WithHoldout(decision.Holdout{ID: "holdout_1", Key: "global_holdout", EndOfRange: 10000}),
+	)
+	assert.NoError(t, err)
+
+	// the explained decisions go through the stages of the client
+	explainer, err := optimizelyClient.Explainer()
+	assert.NoError(t, err)
+	explanation, err := explainer.ExplainExperiment("exp_1", entities.UserContext{ID: "test_user"})
+	assert.NoError(t, err)
+	assert.Equal(t, reasons.InHoldout, explanation.Reason)
+	assert.Equal(t, decision.HoldoutStage, explanation.Steps[len(explanation.Steps)-1].Stage)
+
+	explanation, err = explainer.ExplainExperiment("exp_1", entities.UserContext{ID: "vip"})
+	assert.NoError(t, err)
+	assert.Equal(t, "var_1", explanation.VariationKey)
+	assert.Equal(t, 0, optimizelyClient.EventProcessor.(*event.BatchEventProcessor).Q.Size())
+
+	optimizelyClient, err = factory.Client(WithConfigManager(configManager), WithDecisionService(new(MockDecisionService)))
+	assert.NoError(t, err)
+	_, err = optimizelyClient.Explainer()
+	assert.Error(t, err)
+}
+
 func TestClientWithEventDispatcher(t *testing.T) {
 	factory := OptimizelyFactory{SDKKey: "1212"}
 
@@ -173,6 +319,92 @@ func TestClientWithEventDispatcher(t *testing.T) {
 	assert.Equal(t, dispatcher, mockEventDispatcher)
 }
 
+func TestClientWithImpressionDeduplication(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "dedupe_sdk_key"}
+	optimizelyClient, err := factory.Client(WithEventDispatcher(new(MockDispatcher)), WithImpressionDeduplication(10, time.Minute))
+	assert.NoError(t, err)
+
+	impression := event.UserEvent{VisitorID: "test_user", Impression: &event.ImpressionEvent{ExperimentID: "1", VariationID: "2"}}
+	optimizelyClient.EventProcessor.ProcessEvent(impression)
+	optimizelyClient.EventProcessor.ProcessEvent(impression)
+	assert.Equal(t, 1, optimizelyClient.EventProcessor.(*event.BatchEventProcessor).Q.Size())
+}
+
+func TestClientWithImpressionSamplingAndRateLimit(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "sampling_sdk_key"}
+	optimizelyClient, err := factory.Client(
+		WithEventDispatcher(new(MockDispatcher)),
+		WithImpressionSampling(map[string]float64{"sampled_out": 0}),
+		WithImpressionRateLimit(0.001, 1),
+	)
+	assert.NoError(t, err)
+
+	processor := optimizelyClient.EventProcessor
+	assert.True(t, processor.ProcessEvent(event.UserEvent{VisitorID: "a", Impression: &event.ImpressionEvent{ExperimentID: "sampled_out"}}))
+	assert.True(t, processor.ProcessEvent(event.UserEvent{VisitorID: "a", Impression: &event.ImpressionEvent{ExperimentID: "1"}}))
+	assert.False(t, processor.ProcessEvent(event.UserEvent{VisitorID: "b", Impression: &event.ImpressionEvent{ExperimentID: "1"}}))
+	assert.Equal(t, 1, processor.(*event.BatchEventProcessor).Q.Size())
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type fixedIDGenerator string
+
+func (g fixedIDGenerator) NewID() string {
+	return string(g)
+}
+
+func TestClientWithEventClockAndIDGenerator(t *testing.T) {
+	configManager, err := config.NewStaticProjectConfigManagerFromPayload([]byte(
+		`{"revision": "42", "version": "4", "events": [{"id": "100", "key": "purchase", "experimentIds": []}]}`))
+	assert.NoError(t, err)
+
+	mockProcessor := new(MockProcessor)
+	mockProcessor.On("ProcessEvent", mock.AnythingOfType("event.UserEvent")).Return(true)
+	factory := OptimizelyFactory{SDKKey: "clock_sdk_key"}
+	optimizelyClient, err := factory.Client(
+		WithConfigManager(configManager),
+		WithEventProcessor(mockProcessor),
+		WithEventClock(fixedClock(time.Unix(1580000000, 0))),
+		WithEventIDGenerator(fixedIDGenerator("uuid")),
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, optimizelyClient.Track("purchase", entities.UserContext{ID: "test_user"}, nil))
+	if assert.Len(t, mockProcessor.Events, 1) {
+		assert.Equal(t, int64(1580000000000), mockProcessor.Events[0].Timestamp)
+		assert.Equal(t, "uuid", mockProcessor.Events[0].UUID)
+	}
+}
+
+type recordingSink struct {
+	events chan event.UserEvent
+}
+
+func (s recordingSink) Send(events []event.UserEvent) error {
+	for _, userEvent := range events {
+		s.events <- userEvent
+	}
+	return nil
+}
+
+func TestClientWithEventSink(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "sink_sdk_key"}
+
+	sink := recordingSink{events: make(chan event.UserEvent, 1)}
+	optimizelyClient, err := factory.Client(WithEventDispatcher(new(MockDispatcher)), WithEventSink("recording", sink))
+	assert.NoError(t, err)
+
+	userEvent := event.UserEvent{UUID: "uuid"}
+	optimizelyClient.EventProcessor.ProcessEvent(userEvent)
+	optimizelyClient.Close()
+	assert.Equal(t, userEvent, <-sink.events)
+}
+
 func TestClientMetrics(t *testing.T) {
 	factory := OptimizelyFactory{SDKKey: "1212"}
 
@@ -185,3 +417,89 @@ func TestClientMetrics(t *testing.T) {
 	eventProcessor := optimizelyClient.EventProcessor.(*event.BatchEventProcessor)
 	assert.NotNil(t, eventProcessor)
 }
+
+func TestClientWithNotificationCenter(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "async_sdk_key"}
+
+	notificationCenter := notification.NewAsyncNotificationCenter()
+	optimizelyClient, err := factory.Client(WithNotificationCenter(notificationCenter))
+	assert.NoError(t, err)
+	assert.Equal(t, notificationCenter, optimizelyClient.notificationCenter)
+	assert.Equal(t, notificationCenter, registry.GetNotificationCenter("async_sdk_key"))
+
+	received := make(chan interface{}, 1)
+	notificationCenter.AddHandler(notification.Track, func(payload interface{}) {
+		received <- payload
+	})
+	notificationCenter.Send(notification.Track, "payload")
+	optimizelyClient.Close()
+	assert.Equal(t, "payload", <-received)
+}
+
+func TestClientWithPollingConfigManagerUsesNotificationCenter(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "polling_notification_sdk_key"}
+
+	notificationCenter := notification.NewNotificationCenter()
+	optimizelyClient, err := factory.Client(
+		WithPollingConfigManager(time.Hour, []byte(`{"revision": "42", "version": "4"}`)),
+		WithNotificationCenter(notificationCenter),
+	)
+	assert.NoError(t, err)
+	defer optimizelyClient.Close()
+
+	received := 0
+	configManager := optimizelyClient.ConfigManager.(*config.PollingProjectConfigManager)
+	_, err = configManager.OnProjectConfigUpdate(func(notification.ProjectConfigUpdateNotification) {
+		received++
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, notificationCenter.Send(notification.ProjectConfigUpdate, notification.ProjectConfigUpdateNotification{}))
+	assert.Equal(t, 1, received)
+}
+
+func TestClientWithAsyncNotifications(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "async_notifications_sdk_key"}
+	metricsRegistry := &cacheTestRegistry{counters: map[string]*cacheTestCounter{}, gauges: map[string]*cacheTestGauge{}}
+
+	optimizelyClient, err := factory.Client(
+		WithConfigManager(config.NewStaticProjectConfigManager(datafileprojectconfig.DatafileProjectConfig{})),
+		WithMetricsRegistry(metricsRegistry),
+		WithAsyncNotifications(notification.WithBufferSize(1)),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, optimizelyClient.notificationCenter, registry.GetNotificationCenter("async_notifications_sdk_key"))
+
+	// the handler holds the delivery until the notifications overflow the buffer
+	release := make(chan struct{})
+	_, err = optimizelyClient.notificationCenter.AddHandler(notification.Track, func(interface{}) {
+		<-release
+	})
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, optimizelyClient.notificationCenter.Send(notification.Track, i))
+	}
+	close(release)
+	optimizelyClient.Close()
+
+	assert.True(t, metricsRegistry.counters["notification.dropped.track"].value >= 8)
+}
+
+func TestClientWithEventOverflowPolicy(t *testing.T) {
+	factory := OptimizelyFactory{SDKKey: "overflow_sdk_key"}
+	optimizelyClient, err := factory.Client(
+		WithEventDispatcher(new(MockDispatcher)),
+		WithEventOverflowPolicy(event.OverflowSpill(event.NewInMemoryQueue(10), 10)),
+	)
+	assert.NoError(t, err)
+
+	drops := make(chan notification.EventDropNotification, 1)
+	id, err := optimizelyClient.OnEventDrop(func(drop notification.EventDropNotification) {
+		drops <- drop
+	})
+	assert.NoError(t, err)
+
+	dropNotification := notification.EventDropNotification{Reason: notification.QueueFull, Event: event.UserEvent{VisitorID: "a"}}
+	assert.NoError(t, registry.GetNotificationCenter("overflow_sdk_key").Send(notification.EventDrop, dropNotification))
+	assert.Equal(t, dropNotification, <-drops)
+	assert.NoError(t, optimizelyClient.RemoveOnEventDrop(id))
+}
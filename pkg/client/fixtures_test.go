@@ -18,6 +18,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/optimizely/go-sdk/pkg/config"
@@ -164,6 +165,37 @@ type MockUserProfileService struct {
 	mock.Mock
 }
 
+type MockUserProfileServiceV2 struct {
+	mock.Mock
+}
+
+func (m *MockUserProfileServiceV2) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	args := m.Called(userID)
+	return args.Get(0).(decision.UserProfile), args.Error(1)
+}
+
+func (m *MockUserProfileServiceV2) Save(ctx context.Context, userProfile decision.UserProfile) error {
+	return m.Called(userProfile).Error(0)
+}
+
+// ProfilingDecisionService buckets into the first variation of the feature experiment and records it in the
+// user profile of the decision context
+type ProfilingDecisionService struct {
+	decision.Service
+}
+
+func (p *ProfilingDecisionService) GetFeatureDecision(decisionContext decision.FeatureDecisionContext, userContext entities.UserContext) (decision.FeatureDecision, error) {
+	experiment := decisionContext.Feature.FeatureExperiments[0]
+	if _, err := decisionContext.UserProfile.Profile(context.Background()); err != nil {
+		return decision.FeatureDecision{}, err
+	}
+	for _, variation := range experiment.Variations {
+		decisionContext.UserProfile.SaveDecision(experiment.ID, variation.ID)
+		return decision.FeatureDecision{Experiment: experiment, Variation: &variation}, nil
+	}
+	return decision.FeatureDecision{}, nil
+}
+
 // Helper methods for creating test entities
 func makeTestExperiment(experimentKey string) entities.Experiment {
 	return entities.Experiment{
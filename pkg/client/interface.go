@@ -0,0 +1,55 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package client
+
+import (
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/event"
+	"github.com/optimizely/go-sdk/pkg/notification"
+)
+
+// Client has the public methods of the OptimizelyClient, so that it can be replaced, by a fake in tests for instance
+type Client interface {
+	Activate(experimentKey string, userContext entities.UserContext) (string, error)
+	GetVariation(experimentKey string, userContext entities.UserContext) (string, error)
+	IsFeatureEnabled(featureKey string, userContext entities.UserContext) (bool, error)
+	GetEnabledFeatures(userContext entities.UserContext) ([]string, error)
+	GetFeatureVariable(featureKey, variableKey string, userContext entities.UserContext) (string, entities.VariableType, error)
+	GetFeatureVariableBoolean(featureKey, variableKey string, userContext entities.UserContext) (bool, error)
+	GetFeatureVariableDouble(featureKey, variableKey string, userContext entities.UserContext) (float64, error)
+	GetFeatureVariableInteger(featureKey, variableKey string, userContext entities.UserContext) (int, error)
+	GetFeatureVariableString(featureKey, variableKey string, userContext entities.UserContext) (string, error)
+	GetAllFeatureVariables(featureKey string, userContext entities.UserContext) (bool, map[string]interface{}, error)
+	Track(eventKey string, userContext entities.UserContext, eventTags map[string]interface{}) error
+	GetOptimizelyConfig() *config.OptimizelyConfig
+
+	OnTrack(callback func(eventKey string, userContext entities.UserContext, eventTags map[string]interface{}, conversionEvent event.ConversionEvent)) (int, error)
+	RemoveOnTrack(id int) error
+	OnDecision(callback func(notification.DecisionNotification)) (int, error)
+	RemoveOnDecision(id int) error
+	OnConfigUpdate(callback func(notification.ProjectConfigUpdateNotification)) (int, error)
+	RemoveOnConfigUpdate(id int) error
+	OnLogEvent(callback func(logEvent event.LogEvent)) (int, error)
+	RemoveOnLogEvent(id int) error
+	OnEventDrop(callback func(notification.EventDropNotification)) (int, error)
+	RemoveOnEventDrop(id int) error
+
+	Close()
+}
+
+var _ Client = &OptimizelyClient{}
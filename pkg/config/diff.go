@@ -0,0 +1,365 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package config //
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/notification"
+)
+
+// DiffProjectConfigs returns the features, variables, experiments, rollout rules, variations, groups and audiences
+// added, removed or changed from the old project config to the new one. A nil project config has no entities.
+// Nested entities are only compared for entities found in both project configs. For each kind of entity, the
+// added and removed entities come first, sorted by key, followed by the changes of the entities found in both.
+func DiffProjectConfigs(oldConfig, newConfig ProjectConfig) notification.ProjectConfigDiff {
+	oldView, newView := newConfigView(oldConfig), newConfigView(newConfig)
+	d := &differ{}
+	d.diffFeatures(oldView, newView)
+	d.diffExperiments(oldView, newView)
+	d.diffGroups(oldView, newView)
+	d.diffAudiences(oldView, newView)
+	return notification.ProjectConfigDiff{
+		OldRevision: oldView.revision,
+		NewRevision: newView.revision,
+		Changes:     d.changes,
+	}
+}
+
+// configView indexes the entities of a project config
+type configView struct {
+	revision     string
+	features     map[string]entities.Feature
+	experiments  map[string]entities.Experiment
+	groups       map[string]entities.Group
+	audiences    map[string]entities.Audience
+	variableKeys map[string]string // variable key by variable ID
+}
+
+func newConfigView(projectConfig ProjectConfig) configView {
+	view := configView{
+		features:     map[string]entities.Feature{},
+		experiments:  map[string]entities.Experiment{},
+		groups:       map[string]entities.Group{},
+		audiences:    map[string]entities.Audience{},
+		variableKeys: map[string]string{},
+	}
+	if projectConfig == nil {
+		return view
+	}
+
+	view.revision = projectConfig.GetRevision()
+	for _, feature := range projectConfig.GetFeatureList() {
+		view.features[feature.Key] = feature
+		for _, variable := range feature.VariableMap {
+			view.variableKeys[variable.ID] = variable.Key
+		}
+	}
+	for _, experiment := range projectConfig.GetExperimentList() {
+		view.experiments[experiment.Key] = experiment
+		if experiment.GroupID != "" {
+			if group, err := projectConfig.GetGroupByID(experiment.GroupID); err == nil {
+				view.groups[group.ID] = group
+			}
+		}
+	}
+	for id, audience := range projectConfig.GetAudienceMap() {
+		view.audiences[id] = audience
+	}
+	return view
+}
+
+func (v configView) experimentKey(experimentID string) string {
+	for _, experiment := range v.experiments {
+		if experiment.ID == experimentID {
+			return experiment.Key
+		}
+	}
+	return experimentID
+}
+
+type differ struct {
+	changes []notification.ProjectConfigChange
+}
+
+func (d *differ) add(changeType notification.ChangeType, entity notification.ConfigEntity, key string) {
+	d.changes = append(d.changes, notification.ProjectConfigChange{Type: changeType, Entity: entity, Key: key})
+}
+
+func (d *differ) compare(entity notification.ConfigEntity, key, field, oldValue, newValue string) {
+	if oldValue != newValue {
+		d.changes = append(d.changes, notification.ProjectConfigChange{
+			Type:     notification.Changed,
+			Entity:   entity,
+			Key:      key,
+			Field:    field,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+}
+
+// diffKeys adds the keys missing from either side, and returns the sorted keys of both sides
+func (d *differ) diffKeys(entity notification.ConfigEntity, prefix string, oldKeys, newKeys []string) (common []string) {
+	oldSet := make(map[string]bool, len(oldKeys))
+	for _, key := range oldKeys {
+		oldSet[key] = true
+	}
+	newSet := make(map[string]bool, len(newKeys))
+	for _, key := range newKeys {
+		newSet[key] = true
+	}
+
+	all := append(append([]string{}, oldKeys...), newKeys...)
+	sort.Strings(all)
+	for i, key := range all {
+		if i > 0 && all[i-1] == key {
+			continue
+		}
+		switch {
+		case !oldSet[key]:
+			d.add(notification.Added, entity, prefix+key)
+		case !newSet[key]:
+			d.add(notification.Removed, entity, prefix+key)
+		default:
+			common = append(common, key)
+		}
+	}
+	return common
+}
+
+func (d *differ) diffFeatures(oldView, newView configView) {
+	for _, key := range d.diffKeys(notification.FeatureEntity, "", featureKeys(oldView.features), featureKeys(newView.features)) {
+		oldFeature, newFeature := oldView.features[key], newView.features[key]
+		d.compare(notification.FeatureEntity, key, "experiments", experimentKeys(oldFeature.FeatureExperiments), experimentKeys(newFeature.FeatureExperiments))
+		d.compare(notification.FeatureEntity, key, "rollout", oldFeature.Rollout.ID, newFeature.Rollout.ID)
+
+		prefix := key + "."
+		for _, variableKey := range d.diffKeys(notification.VariableEntity, prefix, variableKeys(oldFeature.VariableMap), variableKeys(newFeature.VariableMap)) {
+			oldVariable, newVariable := oldFeature.VariableMap[variableKey], newFeature.VariableMap[variableKey]
+			d.compare(notification.VariableEntity, prefix+variableKey, "type", string(oldVariable.Type), string(newVariable.Type))
+			d.compare(notification.VariableEntity, prefix+variableKey, "defaultValue", oldVariable.DefaultValue, newVariable.DefaultValue)
+		}
+
+		oldRules, newRules := experimentMap(oldFeature.Rollout.Experiments), experimentMap(newFeature.Rollout.Experiments)
+		for _, ruleKey := range d.diffKeys(notification.RolloutRuleEntity, prefix, experimentMapKeys(oldRules), experimentMapKeys(newRules)) {
+			d.diffExperiment(notification.RolloutRuleEntity, prefix+ruleKey, oldRules[ruleKey], newRules[ruleKey], oldView, newView)
+		}
+	}
+}
+
+func (d *differ) diffExperiments(oldView, newView configView) {
+	for _, key := range d.diffKeys(notification.ExperimentEntity, "", experimentMapKeys(oldView.experiments), experimentMapKeys(newView.experiments)) {
+		d.diffExperiment(notification.ExperimentEntity, key, oldView.experiments[key], newView.experiments[key], oldView, newView)
+	}
+}
+
+func (d *differ) diffExperiment(entity notification.ConfigEntity, key string, oldExperiment, newExperiment entities.Experiment, oldView, newView configView) {
+	d.compare(entity, key, "group", oldExperiment.GroupID, newExperiment.GroupID)
+	d.compare(entity, key, "audienceConditions", formatConditionTree(oldExperiment.AudienceConditionTree), formatConditionTree(newExperiment.AudienceConditionTree))
+	d.compare(entity, key, "trafficAllocation", formatTrafficAllocation(oldExperiment.TrafficAllocation, variationKeyOf(oldExperiment)),
+		formatTrafficAllocation(newExperiment.TrafficAllocation, variationKeyOf(newExperiment)))
+	d.compare(entity, key, "whitelist", formatMap(oldExperiment.Whitelist), formatMap(newExperiment.Whitelist))
+
+	oldVariations, newVariations := variationMap(oldExperiment), variationMap(newExperiment)
+	prefix := key + "."
+	for _, variationKey := range d.diffKeys(notification.VariationEntity, prefix, variationMapKeys(oldVariations), variationMapKeys(newVariations)) {
+		oldVariation, newVariation := oldVariations[variationKey], newVariations[variationKey]
+		d.compare(notification.VariationEntity, prefix+variationKey, "featureEnabled", strconv.FormatBool(oldVariation.FeatureEnabled), strconv.FormatBool(newVariation.FeatureEnabled))
+
+		oldValues, newValues := variableValues(oldVariation, oldView), variableValues(newVariation, newView)
+		variableKeys := make([]string, 0, len(oldValues)+len(newValues))
+		for variableKey := range oldValues {
+			variableKeys = append(variableKeys, variableKey)
+		}
+		for variableKey := range newValues {
+			if _, ok := oldValues[variableKey]; !ok {
+				variableKeys = append(variableKeys, variableKey)
+			}
+		}
+		sort.Strings(variableKeys)
+		for _, variableKey := range variableKeys {
+			d.compare(notification.VariationEntity, prefix+variationKey, "variables."+variableKey, oldValues[variableKey], newValues[variableKey])
+		}
+	}
+}
+
+func (d *differ) diffGroups(oldView, newView configView) {
+	oldIDs := make([]string, 0, len(oldView.groups))
+	for id := range oldView.groups {
+		oldIDs = append(oldIDs, id)
+	}
+	newIDs := make([]string, 0, len(newView.groups))
+	for id := range newView.groups {
+		newIDs = append(newIDs, id)
+	}
+
+	for _, id := range d.diffKeys(notification.GroupEntity, "", oldIDs, newIDs) {
+		oldGroup, newGroup := oldView.groups[id], newView.groups[id]
+		d.compare(notification.GroupEntity, id, "policy", oldGroup.Policy, newGroup.Policy)
+		d.compare(notification.GroupEntity, id, "trafficAllocation", formatTrafficAllocation(oldGroup.TrafficAllocation, oldView.experimentKey),
+			formatTrafficAllocation(newGroup.TrafficAllocation, newView.experimentKey))
+	}
+}
+
+func (d *differ) diffAudiences(oldView, newView configView) {
+	oldIDs := make([]string, 0, len(oldView.audiences))
+	for id := range oldView.audiences {
+		oldIDs = append(oldIDs, id)
+	}
+	newIDs := make([]string, 0, len(newView.audiences))
+	for id := range newView.audiences {
+		newIDs = append(newIDs, id)
+	}
+
+	for _, id := range d.diffKeys(notification.AudienceEntity, "", oldIDs, newIDs) {
+		oldAudience, newAudience := oldView.audiences[id], newView.audiences[id]
+		d.compare(notification.AudienceEntity, id, "name", oldAudience.Name, newAudience.Name)
+		d.compare(notification.AudienceEntity, id, "conditions", formatConditionTree(oldAudience.ConditionTree), formatConditionTree(newAudience.ConditionTree))
+	}
+}
+
+func featureKeys(features map[string]entities.Feature) []string {
+	keys := make([]string, 0, len(features))
+	for key := range features {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func variableKeys(variables map[string]entities.Variable) []string {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func experimentMap(experiments []entities.Experiment) map[string]entities.Experiment {
+	experimentMap := make(map[string]entities.Experiment, len(experiments))
+	for _, experiment := range experiments {
+		experimentMap[experiment.Key] = experiment
+	}
+	return experimentMap
+}
+
+func experimentMapKeys(experiments map[string]entities.Experiment) []string {
+	keys := make([]string, 0, len(experiments))
+	for key := range experiments {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func experimentKeys(experiments []entities.Experiment) string {
+	keys := make([]string, 0, len(experiments))
+	for _, experiment := range experiments {
+		keys = append(keys, experiment.Key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func variationMap(experiment entities.Experiment) map[string]entities.Variation {
+	variations := make(map[string]entities.Variation, len(experiment.Variations))
+	for _, variation := range experiment.Variations {
+		variations[variation.Key] = variation
+	}
+	return variations
+}
+
+func variationMapKeys(variations map[string]entities.Variation) []string {
+	keys := make([]string, 0, len(variations))
+	for key := range variations {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func variationKeyOf(experiment entities.Experiment) func(string) string {
+	return func(variationID string) string {
+		if variation, ok := experiment.Variations[variationID]; ok {
+			return variation.Key
+		}
+		return variationID
+	}
+}
+
+// variableValues returns the values of the variables of the variation by variable key
+func variableValues(variation entities.Variation, view configView) map[string]string {
+	values := make(map[string]string, len(variation.Variables))
+	for id, variable := range variation.Variables {
+		key, ok := view.variableKeys[id]
+		if !ok {
+			key = id
+		}
+		values[key] = variable.Value
+	}
+	return values
+}
+
+// formatTrafficAllocation formats the ranges as entity:endOfRange, naming the entities with the given function
+func formatTrafficAllocation(trafficAllocation []entities.Range, entityName func(string) string) string {
+	ranges := make([]string, 0, len(trafficAllocation))
+	for _, trafficRange := range trafficAllocation {
+		ranges = append(ranges, fmt.Sprintf("%s:%d", entityName(trafficRange.EntityID), trafficRange.EndOfRange))
+	}
+	return strings.Join(ranges, ",")
+}
+
+func formatMap(m map[string]string) string {
+	entries := make([]string, 0, len(m))
+	for key, value := range m {
+		entries = append(entries, key+"="+value)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func formatConditionTree(node *entities.TreeNode) string {
+	if node == nil {
+		return ""
+	}
+	if node.Operator != "" {
+		children := make([]string, 0, len(node.Nodes))
+		for _, child := range node.Nodes {
+			children = append(children, formatConditionTree(child))
+		}
+		return node.Operator + "(" + strings.Join(children, ", ") + ")"
+	}
+
+	switch item := node.Item.(type) {
+	case entities.Condition:
+		match := item.Match
+		if match == "" {
+			match = "exact"
+		}
+		if item.Value == nil {
+			return fmt.Sprintf("%s %s %s", item.Type, item.Name, match)
+		}
+		return fmt.Sprintf("%s %s %s %#v", item.Type, item.Name, match, item.Value)
+	case string:
+		return "audience " + item
+	default:
+		return fmt.Sprintf("%v", item)
+	}
+}
@@ -0,0 +1,132 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
+	"github.com/optimizely/go-sdk/pkg/notification"
+)
+
+const diffOldDatafile = `{
+  "version": "4", "revision": "1",
+  "attributes": [{"id": "100", "key": "country"}],
+  "audiences": [],
+  "typedAudiences": [
+    {"id": "200", "name": "us", "conditions": ["and", {"name": "country", "type": "custom_attribute", "match": "exact", "value": "us"}]},
+    {"id": "201", "name": "ca", "conditions": ["and", {"name": "country", "type": "custom_attribute", "match": "exact", "value": "ca"}]}
+  ],
+  "experiments": [
+    {"id": "1000", "key": "exp", "layerId": "1", "status": "Running", "audienceIds": ["200"], "forcedVariations": {},
+     "variations": [{"id": "1001", "key": "a", "featureEnabled": true, "variables": [{"id": "400", "value": "1"}]}, {"id": "1002", "key": "b", "variables": []}],
+     "trafficAllocation": [{"entityId": "1001", "endOfRange": 5000}, {"entityId": "1002", "endOfRange": 10000}]},
+    {"id": "1100", "key": "removed_exp", "layerId": "2", "status": "Running", "audienceIds": [], "forcedVariations": {},
+     "variations": [{"id": "1101", "key": "a", "variables": []}], "trafficAllocation": [{"entityId": "1101", "endOfRange": 10000}]}
+  ],
+  "groups": [
+    {"id": "500", "policy": "random", "trafficAllocation": [{"entityId": "1200", "endOfRange": 5000}],
+     "experiments": [{"id": "1200", "key": "group_exp", "layerId": "3", "status": "Running", "audienceIds": [], "forcedVariations": {},
+       "variations": [{"id": "1201", "key": "a", "variables": []}], "trafficAllocation": [{"entityId": "1201", "endOfRange": 10000}]}]}
+  ],
+  "featureFlags": [
+    {"id": "300", "key": "feature", "rolloutId": "600", "experimentIds": ["1000"],
+     "variables": [{"id": "400", "key": "count", "type": "integer", "defaultValue": "0"}, {"id": "401", "key": "old_var", "type": "string", "defaultValue": ""}]}
+  ],
+  "rollouts": [
+    {"id": "600", "experiments": [{"id": "601", "key": "601", "layerId": "600", "status": "Running", "audienceIds": [], "forcedVariations": {},
+      "variations": [{"id": "602", "key": "on", "featureEnabled": true, "variables": []}], "trafficAllocation": [{"entityId": "602", "endOfRange": 1000}]}]}
+  ],
+  "events": []
+}`
+
+const diffNewDatafile = `{
+  "version": "4", "revision": "2",
+  "attributes": [{"id": "100", "key": "country"}],
+  "audiences": [],
+  "typedAudiences": [
+    {"id": "200", "name": "us", "conditions": ["and", {"name": "country", "type": "custom_attribute", "match": "exact", "value": "US"}]},
+    {"id": "202", "name": "fr", "conditions": ["and", {"name": "country", "type": "custom_attribute", "match": "exact", "value": "fr"}]}
+  ],
+  "experiments": [
+    {"id": "1000", "key": "exp", "layerId": "1", "status": "Running", "audienceIds": ["200"], "forcedVariations": {"vip": "b"},
+     "variations": [{"id": "1001", "key": "a", "featureEnabled": false, "variables": [{"id": "400", "value": "2"}]}, {"id": "1003", "key": "c", "variables": []}],
+     "trafficAllocation": [{"entityId": "1001", "endOfRange": 2000}, {"entityId": "1003", "endOfRange": 10000}]}
+  ],
+  "groups": [
+    {"id": "500", "policy": "random", "trafficAllocation": [{"entityId": "1200", "endOfRange": 8000}],
+     "experiments": [{"id": "1200", "key": "group_exp", "layerId": "3", "status": "Running", "audienceIds": [], "forcedVariations": {},
+       "variations": [{"id": "1201", "key": "a", "variables": []}], "trafficAllocation": [{"entityId": "1201", "endOfRange": 10000}]}]}
+  ],
+  "featureFlags": [
+    {"id": "300", "key": "feature", "rolloutId": "600", "experimentIds": ["1000"],
+     "variables": [{"id": "400", "key": "count", "type": "integer", "defaultValue": "10"}, {"id": "402", "key": "new_var", "type": "boolean", "defaultValue": "true"}]},
+    {"id": "301", "key": "new_feature", "rolloutId": "", "experimentIds": [], "variables": []}
+  ],
+  "rollouts": [
+    {"id": "600", "experiments": [{"id": "601", "key": "601", "layerId": "600", "status": "Running", "audienceIds": [], "forcedVariations": {},
+      "variations": [{"id": "602", "key": "on", "featureEnabled": true, "variables": []}], "trafficAllocation": [{"entityId": "602", "endOfRange": 5000}]}]}
+  ],
+  "events": []
+}`
+
+func TestDiffProjectConfigs(t *testing.T) {
+	oldConfig, err := datafileprojectconfig.NewDatafileProjectConfig([]byte(diffOldDatafile))
+	require.NoError(t, err)
+	newConfig, err := datafileprojectconfig.NewDatafileProjectConfig([]byte(diffNewDatafile))
+	require.NoError(t, err)
+
+	diff := DiffProjectConfigs(oldConfig, newConfig)
+	assert.Equal(t, "1", diff.OldRevision)
+	assert.Equal(t, "2", diff.NewRevision)
+	assert.Equal(t, []notification.ProjectConfigChange{
+		{Type: notification.Added, Entity: notification.FeatureEntity, Key: "new_feature"},
+		{Type: notification.Added, Entity: notification.VariableEntity, Key: "feature.new_var"},
+		{Type: notification.Removed, Entity: notification.VariableEntity, Key: "feature.old_var"},
+		{Type: notification.Changed, Entity: notification.VariableEntity, Key: "feature.count", Field: "defaultValue", OldValue: "0", NewValue: "10"},
+		{Type: notification.Changed, Entity: notification.RolloutRuleEntity, Key: "feature.601", Field: "trafficAllocation", OldValue: "on:1000", NewValue: "on:5000"},
+		{Type: notification.Removed, Entity: notification.ExperimentEntity, Key: "removed_exp"},
+		{Type: notification.Changed, Entity: notification.ExperimentEntity, Key: "exp", Field: "trafficAllocation", OldValue: "a:5000,b:10000", NewValue: "a:2000,c:10000"},
+		{Type: notification.Changed, Entity: notification.ExperimentEntity, Key: "exp", Field: "whitelist", OldValue: "", NewValue: "vip=b"},
+		{Type: notification.Removed, Entity: notification.VariationEntity, Key: "exp.b"},
+		{Type: notification.Added, Entity: notification.VariationEntity, Key: "exp.c"},
+		{Type: notification.Changed, Entity: notification.VariationEntity, Key: "exp.a", Field: "featureEnabled", OldValue: "true", NewValue: "false"},
+		{Type: notification.Changed, Entity: notification.VariationEntity, Key: "exp.a", Field: "variables.count", OldValue: "1", NewValue: "2"},
+		{Type: notification.Changed, Entity: notification.GroupEntity, Key: "500", Field: "trafficAllocation", OldValue: "group_exp:5000", NewValue: "group_exp:8000"},
+		{Type: notification.Removed, Entity: notification.AudienceEntity, Key: "201"},
+		{Type: notification.Added, Entity: notification.AudienceEntity, Key: "202"},
+		{Type: notification.Changed, Entity: notification.AudienceEntity, Key: "200", Field: "conditions",
+			OldValue: `and(custom_attribute country exact "us")`, NewValue: `and(custom_attribute country exact "US")`},
+	}, diff.Changes)
+
+	assert.Empty(t, DiffProjectConfigs(newConfig, newConfig).Changes)
+}
+
+func TestDiffProjectConfigsFromNil(t *testing.T) {
+	newConfig, err := datafileprojectconfig.NewDatafileProjectConfig([]byte(diffNewDatafile))
+	require.NoError(t, err)
+
+	diff := DiffProjectConfigs(nil, newConfig)
+	assert.Equal(t, "", diff.OldRevision)
+	for _, change := range diff.Changes {
+		assert.Equal(t, notification.Added, change.Type)
+	}
+	assert.Len(t, diff.Changes, 7) // 2 features, 2 experiments, 1 group and 2 audiences
+}
@@ -160,11 +160,12 @@ func (cm *PollingProjectConfigManager) SyncConfig() {
 		closeMutex(nil)
 		return
 	}
+	diff := DiffProjectConfigs(cm.projectConfig, projectConfig)
 	err = cm.setConfig(projectConfig)
 	closeMutex(err)
 	if err == nil {
 		cmLogger.Debug(fmt.Sprintf("New datafile set with revision: %s. Old revision: %s", projectConfig.GetRevision(), previousRevision))
-		cm.sendConfigUpdateNotification()
+		cm.sendConfigUpdateNotification(diff)
 	}
 }
 
@@ -296,11 +297,12 @@ func (cm *PollingProjectConfigManager) setInitialDatafile(datafile []byte) {
 	}
 }
 
-func (cm *PollingProjectConfigManager) sendConfigUpdateNotification() {
+func (cm *PollingProjectConfigManager) sendConfigUpdateNotification(diff notification.ProjectConfigDiff) {
 	if cm.notificationCenter != nil {
 		projectConfigUpdateNotification := notification.ProjectConfigUpdateNotification{
 			Type:     notification.ProjectConfigUpdate,
-			Revision: cm.projectConfig.GetRevision(),
+			Revision: diff.NewRevision,
+			Diff:     &diff,
 		}
 		if err := cm.notificationCenter.Send(notification.ProjectConfigUpdate, projectConfigUpdateNotification); err != nil {
 			cmLogger.Warning("Problem with sending notification")
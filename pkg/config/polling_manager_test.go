@@ -29,6 +29,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockRequester struct {
@@ -304,6 +305,28 @@ func TestNewPollingProjectConfigManagerWithDifferentDatafileRevisions(t *testing
 	assert.Equal(t, uint64(1), atomic.LoadUint64(&numberOfCalls))
 }
 
+func TestPollingProjectConfigManagerNotifiesDiff(t *testing.T) {
+	mockRequester := new(MockRequester)
+	mockRequester.On("Get", []utils.Header(nil)).Return([]byte(diffNewDatafile), http.Header{}, http.StatusOK, nil)
+	configManager := NewAsyncPollingProjectConfigManager("test_diff_sdk_key", WithRequester(mockRequester), WithInitialDatafile([]byte(diffOldDatafile)))
+
+	var notifications []notification.ProjectConfigUpdateNotification
+	_, err := configManager.OnProjectConfigUpdate(func(projectConfigUpdateNotification notification.ProjectConfigUpdateNotification) {
+		notifications = append(notifications, projectConfigUpdateNotification)
+	})
+	assert.NoError(t, err)
+
+	configManager.SyncConfig()
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "2", notifications[0].Revision)
+	require.NotNil(t, notifications[0].Diff)
+	assert.Equal(t, "1", notifications[0].Diff.OldRevision)
+	assert.Equal(t, "2", notifications[0].Diff.NewRevision)
+	assert.Contains(t, notifications[0].Diff.Changes, notification.ProjectConfigChange{
+		Type: notification.Added, Entity: notification.FeatureEntity, Key: "new_feature",
+	})
+}
+
 func TestNewAsyncPollingProjectConfigManagerWithDifferentDatafileRevisions(t *testing.T) {
 	// Test newer datafile should replace the older one if revisions are different
 	mockDatafile1 := []byte(`{"revision":"42","botFiltering":true,"version": "4"}`)
@@ -22,6 +22,7 @@ import (
 
 	"github.com/optimizely/go-sdk/pkg/entities"
 	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/metrics"
 )
 
 var ceLogger = logging.GetLogger("CompositeExperimentService")
@@ -36,6 +37,28 @@ func WithUserProfileService(userProfileService UserProfileService) CESOptionFunc
 	}
 }
 
+// WithUserProfileServiceV2 adds a user profile service reporting store failures. It takes precedence over
+// a service added with WithUserProfileService.
+func WithUserProfileServiceV2(userProfileService UserProfileServiceV2) CESOptionFunc {
+	return func(f *CompositeExperimentService) {
+		f.userProfileServiceV2 = userProfileService
+	}
+}
+
+// WithMetricsRegistry sets the registry counting the user profile service failures
+func WithMetricsRegistry(metricsRegistry metrics.Registry) CESOptionFunc {
+	return func(f *CompositeExperimentService) {
+		f.metricsRegistry = metricsRegistry
+	}
+}
+
+// WithExperimentBucketerOptions customizes the bucketer and the audience evaluator of the bucketing stage
+func WithExperimentBucketerOptions(options ...EBSOptionFunc) CESOptionFunc {
+	return func(f *CompositeExperimentService) {
+		f.bucketerOptions = append(f.bucketerOptions, options...)
+	}
+}
+
 // WithOverrideStore adds an experiment override store
 func WithOverrideStore(overrideStore ExperimentOverrideStore) CESOptionFunc {
 	return func(f *CompositeExperimentService) {
@@ -48,6 +71,10 @@ type CompositeExperimentService struct {
 	experimentServices []ExperimentService
 	overrideStore      ExperimentOverrideStore
 	userProfileService UserProfileService
+
+	userProfileServiceV2 UserProfileServiceV2
+	metricsRegistry      metrics.Registry
+	bucketerOptions      []EBSOptionFunc
 }
 
 // NewCompositeExperimentService creates a new instance of the CompositeExperimentService
@@ -56,29 +83,8 @@ func NewCompositeExperimentService(options ...CESOptionFunc) *CompositeExperimen
 	// 1. Overrides (if supplied)
 	// 2. Whitelist
 	// 3. Bucketing (with User profile integration if supplied)
-	compositeExperimentService := &CompositeExperimentService{}
-	for _, opt := range options {
-		opt(compositeExperimentService)
-	}
-	experimentServices := []ExperimentService{
-		NewExperimentWhitelistService(),
-	}
-
-	// Prepend overrides if supplied
-	if compositeExperimentService.overrideStore != nil {
-		overrideService := NewExperimentOverrideService(compositeExperimentService.overrideStore)
-		experimentServices = append([]ExperimentService{overrideService}, experimentServices...)
-	}
-
-	experimentBucketerService := NewExperimentBucketerService()
-	if compositeExperimentService.userProfileService != nil {
-		persistingExperimentService := NewPersistingExperimentService(experimentBucketerService, compositeExperimentService.userProfileService)
-		experimentServices = append(experimentServices, persistingExperimentService)
-	} else {
-		experimentServices = append(experimentServices, experimentBucketerService)
-	}
-	compositeExperimentService.experimentServices = experimentServices
-
+	// Use an ExperimentPipeline to customize them.
+	compositeExperimentService, _ := NewExperimentPipeline(options...).Build()
 	return compositeExperimentService
 }
 
@@ -91,7 +97,7 @@ func (s CompositeExperimentService) GetDecision(decisionContext ExperimentDecisi
 		if err != nil {
 			ceLogger.Debug(fmt.Sprintf("%v", err))
 		}
-		if decision.Variation != nil && err == nil {
+		if (decision.Variation != nil || decision.isFinal()) && err == nil {
 			return decision, err
 		}
 	}
@@ -169,6 +169,19 @@ func (s *CompositeExperimentTestSuite) TestNewCompositeExperimentServiceWithCust
 	s.Equal(mockExperimentOverrideStore, compositeExperimentService.overrideStore)
 }
 
+func (s *CompositeExperimentTestSuite) TestNewCompositeExperimentServiceWithUserProfileServiceV2() {
+	mockUserProfileService := new(MockUserProfileService)
+	mockUserProfileServiceV2 := new(MockUserProfileServiceV2)
+	compositeExperimentService := NewCompositeExperimentService(
+		WithUserProfileService(mockUserProfileService),
+		WithUserProfileServiceV2(mockUserProfileServiceV2),
+	)
+	s.Equal(2, len(compositeExperimentService.experimentServices))
+	persistingExperimentService, ok := compositeExperimentService.experimentServices[1].(*PersistingExperimentService)
+	s.True(ok)
+	s.Equal(mockUserProfileServiceV2, persistingExperimentService.userProfileService)
+}
+
 func TestCompositeExperimentTestSuite(t *testing.T) {
 	suite.Run(t, new(CompositeExperimentTestSuite))
 }
@@ -26,19 +26,28 @@ import (
 
 var cfLogger = logging.GetLogger("CompositeFeatureService")
 
+// CFSOptionFunc is used to pass custom config options into the CompositeFeatureService.
+type CFSOptionFunc func(*CompositeFeatureService)
+
+// WithRolloutService sets the service making the feature rollout decisions, for instance a RolloutService created
+// with WithRolloutUserProfileService
+func WithRolloutService(rolloutService FeatureService) CFSOptionFunc {
+	return func(f *CompositeFeatureService) {
+		f.rolloutService = rolloutService
+	}
+}
+
 // CompositeFeatureService is the default out-of-the-box feature decision service
 type CompositeFeatureService struct {
 	featureServices []FeatureService
+	rolloutService  FeatureService
 }
 
-// NewCompositeFeatureService returns a new instance of the CompositeFeatureService
-func NewCompositeFeatureService(compositeExperimentService ExperimentService) *CompositeFeatureService {
-	return &CompositeFeatureService{
-		featureServices: []FeatureService{
-			NewFeatureExperimentService(compositeExperimentService),
-			NewRolloutService(),
-		},
-	}
+// NewCompositeFeatureService returns a new instance of the CompositeFeatureService. Use a FeaturePipeline to
+// customize the feature decision services.
+func NewCompositeFeatureService(compositeExperimentService ExperimentService, options ...CFSOptionFunc) *CompositeFeatureService {
+	compositeFeatureService, _ := NewFeaturePipeline(compositeExperimentService, options...).Build()
+	return compositeFeatureService
 }
 
 // GetDecision returns a decision for the given feature and user context
@@ -51,7 +60,7 @@ func (f CompositeFeatureService) GetDecision(decisionContext FeatureDecisionCont
 			cfLogger.Debug(fmt.Sprintf("%v", err))
 		}
 
-		if featureDecision.Variation != nil && err == nil {
+		if (featureDecision.Variation != nil || featureDecision.isFinal()) && err == nil {
 			return featureDecision, err
 		}
 	}
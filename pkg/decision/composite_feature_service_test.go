@@ -164,6 +164,14 @@ func (s *CompositeFeatureServiceTestSuite) TestNewCompositeFeatureService() {
 	s.IsType(&RolloutService{}, compositeFeatureService.featureServices[1])
 }
 
+func (s *CompositeFeatureServiceTestSuite) TestNewCompositeFeatureServiceWithRolloutService() {
+	compositeExperimentService := NewCompositeExperimentService()
+	rolloutService := NewRolloutService(WithRolloutUserProfileService(new(MockUserProfileServiceV2)))
+	compositeFeatureService := NewCompositeFeatureService(compositeExperimentService, WithRolloutService(rolloutService))
+	s.Equal(2, len(compositeFeatureService.featureServices))
+	s.Equal(rolloutService, compositeFeatureService.featureServices[1])
+}
+
 func TestCompositeFeatureTestSuite(t *testing.T) {
 	suite.Run(t, new(CompositeFeatureServiceTestSuite))
 }
@@ -46,6 +46,14 @@ func WithCompositeExperimentService(compositeExperimentService ExperimentService
 	}
 }
 
+// WithCompositeFeatureService sets the composite feature service on the CompositeService. It takes precedence over the
+// feature service built around the composite experiment service.
+func WithCompositeFeatureService(compositeFeatureService FeatureService) CSOptionFunc {
+	return func(f *CompositeService) {
+		f.compositeFeatureService = compositeFeatureService
+	}
+}
+
 // NewCompositeService returns a new instance of the CompositeService with the defaults
 func NewCompositeService(sdkKey string, options ...CSOptionFunc) *CompositeService {
 	compositeService := &CompositeService{
@@ -59,7 +67,9 @@ func NewCompositeService(sdkKey string, options ...CSOptionFunc) *CompositeServi
 	if compositeService.compositeExperimentService == nil {
 		compositeService.compositeExperimentService = NewCompositeExperimentService()
 	}
-	compositeService.compositeFeatureService = NewCompositeFeatureService(compositeService.compositeExperimentService)
+	if compositeService.compositeFeatureService == nil {
+		compositeService.compositeFeatureService = NewCompositeFeatureService(compositeService.compositeExperimentService)
+	}
 
 	return compositeService
 }
@@ -71,10 +81,18 @@ func (s CompositeService) GetFeatureDecision(featureDecisionContext FeatureDecis
 	// @TODO: add errors
 	if s.notificationCenter != nil {
 		sourceInfo := map[string]string{}
+		typedFeatureInfo := notification.FeatureDecisionInfo{
+			FeatureKey: featureDecisionContext.Feature.Key,
+			Source:     string(featureDecision.Source),
+		}
 
 		if featureDecision.Source == FeatureTest {
 			sourceInfo["experimentKey"] = featureDecision.Experiment.Key
 			sourceInfo["variationKey"] = featureDecision.Variation.Key
+			typedFeatureInfo.SourceInfo = notification.SourceInfo{
+				ExperimentKey: featureDecision.Experiment.Key,
+				VariationKey:  featureDecision.Variation.Key,
+			}
 		}
 
 		featureInfo := map[string]interface{}{
@@ -85,15 +103,25 @@ func (s CompositeService) GetFeatureDecision(featureDecisionContext FeatureDecis
 		}
 		if featureDecision.Variation != nil {
 			featureInfo["featureEnabled"] = featureDecision.Variation.FeatureEnabled
+			typedFeatureInfo.FeatureEnabled = featureDecision.Variation.FeatureEnabled
 		}
 
-		notificationType := notification.Feature
+		decisionNotification := notification.DecisionNotification{
+			Type:        notification.Feature,
+			UserContext: userContext,
+		}
 		variable := featureDecisionContext.Variable
 		if variable.ID != "" && variable.Key != "" {
 			featureInfo["variableKey"] = variable.Key
 			featureInfo["variableType"] = variable.Type
 
-			notificationType = notification.FeatureVariable
+			variableInfo := &notification.FeatureVariableDecisionInfo{
+				FeatureDecisionInfo: typedFeatureInfo,
+				VariableKey:         variable.Key,
+				VariableType:        variable.Type,
+			}
+			decisionNotification.Type = notification.FeatureVariable
+			decisionNotification.FeatureVariableInfo = variableInfo
 			variableValue := variable.DefaultValue
 
 			if featureDecision.Variation != nil {
@@ -120,17 +148,14 @@ func (s CompositeService) GetFeatureDecision(featureDecisionContext FeatureDecis
 			} else {
 				featureInfo["variableValue"] = convertedValue
 			}
+			variableInfo.VariableValue = featureInfo["variableValue"]
+		} else {
+			decisionNotification.FeatureInfo = &typedFeatureInfo
 		}
 
-		decisionInfo := map[string]interface{}{
+		decisionNotification.DecisionInfo = map[string]interface{}{
 			"feature": featureInfo,
 		}
-
-		decisionNotification := notification.DecisionNotification{
-			DecisionInfo: decisionInfo,
-			Type:         notificationType,
-			UserContext:  userContext,
-		}
 		if err = s.notificationCenter.Send(notification.Decision, decisionNotification); err != nil {
 			csLogger.Warning("Problem with sending notification")
 		}
@@ -149,17 +174,28 @@ func (s CompositeService) GetExperimentDecision(experimentDecisionContext Experi
 			"experimentKey": experimentDecisionContext.Experiment.Key,
 		}
 
+		variationKey := ""
 		if experimentDecision.Variation != nil {
-			decisionInfo["variationKey"] = experimentDecision.Variation.Key
+			variationKey = experimentDecision.Variation.Key
+			decisionInfo["variationKey"] = variationKey
 		}
 
 		decisionNotification := notification.DecisionNotification{
 			DecisionInfo: decisionInfo,
 			UserContext:  userContext,
-			Type:         notification.ABTest,
 		}
 		if experimentDecisionContext.Experiment.IsFeatureExperiment {
 			decisionNotification.Type = notification.FeatureTest
+			decisionNotification.FeatureTestInfo = &notification.FeatureTestDecisionInfo{
+				ExperimentKey: experimentDecisionContext.Experiment.Key,
+				VariationKey:  variationKey,
+			}
+		} else {
+			decisionNotification.Type = notification.ABTest
+			decisionNotification.ABTestInfo = &notification.ABTestDecisionInfo{
+				ExperimentKey: experimentDecisionContext.Experiment.Key,
+				VariationKey:  variationKey,
+			}
 		}
 
 		if err = s.notificationCenter.Send(notification.Decision, decisionNotification); err != nil {
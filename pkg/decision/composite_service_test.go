@@ -128,6 +128,19 @@ func (s *CompositeServiceFeatureTestSuite) TestDecisionListenersNotificationWith
 
 	s.Equal(expectedDecisionInfo, note.DecisionInfo)
 
+	expectedVariableInfo := &notification.FeatureVariableDecisionInfo{
+		FeatureDecisionInfo: notification.FeatureDecisionInfo{
+			FeatureKey: "my_test_feature_3333",
+			Source:     string(FeatureTest),
+			SourceInfo: notification.SourceInfo{ExperimentKey: "test_experiment_1111", VariationKey: "2222"},
+		},
+		VariableKey:   "Key",
+		VariableType:  entities.Double,
+		VariableValue: 23.34,
+	}
+	s.Equal(notification.FeatureVariable, note.Type)
+	s.Equal(expectedVariableInfo, note.FeatureVariableInfo)
+	s.Nil(note.FeatureInfo)
 }
 
 func (s *CompositeServiceFeatureTestSuite) TestDecisionListenersNotificationWithIntegerVariable() {
@@ -273,6 +286,15 @@ func (s *CompositeServiceFeatureTestSuite) TestDecisionListenersNotificationWith
 		"sourceInfo": map[string]string{"experimentKey": "test_experiment_1111", "variationKey": "2222"}}}
 
 	s.Equal(expectedDecisionInfo, note.DecisionInfo)
+
+	expectedFeatureInfo := &notification.FeatureDecisionInfo{
+		FeatureKey: "my_test_feature_3333",
+		Source:     string(FeatureTest),
+		SourceInfo: notification.SourceInfo{ExperimentKey: "test_experiment_1111", VariationKey: "2222"},
+	}
+	s.Equal(notification.Feature, note.Type)
+	s.Equal(expectedFeatureInfo, note.FeatureInfo)
+	s.Nil(note.FeatureVariableInfo)
 }
 
 func (s *CompositeServiceFeatureTestSuite) TestNewCompositeService() {
@@ -288,6 +310,10 @@ func (s *CompositeServiceFeatureTestSuite) TestNewCompositeServiceWithCustomOpti
 	compositeService := NewCompositeService("sdk_key", WithCompositeExperimentService(compositeExperimentService))
 	s.IsType(compositeExperimentService, compositeService.compositeExperimentService)
 	s.IsType(&CompositeFeatureService{}, compositeService.compositeFeatureService)
+
+	compositeFeatureService := NewCompositeFeatureService(compositeExperimentService)
+	compositeService = NewCompositeService("sdk_key", WithCompositeFeatureService(compositeFeatureService))
+	s.Equal(compositeFeatureService, compositeService.compositeFeatureService)
 }
 
 type CompositeServiceExperimentTestSuite struct {
@@ -338,7 +364,9 @@ func (s *CompositeServiceExperimentTestSuite) TestDecisionListeners() {
 	decisionService.GetExperimentDecision(s.decisionContext, s.testUserContext)
 
 	var numberOfCalls = 0
+	note := notification.DecisionNotification{}
 	callback := func(notification notification.DecisionNotification) {
+		note = notification
 		numberOfCalls++
 	}
 	id, _ := decisionService.OnDecision(callback)
@@ -346,6 +374,9 @@ func (s *CompositeServiceExperimentTestSuite) TestDecisionListeners() {
 	s.NotEqual(id, 0)
 	decisionService.GetExperimentDecision(s.decisionContext, s.testUserContext)
 	s.Equal(numberOfCalls, 1)
+	s.Equal(notification.ABTest, note.Type)
+	s.Equal(&notification.ABTestDecisionInfo{ExperimentKey: testExp1111.Key, VariationKey: testExp1111Var2222.Key}, note.ABTestInfo)
+	s.Nil(note.FeatureTestInfo)
 
 	err := decisionService.RemoveOnDecision(id)
 	s.NoError(err)
@@ -27,6 +27,7 @@ import (
 type ExperimentDecisionContext struct {
 	Experiment    *entities.Experiment
 	ProjectConfig config.ProjectConfig
+	UserProfile   *UserProfileTracker // optional, shares one profile lookup and save between decisions
 }
 
 // FeatureDecisionContext contains the information needed to be able to make a decision for a given feature
@@ -34,6 +35,7 @@ type FeatureDecisionContext struct {
 	Feature       *entities.Feature
 	ProjectConfig config.ProjectConfig
 	Variable      entities.Variable
+	UserProfile   *UserProfileTracker // optional, shares one profile lookup and save between decisions
 }
 
 // Source is where the decision came from
@@ -44,6 +46,8 @@ const (
 	Rollout Source = "rollout"
 	// FeatureTest - the decision came from a feature test
 	FeatureTest Source = "feature-test"
+	// HoldoutSource - the feature is off because the user is in a holdout
+	HoldoutSource Source = "holdout"
 )
 
 // Decision contains base information about a decision
@@ -51,18 +55,25 @@ type Decision struct {
 	Reason reasons.Reason
 }
 
+// isFinal returns whether the decision ends a pipeline even though it has no variation
+func (d Decision) isFinal() bool {
+	return d.Reason == reasons.InHoldout
+}
+
 // FeatureDecision contains the decision information about a feature
 type FeatureDecision struct {
 	Decision
 	Source     Source
 	Experiment entities.Experiment
 	Variation  *entities.Variation
+	Holdout    *Holdout // the holdout of the user if the reason is InHoldout
 }
 
 // ExperimentDecision contains the decision information about an experiment
 type ExperimentDecision struct {
 	Decision
 	Variation *entities.Variation
+	Holdout   *Holdout // the holdout of the user if the reason is InHoldout
 }
 
 // UserDecisionKey is used to access the saved decisions in a user profile
@@ -30,6 +30,23 @@ import (
 // These variables are package-scoped, meaning that they can be accessed within the same package so we need unique names.
 var bLogger = logging.GetLogger("ExperimentBucketerService")
 
+// EBSOptionFunc is used to pass custom config options into the ExperimentBucketerService.
+type EBSOptionFunc func(*ExperimentBucketerService)
+
+// WithExperimentBucketer sets the bucketer assigning users to variations
+func WithExperimentBucketer(experimentBucketer bucketer.ExperimentBucketer) EBSOptionFunc {
+	return func(s *ExperimentBucketerService) {
+		s.bucketer = experimentBucketer
+	}
+}
+
+// WithAudienceTreeEvaluator sets the evaluator of the experiment audience conditions
+func WithAudienceTreeEvaluator(audienceTreeEvaluator evaluator.TreeEvaluator) EBSOptionFunc {
+	return func(s *ExperimentBucketerService) {
+		s.audienceTreeEvaluator = audienceTreeEvaluator
+	}
+}
+
 // ExperimentBucketerService makes a decision using the experiment bucketer
 type ExperimentBucketerService struct {
 	audienceTreeEvaluator evaluator.TreeEvaluator
@@ -37,12 +54,16 @@ type ExperimentBucketerService struct {
 }
 
 // NewExperimentBucketerService returns a new instance of the ExperimentBucketerService
-func NewExperimentBucketerService() *ExperimentBucketerService {
+func NewExperimentBucketerService(options ...EBSOptionFunc) *ExperimentBucketerService {
 	// @TODO(mng): add experiment override service
-	return &ExperimentBucketerService{
+	experimentBucketerService := &ExperimentBucketerService{
 		audienceTreeEvaluator: evaluator.NewMixedTreeEvaluator(),
 		bucketer:              *bucketer.NewMurmurhashExperimentBucketer(bucketer.DefaultHashSeed),
 	}
+	for _, opt := range options {
+		opt(experimentBucketerService)
+	}
+	return experimentBucketerService
 }
 
 // GetDecision returns the decision with the variation the user is bucketed into
@@ -47,6 +47,7 @@ func (f FeatureExperimentService) GetDecision(decisionContext FeatureDecisionCon
 		experimentDecisionContext := ExperimentDecisionContext{
 			Experiment:    &experiment,
 			ProjectConfig: decisionContext.ProjectConfig,
+			UserProfile:   decisionContext.UserProfile,
 		}
 
 		experimentDecision, err := f.compositeExperimentService.GetDecision(experimentDecisionContext, userContext)
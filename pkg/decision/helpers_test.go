@@ -22,8 +22,11 @@
 package decision
 
 import (
+	"context"
+
 	"github.com/optimizely/go-sdk/pkg/config"
 	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/metrics"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -89,6 +92,47 @@ func (m *MockUserProfileService) Save(userProfile UserProfile) {
 	m.Called(userProfile)
 }
 
+type MockUserProfileServiceV2 struct {
+	mock.Mock
+}
+
+func (m *MockUserProfileServiceV2) Lookup(ctx context.Context, userID string) (UserProfile, error) {
+	args := m.Called(userID)
+	return args.Get(0).(UserProfile), args.Error(1)
+}
+
+func (m *MockUserProfileServiceV2) Save(ctx context.Context, userProfile UserProfile) error {
+	return m.Called(userProfile).Error(0)
+}
+
+// counting metrics registry
+type testCounter struct {
+	value float64
+}
+
+func (c *testCounter) Add(value float64) {
+	c.value += value
+}
+
+type testRegistry struct {
+	counters map[string]*testCounter
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{counters: map[string]*testCounter{}}
+}
+
+func (r *testRegistry) GetCounter(key string) metrics.Counter {
+	if _, ok := r.counters[key]; !ok {
+		r.counters[key] = &testCounter{}
+	}
+	return r.counters[key]
+}
+
+func (r *testRegistry) GetGauge(key string) metrics.Gauge {
+	return metrics.NewNoopRegistry().GetGauge(key)
+}
+
 func (m *MockAudienceTreeEvaluator) Evaluate(node *entities.TreeNode, condTreeParams *entities.TreeParameters) (evalResult, isValid bool) {
 	args := m.Called(node, condTreeParams)
 	return args.Bool(0), args.Bool(1)
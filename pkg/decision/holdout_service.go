@@ -0,0 +1,122 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package decision //
+package decision
+
+import (
+	"fmt"
+
+	"github.com/optimizely/go-sdk/pkg/decision/bucketer"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/logging"
+)
+
+var hsLogger = logging.GetLogger("HoldoutService")
+
+// HoldoutVariationKey is the key of the variation reported in the impressions of held out users
+const HoldoutVariationKey = "holdout"
+
+// Holdout is a slice of the traffic excluded from all the experiments and feature flags, to measure their cumulative
+// impact. Users are held out if their bucketing value, hashed with the holdout ID, is below EndOfRange (out of 10000).
+// Their impressions report the holdout ID as experiment, campaign and variation ID.
+type Holdout struct {
+	ID         string
+	Key        string
+	EndOfRange int
+}
+
+// Experiment returns the experiment reported in the impressions of the holdout
+func (h Holdout) Experiment() entities.Experiment {
+	return entities.Experiment{ID: h.ID, Key: h.Key, LayerID: h.ID}
+}
+
+// Variation returns the variation reported in the impressions of the holdout
+func (h Holdout) Variation() entities.Variation {
+	return entities.Variation{ID: h.ID, Key: HoldoutVariationKey}
+}
+
+type holdoutBucketer struct {
+	holdout  Holdout
+	bucketer bucketer.Bucketer
+}
+
+func newHoldoutBucketer(holdout Holdout) holdoutBucketer {
+	return holdoutBucketer{
+		holdout:  holdout,
+		bucketer: bucketer.NewMurmurhashBucketer(bucketer.DefaultHashSeed),
+	}
+}
+
+// holdOut returns whether the user is held out
+func (h holdoutBucketer) holdOut(userContext entities.UserContext) bool {
+	bucketingID, err := userContext.GetBucketingID()
+	if err != nil {
+		hsLogger.Debug(fmt.Sprintf(`Error computing bucketing ID for holdout "%s": "%s"`, h.holdout.Key, err.Error()))
+	}
+
+	trafficAllocation := []entities.Range{{EntityID: h.holdout.ID, EndOfRange: h.holdout.EndOfRange}}
+	if h.bucketer.BucketToEntity(bucketingID+h.holdout.ID, trafficAllocation) == "" {
+		return false
+	}
+
+	hsLogger.Debug(fmt.Sprintf(`User "%s" is in holdout "%s".`, userContext.ID, h.holdout.Key))
+	return true
+}
+
+// ExperimentHoldoutService is an experiment decision stage returning no variation for the users of a holdout
+type ExperimentHoldoutService struct {
+	holdoutBucketer
+}
+
+// NewExperimentHoldoutService returns a new instance of the ExperimentHoldoutService
+func NewExperimentHoldoutService(holdout Holdout) *ExperimentHoldoutService {
+	return &ExperimentHoldoutService{holdoutBucketer: newHoldoutBucketer(holdout)}
+}
+
+// GetDecision returns a decision with the InHoldout reason, the holdout and no variation if the user is held out
+func (s ExperimentHoldoutService) GetDecision(decisionContext ExperimentDecisionContext, userContext entities.UserContext) (ExperimentDecision, error) {
+	experimentDecision := ExperimentDecision{}
+	if s.holdOut(userContext) {
+		holdout := s.holdout
+		experimentDecision.Reason = reasons.InHoldout
+		experimentDecision.Holdout = &holdout
+	}
+	return experimentDecision, nil
+}
+
+// FeatureHoldoutService is a feature decision stage turning the features off for the users of a holdout
+type FeatureHoldoutService struct {
+	holdoutBucketer
+}
+
+// NewFeatureHoldoutService returns a new instance of the FeatureHoldoutService
+func NewFeatureHoldoutService(holdout Holdout) *FeatureHoldoutService {
+	return &FeatureHoldoutService{holdoutBucketer: newHoldoutBucketer(holdout)}
+}
+
+// GetDecision returns a decision with the InHoldout reason, the holdout and no variation if the user is held out
+func (s FeatureHoldoutService) GetDecision(decisionContext FeatureDecisionContext, userContext entities.UserContext) (FeatureDecision, error) {
+	featureDecision := FeatureDecision{}
+	if s.holdOut(userContext) {
+		holdout := s.holdout
+		featureDecision.Reason = reasons.InHoldout
+		featureDecision.Source = HoldoutSource
+		featureDecision.Holdout = &holdout
+	}
+	return featureDecision, nil
+}
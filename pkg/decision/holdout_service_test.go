@@ -0,0 +1,163 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package decision
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/entities"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type holdoutProjectConfig struct {
+	config.ProjectConfig
+}
+
+func (c holdoutProjectConfig) GetProjectID() string  { return "project_1" }
+func (c holdoutProjectConfig) GetRevision() string   { return "1" }
+func (c holdoutProjectConfig) GetAccountID() string  { return "account_1" }
+func (c holdoutProjectConfig) GetAnonymizeIP() bool  { return false }
+func (c holdoutProjectConfig) GetBotFiltering() bool { return false }
+func (c holdoutProjectConfig) GetAudienceMap() map[string]entities.Audience {
+	return map[string]entities.Audience{}
+}
+
+type HoldoutServiceTestSuite struct {
+	suite.Suite
+	projectConfig holdoutProjectConfig
+	holdout       Holdout
+}
+
+func (s *HoldoutServiceTestSuite) SetupTest() {
+	s.holdout = Holdout{ID: "holdout_1", Key: "global_holdout", EndOfRange: 10000}
+}
+
+func (s *HoldoutServiceTestSuite) TestExperimentHoldout() {
+	holdoutService := NewExperimentHoldoutService(s.holdout)
+	userContext := entities.UserContext{ID: "test_user_1"}
+	decision, err := holdoutService.GetDecision(ExperimentDecisionContext{
+		Experiment:    &testExp1111,
+		ProjectConfig: s.projectConfig,
+	}, userContext)
+	s.NoError(err)
+	s.Equal(ExperimentDecision{Decision: Decision{Reason: reasons.InHoldout}, Holdout: &s.holdout}, decision)
+}
+
+func (s *HoldoutServiceTestSuite) TestFeatureHoldout() {
+	holdoutService := NewFeatureHoldoutService(s.holdout)
+	decision, err := holdoutService.GetDecision(FeatureDecisionContext{
+		Feature:       &testFeat3335,
+		ProjectConfig: s.projectConfig,
+	}, entities.UserContext{ID: "test_user_1"})
+	s.NoError(err)
+	s.Equal(FeatureDecision{Decision: Decision{Reason: reasons.InHoldout}, Source: HoldoutSource, Holdout: &s.holdout}, decision)
+}
+
+func (s *HoldoutServiceTestSuite) TestHoldoutImpressionEntities() {
+	s.Equal(entities.Experiment{ID: "holdout_1", Key: "global_holdout", LayerID: "holdout_1"}, s.holdout.Experiment())
+	s.Equal(entities.Variation{ID: "holdout_1", Key: HoldoutVariationKey}, s.holdout.Variation())
+}
+
+func (s *HoldoutServiceTestSuite) TestNotInHoldout() {
+	s.holdout.EndOfRange = 0
+	holdoutService := NewExperimentHoldoutService(s.holdout)
+	decision, err := holdoutService.GetDecision(ExperimentDecisionContext{
+		Experiment:    &testExp1111,
+		ProjectConfig: s.projectConfig,
+	}, entities.UserContext{ID: "test_user_1"})
+	s.NoError(err)
+	s.Equal(ExperimentDecision{}, decision)
+}
+
+func (s *HoldoutServiceTestSuite) TestHoldoutRange() {
+	s.holdout.EndOfRange = 1000
+	holdoutService := NewExperimentHoldoutService(s.holdout)
+	decisionContext := ExperimentDecisionContext{Experiment: &testExp1111, ProjectConfig: s.projectConfig}
+
+	heldOut := 0
+	for i := 0; i < 10000; i++ {
+		userContext := entities.UserContext{ID: fmt.Sprintf("user_%d", i)}
+		decision, _ := holdoutService.GetDecision(decisionContext, userContext)
+		if decision.Reason == reasons.InHoldout {
+			heldOut++
+		}
+		// the assignment is deterministic
+		again, _ := holdoutService.GetDecision(decisionContext, userContext)
+		s.Equal(decision, again)
+	}
+	s.InDelta(1000, heldOut, 150)
+}
+
+func (s *HoldoutServiceTestSuite) TestHoldoutUsesBucketingID() {
+	holdoutService := NewExperimentHoldoutService(Holdout{ID: "holdout_1", EndOfRange: 5000})
+	decisionContext := ExperimentDecisionContext{Experiment: &testExp1111, ProjectConfig: s.projectConfig}
+
+	// users sharing a bucketing ID share the holdout assignment
+	var expected ExperimentDecision
+	for i := 0; i < 20; i++ {
+		userContext := entities.UserContext{
+			ID:         fmt.Sprintf("user_%d", i),
+			Attributes: map[string]interface{}{"$opt_bucketing_id": "shared_id"},
+		}
+		decision, _ := holdoutService.GetDecision(decisionContext, userContext)
+		if i == 0 {
+			expected = decision
+		}
+		s.Equal(expected, decision)
+	}
+}
+
+func (s *HoldoutServiceTestSuite) TestHoldoutStopsPipelines() {
+	mockExperimentService := new(MockExperimentDecisionService)
+	compositeExperimentService, err := NewExperimentPipeline().
+		Prepend(HoldoutStage, NewExperimentHoldoutService(s.holdout)).
+		Append("custom", mockExperimentService).
+		Build()
+	s.NoError(err)
+	decision, err := compositeExperimentService.GetDecision(ExperimentDecisionContext{
+		Experiment:    &testExp1111,
+		ProjectConfig: s.projectConfig,
+	}, entities.UserContext{ID: "test_user_1"})
+	s.NoError(err)
+	s.Equal(reasons.InHoldout, decision.Reason)
+	s.Nil(decision.Variation)
+	mockExperimentService.AssertNotCalled(s.T(), "GetDecision", mock.Anything, mock.Anything)
+
+	mockFeatureService := new(MockFeatureDecisionService)
+	compositeFeatureService, err := NewFeaturePipeline(compositeExperimentService).
+		Replace(RolloutStage, mockFeatureService).
+		Prepend(HoldoutStage, NewFeatureHoldoutService(s.holdout)).
+		Build()
+	s.NoError(err)
+	featureDecision, err := compositeFeatureService.GetDecision(FeatureDecisionContext{
+		Feature:       &testFeat3335,
+		ProjectConfig: s.projectConfig,
+	}, entities.UserContext{ID: "test_user_1"})
+	s.NoError(err)
+	s.Equal(reasons.InHoldout, featureDecision.Reason)
+	s.Nil(featureDecision.Variation)
+	mockFeatureService.AssertNotCalled(s.T(), "GetDecision", mock.Anything, mock.Anything)
+}
+
+func TestHoldoutServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(HoldoutServiceTestSuite))
+}
@@ -18,6 +18,8 @@
 package decision
 
 import (
+	"context"
+
 	"github.com/optimizely/go-sdk/pkg/entities"
 	"github.com/optimizely/go-sdk/pkg/notification"
 )
@@ -45,3 +47,10 @@ type UserProfileService interface {
 	Lookup(string) UserProfile
 	Save(UserProfile)
 }
+
+// UserProfileServiceV2 is used to save and retrieve past bucketing decisions for users, reporting store failures.
+// Lookup returns an empty profile and no error when there is no saved profile for the user.
+type UserProfileServiceV2 interface {
+	Lookup(ctx context.Context, userID string) (UserProfile, error)
+	Save(ctx context.Context, profile UserProfile) error
+}
@@ -18,10 +18,12 @@
 package decision
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/optimizely/go-sdk/pkg/entities"
 	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/metrics"
 )
 
 var pesLogger = logging.GetLogger("PersistingExperimentService")
@@ -29,52 +31,82 @@ var pesLogger = logging.GetLogger("PersistingExperimentService")
 // PersistingExperimentService attempts to retrieve a saved decision from the user profile service
 // for the user before having the ExperimentBucketerService compute it.
 // If computed, the decision is saved back to the user profile service if provided.
+//
+// Store failures never fail the decision. If the lookup fails the decision is computed but not saved, as saving
+// would overwrite the decisions of the profile which could not be read. If the save fails the computed decision
+// is still returned. Failures are logged and counted in the ups.lookupError and ups.saveError metrics.
 type PersistingExperimentService struct {
 	experimentBucketedService ExperimentService
-	userProfileService        UserProfileService
+	userProfileService        UserProfileServiceV2
+
+	lookupErrorCounter metrics.Counter
+	saveErrorCounter   metrics.Counter
 }
 
 // NewPersistingExperimentService returns a new instance of the PersistingExperimentService
 func NewPersistingExperimentService(experimentBucketerService ExperimentService, userProfileService UserProfileService) *PersistingExperimentService {
+	return NewPersistingExperimentServiceV2(experimentBucketerService, NewUserProfileServiceAdapter(userProfileService), nil)
+}
+
+// NewPersistingExperimentServiceV2 returns a new instance of the PersistingExperimentService using a
+// UserProfileServiceV2, counting store failures in the given metrics registry
+func NewPersistingExperimentServiceV2(experimentBucketerService ExperimentService, userProfileService UserProfileServiceV2, metricsRegistry metrics.Registry) *PersistingExperimentService {
+	if metricsRegistry == nil {
+		metricsRegistry = metrics.NewNoopRegistry()
+	}
+
 	persistingExperimentService := &PersistingExperimentService{
 		experimentBucketedService: experimentBucketerService,
 		userProfileService:        userProfileService,
+		lookupErrorCounter:        metricsRegistry.GetCounter(metrics.UserProfileLookupError),
+		saveErrorCounter:          metricsRegistry.GetCounter(metrics.UserProfileSaveError),
 	}
 
 	return persistingExperimentService
 }
 
-// GetDecision returns the decision with the variation the user is bucketed into
+// GetDecision returns the decision with the variation the user is bucketed into. If the decision context carries a
+// UserProfileTracker, the profile is read and updated through it and saving is left to its owner.
 func (p PersistingExperimentService) GetDecision(decisionContext ExperimentDecisionContext, userContext entities.UserContext) (experimentDecision ExperimentDecision, err error) {
 	if p.userProfileService == nil {
 		return p.experimentBucketedService.GetDecision(decisionContext, userContext)
 	}
 
-	var userProfile UserProfile
-	// check to see if there is a saved decision for the user
-	experimentDecision, userProfile = p.getSavedDecision(decisionContext, userContext)
-	if experimentDecision.Variation != nil {
-		return experimentDecision, nil
+	tracker := decisionContext.UserProfile
+	if tracker == nil {
+		tracker = NewUserProfileTracker(userContext.ID, p.userProfileService, p.lookupErrorCounter)
+		defer p.save(tracker)
+	}
+
+	// the lookup error is logged and counted by the tracker
+	userProfile, lookupErr := tracker.Profile(context.Background())
+	if lookupErr == nil {
+		// check to see if there is a saved decision for the user
+		experimentDecision = p.getSavedDecision(decisionContext, userContext, userProfile)
+		if experimentDecision.Variation != nil {
+			return experimentDecision, nil
+		}
 	}
 
 	experimentDecision, err = p.experimentBucketedService.GetDecision(decisionContext, userContext)
 	if experimentDecision.Variation != nil {
-		// save decision if a user profile service is provided
-		userProfile.ID = userContext.ID
-		p.saveDecision(userProfile, decisionContext.Experiment, experimentDecision)
+		if lookupErr != nil {
+			pesLogger.Warning(fmt.Sprintf(`Decision not saved for user "%s" because the user profile could not be looked up.`, userContext.ID))
+			return experimentDecision, err
+		}
+		tracker.SaveDecision(decisionContext.Experiment.ID, experimentDecision.Variation.ID)
 	}
 
 	return experimentDecision, err
 }
 
-func (p PersistingExperimentService) getSavedDecision(decisionContext ExperimentDecisionContext, userContext entities.UserContext) (ExperimentDecision, UserProfile) {
+func (p PersistingExperimentService) getSavedDecision(decisionContext ExperimentDecisionContext, userContext entities.UserContext, userProfile UserProfile) ExperimentDecision {
 	experimentDecision := ExperimentDecision{}
-	userProfile := p.userProfileService.Lookup(userContext.ID)
 
 	// look up experiment decision from user profile
 	decisionKey := NewUserDecisionKey(decisionContext.Experiment.ID)
 	if userProfile.ExperimentBucketMap == nil {
-		return experimentDecision, userProfile
+		return experimentDecision
 	}
 
 	if savedVariationID, ok := userProfile.ExperimentBucketMap[decisionKey]; ok {
@@ -86,17 +118,17 @@ func (p PersistingExperimentService) getSavedDecision(decisionContext Experiment
 		}
 	}
 
-	return experimentDecision, userProfile
+	return experimentDecision
 }
 
-func (p PersistingExperimentService) saveDecision(userProfile UserProfile, experiment *entities.Experiment, decision ExperimentDecision) {
-	if p.userProfileService != nil {
-		decisionKey := NewUserDecisionKey(experiment.ID)
-		if userProfile.ExperimentBucketMap == nil {
-			userProfile.ExperimentBucketMap = map[UserDecisionKey]string{}
-		}
-		userProfile.ExperimentBucketMap[decisionKey] = decision.Variation.ID
-		p.userProfileService.Save(userProfile)
-		pesLogger.Debug(fmt.Sprintf(`Decision saved for user "%s".`, userProfile.ID))
+func (p PersistingExperimentService) save(tracker *UserProfileTracker) {
+	if !tracker.hasChanges() {
+		return
+	}
+	if err := tracker.Save(context.Background()); err != nil {
+		pesLogger.Error(fmt.Sprintf(`Unable to save decision for user "%s".`, tracker.userID), err)
+		p.saveErrorCounter.Add(1)
+		return
 	}
+	pesLogger.Debug(fmt.Sprintf(`Decision saved for user "%s".`, tracker.userID))
 }
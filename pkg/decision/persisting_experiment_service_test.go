@@ -18,9 +18,13 @@
 package decision
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -120,6 +124,74 @@ func (s *PersistingExperimentServiceTestSuite) TestSavedVariationNoLongerValid()
 	s.mockUserProfileService.AssertExpectations(s.T())
 }
 
+func (s *PersistingExperimentServiceTestSuite) TestLookupErrorSkipsSave() {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", testUserContext.ID).Return(UserProfile{ID: testUserContext.ID}, errors.New("store down"))
+	metricsRegistry := newTestRegistry()
+
+	persistingExperimentService := NewPersistingExperimentServiceV2(s.mockExperimentService, mockUserProfileService, metricsRegistry)
+	decision, err := persistingExperimentService.GetDecision(s.testDecisionContext, testUserContext)
+	s.Equal(s.testComputedDecision, decision)
+	s.NoError(err)
+	s.mockExperimentService.AssertExpectations(s.T())
+	mockUserProfileService.AssertNotCalled(s.T(), "Save", mock.Anything)
+	s.Equal(float64(1), metricsRegistry.counters[metrics.UserProfileLookupError].value)
+}
+
+func (s *PersistingExperimentServiceTestSuite) TestSaveErrorReturnsDecision() {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", testUserContext.ID).Return(UserProfile{ID: testUserContext.ID}, nil)
+	mockUserProfileService.On("Save", mock.Anything).Return(errors.New("store down"))
+	metricsRegistry := newTestRegistry()
+
+	persistingExperimentService := NewPersistingExperimentServiceV2(s.mockExperimentService, mockUserProfileService, metricsRegistry)
+	decision, err := persistingExperimentService.GetDecision(s.testDecisionContext, testUserContext)
+	s.Equal(s.testComputedDecision, decision)
+	s.NoError(err)
+	mockUserProfileService.AssertExpectations(s.T())
+	s.Equal(float64(1), metricsRegistry.counters[metrics.UserProfileSaveError].value)
+	s.Equal(float64(0), metricsRegistry.counters[metrics.UserProfileLookupError].value)
+}
+
+func (s *PersistingExperimentServiceTestSuite) TestSharedTrackerIsNotSaved() {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", testUserContext.ID).Return(UserProfile{ID: testUserContext.ID}, nil)
+	tracker := NewUserProfileTracker(testUserContext.ID, mockUserProfileService, nil)
+	decisionContext := s.testDecisionContext
+	decisionContext.UserProfile = tracker
+	s.mockExperimentService.On("GetDecision", decisionContext, testUserContext).Return(s.testComputedDecision, nil)
+
+	persistingExperimentService := NewPersistingExperimentServiceV2(s.mockExperimentService, mockUserProfileService, nil)
+	decision, err := persistingExperimentService.GetDecision(decisionContext, testUserContext)
+	s.Equal(s.testComputedDecision, decision)
+	s.NoError(err)
+	mockUserProfileService.AssertNotCalled(s.T(), "Save", mock.Anything)
+
+	// the decision is recorded in the tracker and saved by its owner
+	mockUserProfileService.On("Save", UserProfile{
+		ID:                  testUserContext.ID,
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey(testExp1113.ID): s.testComputedDecision.Variation.ID},
+	}).Return(nil)
+	s.NoError(tracker.Save(context.Background()))
+	mockUserProfileService.AssertExpectations(s.T())
+}
+
+func TestUserProfileServiceAdapter(t *testing.T) {
+	assert.Nil(t, NewUserProfileServiceAdapter(nil))
+
+	mockUserProfileService := new(MockUserProfileService)
+	profile := UserProfile{ID: testUserContext.ID}
+	mockUserProfileService.On("Lookup", testUserContext.ID).Return(profile)
+	mockUserProfileService.On("Save", profile)
+
+	adapter := NewUserProfileServiceAdapter(mockUserProfileService)
+	lookedUp, err := adapter.Lookup(context.Background(), testUserContext.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, profile, lookedUp)
+	assert.NoError(t, adapter.Save(context.Background(), profile))
+	mockUserProfileService.AssertExpectations(t)
+}
+
 func TestPersistingExperimentServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(PersistingExperimentServiceTestSuite))
 }
@@ -0,0 +1,320 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package decision //
+package decision
+
+import (
+	"fmt"
+)
+
+// Names of the stages of the default decision pipelines
+const (
+	// OverrideStage returns the variation set in the experiment override store, if one was supplied
+	OverrideStage = "override"
+	// WhitelistStage returns the variation the user is whitelisted for
+	WhitelistStage = "whitelist"
+	// BucketingStage buckets the user, saving and reusing the decisions in the user profile service if one was supplied
+	BucketingStage = "bucketing"
+	// FeatureExperimentStage decides the feature through the experiments attached to it
+	FeatureExperimentStage = "featureExperiment"
+	// RolloutStage decides the feature through its rollout
+	RolloutStage = "rollout"
+	// HoldoutStage excludes the users of a holdout from all experiments and features. It is not part of the
+	// default pipelines, and goes before BucketingStage and RolloutStage so that overrides and whitelists still apply.
+	HoldoutStage = "holdout"
+)
+
+// Instrumentation observes the stages of the pipelines built by a PipelineBuilder, for instance to explain their
+// decisions. All its fields are optional.
+type Instrumentation struct {
+	// BucketerOptions customize the bucketer and the audience evaluator of the bucketing and rollout stages
+	BucketerOptions []EBSOptionFunc
+	// UserProfileService wraps the user profile service the decisions are looked up in and saved to
+	UserProfileService func(userProfileService UserProfileServiceV2) UserProfileServiceV2
+	// ExperimentStage and FeatureStage wrap the service of each stage
+	ExperimentStage func(name string, experimentService ExperimentService) ExperimentService
+	FeatureStage    func(name string, featureService FeatureService) FeatureService
+}
+
+// PipelineBuilder builds the experiment and feature decision services of a client, with their stages instrumented
+type PipelineBuilder func(instrumentation Instrumentation) (*CompositeExperimentService, *CompositeFeatureService, error)
+
+// stageList is an ordered list of named decision services
+type stageList struct {
+	names    []string
+	services []interface{}
+	err      error
+}
+
+func (l *stageList) index(name string) int {
+	for i, stageName := range l.names {
+		if stageName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *stageList) insert(position int, name string, service interface{}) {
+	if l.err != nil {
+		return
+	}
+	if l.index(name) >= 0 {
+		l.err = fmt.Errorf(`decision stage "%s" already exists`, name)
+		return
+	}
+	l.names = append(l.names[:position], append([]string{name}, l.names[position:]...)...)
+	l.services = append(l.services[:position], append([]interface{}{service}, l.services[position:]...)...)
+}
+
+func (l *stageList) find(name string) int {
+	if l.err != nil {
+		return -1
+	}
+	i := l.index(name)
+	if i < 0 {
+		l.err = fmt.Errorf(`decision stage "%s" not found`, name)
+	}
+	return i
+}
+
+func (l *stageList) insertBefore(existing, name string, service interface{}) {
+	if i := l.find(existing); i >= 0 {
+		l.insert(i, name, service)
+	}
+}
+
+func (l *stageList) insertAfter(existing, name string, service interface{}) {
+	if i := l.find(existing); i >= 0 {
+		l.insert(i+1, name, service)
+	}
+}
+
+func (l *stageList) replace(name string, service interface{}) {
+	if i := l.find(name); i >= 0 {
+		l.services[i] = service
+	}
+}
+
+func (l *stageList) remove(name string) {
+	if i := l.find(name); i >= 0 {
+		l.names = append(l.names[:i], l.names[i+1:]...)
+		l.services = append(l.services[:i], l.services[i+1:]...)
+	}
+}
+
+func (l *stageList) wrap(wrap func(name string, service interface{}) interface{}) {
+	for i, name := range l.names {
+		l.services[i] = wrap(name, l.services[i])
+	}
+}
+
+func (l *stageList) stageNames() []string {
+	return append([]string{}, l.names...)
+}
+
+// ExperimentPipeline builds a CompositeExperimentService out of named stages, applied in order until one of them
+// returns a variation. It starts with the default stages: OverrideStage (if an override store was supplied),
+// WhitelistStage and BucketingStage. Errors, such as a reference to an unknown stage, are returned by Build.
+type ExperimentPipeline struct {
+	compositeExperimentService *CompositeExperimentService
+	stages                     stageList
+}
+
+// NewExperimentPipeline returns a pipeline with the default stages configured by the given options
+func NewExperimentPipeline(options ...CESOptionFunc) *ExperimentPipeline {
+	compositeExperimentService := &CompositeExperimentService{}
+	for _, opt := range options {
+		opt(compositeExperimentService)
+	}
+
+	pipeline := &ExperimentPipeline{compositeExperimentService: compositeExperimentService}
+	if compositeExperimentService.overrideStore != nil {
+		pipeline.Append(OverrideStage, NewExperimentOverrideService(compositeExperimentService.overrideStore))
+	}
+	pipeline.Append(WhitelistStage, NewExperimentWhitelistService())
+
+	experimentBucketerService := NewExperimentBucketerService(compositeExperimentService.bucketerOptions...)
+	userProfileService := compositeExperimentService.userProfileServiceV2
+	if userProfileService == nil {
+		userProfileService = NewUserProfileServiceAdapter(compositeExperimentService.userProfileService)
+	}
+	if userProfileService != nil {
+		persistingExperimentService := NewPersistingExperimentServiceV2(experimentBucketerService, userProfileService, compositeExperimentService.metricsRegistry)
+		pipeline.Append(BucketingStage, persistingExperimentService)
+	} else {
+		pipeline.Append(BucketingStage, experimentBucketerService)
+	}
+	return pipeline
+}
+
+// Prepend adds a stage applied before all the others
+func (p *ExperimentPipeline) Prepend(name string, experimentService ExperimentService) *ExperimentPipeline {
+	p.stages.insert(0, name, experimentService)
+	return p
+}
+
+// Append adds a stage applied after all the others
+func (p *ExperimentPipeline) Append(name string, experimentService ExperimentService) *ExperimentPipeline {
+	p.stages.insert(len(p.stages.names), name, experimentService)
+	return p
+}
+
+// InsertBefore adds a stage applied right before the existing one
+func (p *ExperimentPipeline) InsertBefore(existing, name string, experimentService ExperimentService) *ExperimentPipeline {
+	p.stages.insertBefore(existing, name, experimentService)
+	return p
+}
+
+// InsertAfter adds a stage applied right after the existing one
+func (p *ExperimentPipeline) InsertAfter(existing, name string, experimentService ExperimentService) *ExperimentPipeline {
+	p.stages.insertAfter(existing, name, experimentService)
+	return p
+}
+
+// Replace replaces the service of an existing stage
+func (p *ExperimentPipeline) Replace(name string, experimentService ExperimentService) *ExperimentPipeline {
+	p.stages.replace(name, experimentService)
+	return p
+}
+
+// Remove removes an existing stage
+func (p *ExperimentPipeline) Remove(name string) *ExperimentPipeline {
+	p.stages.remove(name)
+	return p
+}
+
+// Wrap replaces the service of each stage with the one returned by wrap, if it is not nil
+func (p *ExperimentPipeline) Wrap(wrap func(name string, experimentService ExperimentService) ExperimentService) *ExperimentPipeline {
+	if wrap != nil {
+		p.stages.wrap(func(name string, service interface{}) interface{} {
+			return wrap(name, service.(ExperimentService))
+		})
+	}
+	return p
+}
+
+// Stages returns the names of the stages in the order they are applied
+func (p *ExperimentPipeline) Stages() []string {
+	return p.stages.stageNames()
+}
+
+// Build returns a CompositeExperimentService applying the stages of the pipeline
+func (p *ExperimentPipeline) Build() (*CompositeExperimentService, error) {
+	if p.stages.err != nil {
+		return nil, p.stages.err
+	}
+
+	compositeExperimentService := *p.compositeExperimentService
+	compositeExperimentService.experimentServices = make([]ExperimentService, len(p.stages.services))
+	for i, service := range p.stages.services {
+		compositeExperimentService.experimentServices[i] = service.(ExperimentService)
+	}
+	return &compositeExperimentService, nil
+}
+
+// FeaturePipeline builds a CompositeFeatureService out of named stages, applied in order until one of them returns
+// a variation. It starts with the default stages: FeatureExperimentStage and RolloutStage. Errors, such as a
+// reference to an unknown stage, are returned by Build.
+type FeaturePipeline struct {
+	stages stageList
+}
+
+// NewFeaturePipeline returns a pipeline with the default stages, deciding feature tests with the given experiment
+// service
+func NewFeaturePipeline(compositeExperimentService ExperimentService, options ...CFSOptionFunc) *FeaturePipeline {
+	compositeFeatureService := &CompositeFeatureService{
+		rolloutService: NewRolloutService(),
+	}
+	for _, opt := range options {
+		opt(compositeFeatureService)
+	}
+
+	pipeline := &FeaturePipeline{}
+	pipeline.Append(FeatureExperimentStage, NewFeatureExperimentService(compositeExperimentService))
+	pipeline.Append(RolloutStage, compositeFeatureService.rolloutService)
+	return pipeline
+}
+
+// Prepend adds a stage applied before all the others
+func (p *FeaturePipeline) Prepend(name string, featureService FeatureService) *FeaturePipeline {
+	p.stages.insert(0, name, featureService)
+	return p
+}
+
+// Append adds a stage applied after all the others
+func (p *FeaturePipeline) Append(name string, featureService FeatureService) *FeaturePipeline {
+	p.stages.insert(len(p.stages.names), name, featureService)
+	return p
+}
+
+// InsertBefore adds a stage applied right before the existing one
+func (p *FeaturePipeline) InsertBefore(existing, name string, featureService FeatureService) *FeaturePipeline {
+	p.stages.insertBefore(existing, name, featureService)
+	return p
+}
+
+// InsertAfter adds a stage applied right after the existing one
+func (p *FeaturePipeline) InsertAfter(existing, name string, featureService FeatureService) *FeaturePipeline {
+	p.stages.insertAfter(existing, name, featureService)
+	return p
+}
+
+// Replace replaces the service of an existing stage
+func (p *FeaturePipeline) Replace(name string, featureService FeatureService) *FeaturePipeline {
+	p.stages.replace(name, featureService)
+	return p
+}
+
+// Remove removes an existing stage
+func (p *FeaturePipeline) Remove(name string) *FeaturePipeline {
+	p.stages.remove(name)
+	return p
+}
+
+// Wrap replaces the service of each stage with the one returned by wrap, if it is not nil
+func (p *FeaturePipeline) Wrap(wrap func(name string, featureService FeatureService) FeatureService) *FeaturePipeline {
+	if wrap != nil {
+		p.stages.wrap(func(name string, service interface{}) interface{} {
+			return wrap(name, service.(FeatureService))
+		})
+	}
+	return p
+}
+
+// Stages returns the names of the stages in the order they are applied
+func (p *FeaturePipeline) Stages() []string {
+	return p.stages.stageNames()
+}
+
+// Build returns a CompositeFeatureService applying the stages of the pipeline
+func (p *FeaturePipeline) Build() (*CompositeFeatureService, error) {
+	if p.stages.err != nil {
+		return nil, p.stages.err
+	}
+
+	compositeFeatureService := &CompositeFeatureService{
+		featureServices: make([]FeatureService, len(p.stages.services)),
+	}
+	for i, service := range p.stages.services {
+		compositeFeatureService.featureServices[i] = service.(FeatureService)
+		if p.stages.names[i] == RolloutStage {
+			compositeFeatureService.rolloutService = compositeFeatureService.featureServices[i]
+		}
+	}
+	return compositeFeatureService, nil
+}
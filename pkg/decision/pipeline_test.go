@@ -0,0 +1,190 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package decision
+
+import (
+	"testing"
+
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type PipelineTestSuite struct {
+	suite.Suite
+	mockConfig            *mockProjectConfig
+	mockExperimentService *MockExperimentDecisionService
+	mockFeatureService    *MockFeatureDecisionService
+	testUserContext       entities.UserContext
+}
+
+func (s *PipelineTestSuite) SetupTest() {
+	s.mockConfig = new(mockProjectConfig)
+	s.mockExperimentService = new(MockExperimentDecisionService)
+	s.mockFeatureService = new(MockFeatureDecisionService)
+	s.testUserContext = entities.UserContext{ID: "test_user_1"}
+}
+
+func (s *PipelineTestSuite) TestDefaultExperimentStages() {
+	s.Equal([]string{WhitelistStage, BucketingStage}, NewExperimentPipeline().Stages())
+
+	pipeline := NewExperimentPipeline(WithOverrideStore(new(MapExperimentOverridesStore)), WithUserProfileServiceV2(new(MockUserProfileServiceV2)))
+	s.Equal([]string{OverrideStage, WhitelistStage, BucketingStage}, pipeline.Stages())
+	compositeExperimentService, err := pipeline.Build()
+	s.NoError(err)
+	s.IsType(&ExperimentOverrideService{}, compositeExperimentService.experimentServices[0])
+	s.IsType(&ExperimentWhitelistService{}, compositeExperimentService.experimentServices[1])
+	s.IsType(&PersistingExperimentService{}, compositeExperimentService.experimentServices[2])
+}
+
+func (s *PipelineTestSuite) TestInsertExperimentStages() {
+	pipeline := NewExperimentPipeline().
+		Prepend("first", s.mockExperimentService).
+		InsertBefore(BucketingStage, "beforeBucketing", s.mockExperimentService).
+		InsertAfter(WhitelistStage, "afterWhitelist", s.mockExperimentService).
+		Append("last", s.mockExperimentService)
+	s.Equal([]string{"first", WhitelistStage, "afterWhitelist", "beforeBucketing", BucketingStage, "last"}, pipeline.Stages())
+
+	compositeExperimentService, err := pipeline.Build()
+	s.NoError(err)
+	s.Len(compositeExperimentService.experimentServices, 6)
+	s.Equal(s.mockExperimentService, compositeExperimentService.experimentServices[0])
+	s.IsType(&ExperimentBucketerService{}, compositeExperimentService.experimentServices[4])
+}
+
+func (s *PipelineTestSuite) TestCustomExperimentStageDecides() {
+	decisionContext := ExperimentDecisionContext{
+		Experiment:    &testExp1111,
+		ProjectConfig: s.mockConfig,
+	}
+	blockedDecision := ExperimentDecision{Variation: &testExp1111Var2222}
+	s.mockExperimentService.On("GetDecision", decisionContext, s.testUserContext).Return(blockedDecision, nil)
+
+	compositeExperimentService, err := NewExperimentPipeline().InsertBefore(WhitelistStage, "blocklist", s.mockExperimentService).Build()
+	s.NoError(err)
+	decision, err := compositeExperimentService.GetDecision(decisionContext, s.testUserContext)
+	s.NoError(err)
+	s.Equal(blockedDecision, decision)
+	s.mockExperimentService.AssertExpectations(s.T())
+}
+
+func (s *PipelineTestSuite) TestReplaceAndRemoveExperimentStages() {
+	compositeExperimentService, err := NewExperimentPipeline().
+		Replace(BucketingStage, s.mockExperimentService).
+		Remove(WhitelistStage).
+		Build()
+	s.NoError(err)
+	s.Equal([]ExperimentService{s.mockExperimentService}, compositeExperimentService.experimentServices)
+}
+
+func (s *PipelineTestSuite) TestWrapStages() {
+	var wrapped []string
+	compositeExperimentService, err := NewExperimentPipeline().
+		Wrap(func(name string, experimentService ExperimentService) ExperimentService {
+			wrapped = append(wrapped, name)
+			return s.mockExperimentService
+		}).
+		Wrap(nil).
+		Build()
+	s.NoError(err)
+	s.Equal([]string{WhitelistStage, BucketingStage}, wrapped)
+	s.Equal([]ExperimentService{s.mockExperimentService, s.mockExperimentService}, compositeExperimentService.experimentServices)
+
+	wrapped = nil
+	compositeFeatureService, err := NewFeaturePipeline(compositeExperimentService).
+		Wrap(func(name string, featureService FeatureService) FeatureService {
+			wrapped = append(wrapped, name)
+			return s.mockFeatureService
+		}).
+		Build()
+	s.NoError(err)
+	s.Equal([]string{FeatureExperimentStage, RolloutStage}, wrapped)
+	s.Equal(s.mockFeatureService, compositeFeatureService.rolloutService)
+}
+
+func (s *PipelineTestSuite) TestExperimentPipelineErrors() {
+	_, err := NewExperimentPipeline().InsertBefore("missing", "custom", s.mockExperimentService).Build()
+	s.EqualError(err, `decision stage "missing" not found`)
+
+	_, err = NewExperimentPipeline().Append(WhitelistStage, s.mockExperimentService).Build()
+	s.EqualError(err, `decision stage "whitelist" already exists`)
+
+	// the first error is kept
+	_, err = NewExperimentPipeline().Remove("missing").Replace("other", s.mockExperimentService).Build()
+	s.EqualError(err, `decision stage "missing" not found`)
+}
+
+func (s *PipelineTestSuite) TestExperimentBucketerOptions() {
+	mockBucketer := new(MockBucketer)
+	mockAudienceTreeEvaluator := new(MockAudienceTreeEvaluator)
+	compositeExperimentService, err := NewExperimentPipeline(
+		WithExperimentBucketerOptions(WithExperimentBucketer(mockBucketer), WithAudienceTreeEvaluator(mockAudienceTreeEvaluator)),
+	).Build()
+	s.NoError(err)
+
+	experimentBucketerService := compositeExperimentService.experimentServices[1].(*ExperimentBucketerService)
+	s.Equal(mockBucketer, experimentBucketerService.bucketer)
+	s.Equal(mockAudienceTreeEvaluator, experimentBucketerService.audienceTreeEvaluator)
+
+	mockBucketer.On("Bucket", s.testUserContext.ID, testExp1111, entities.Group{}).Return(&testExp1111Var2222, reasons.BucketedIntoVariation, nil)
+	decision, err := compositeExperimentService.GetDecision(ExperimentDecisionContext{
+		Experiment:    &testExp1111,
+		ProjectConfig: s.mockConfig,
+	}, s.testUserContext)
+	s.NoError(err)
+	s.Equal(&testExp1111Var2222, decision.Variation)
+	mockBucketer.AssertExpectations(s.T())
+}
+
+func (s *PipelineTestSuite) TestFeatureStages() {
+	compositeExperimentService := NewCompositeExperimentService()
+	pipeline := NewFeaturePipeline(compositeExperimentService)
+	s.Equal([]string{FeatureExperimentStage, RolloutStage}, pipeline.Stages())
+
+	compositeFeatureService, err := pipeline.
+		InsertAfter(FeatureExperimentStage, "holdout", s.mockFeatureService).
+		Build()
+	s.NoError(err)
+	s.Equal([]string{FeatureExperimentStage, "holdout", RolloutStage}, pipeline.Stages())
+	s.IsType(&FeatureExperimentService{}, compositeFeatureService.featureServices[0])
+	s.Equal(s.mockFeatureService, compositeFeatureService.featureServices[1])
+	s.IsType(&RolloutService{}, compositeFeatureService.featureServices[2])
+
+	_, err = NewFeaturePipeline(compositeExperimentService).Replace("missing", s.mockFeatureService).Build()
+	s.EqualError(err, `decision stage "missing" not found`)
+}
+
+func (s *PipelineTestSuite) TestFeaturePipelineRolloutService() {
+	rolloutService := NewRolloutService()
+	compositeFeatureService, err := NewFeaturePipeline(NewCompositeExperimentService(), WithRolloutService(rolloutService)).Build()
+	s.NoError(err)
+	s.Equal(rolloutService, compositeFeatureService.featureServices[1])
+}
+
+func TestRolloutBucketerOptions(t *testing.T) {
+	mockBucketer := new(MockBucketer)
+	mockAudienceTreeEvaluator := new(MockAudienceTreeEvaluator)
+	rolloutService := NewRolloutService(WithRolloutBucketerOptions(WithExperimentBucketer(mockBucketer), WithAudienceTreeEvaluator(mockAudienceTreeEvaluator)))
+	assert.Equal(t, mockAudienceTreeEvaluator, rolloutService.audienceTreeEvaluator)
+	assert.Equal(t, mockBucketer, rolloutService.experimentBucketerService.(*ExperimentBucketerService).bucketer)
+}
+
+func TestPipelineTestSuite(t *testing.T) {
+	suite.Run(t, new(PipelineTestSuite))
+}
@@ -53,6 +53,12 @@ const (
 	NoOverrideVariationAssignment Reason = "No override variation assignment"
 	// InvalidOverrideVariationAssignment - An override variation was found for the given user and experiment, but no variation with that key exists in the given experiment
 	InvalidOverrideVariationAssignment Reason = "Invalid override variation assignment"
+	// SavedRolloutAssignmentReused - the variation saved in the user profile for the feature rollout was reused
+	SavedRolloutAssignmentReused Reason = "Saved rollout assignment reused"
+	// SavedRolloutAssignmentInvalidated - the variation saved in the user profile for the feature rollout no longer exists, so the user was bucketed again
+	SavedRolloutAssignmentInvalidated Reason = "Saved rollout assignment invalidated"
+	// InHoldout - the user is in a holdout and does not see any experiment or feature
+	InHoldout Reason = "In holdout"
 	// OverrideVariationAssignmentFound - A valid override variation was found for the given user and experiment
 	OverrideVariationAssignmentFound Reason = "Override variation assignment found"
 )
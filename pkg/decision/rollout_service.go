@@ -18,29 +18,72 @@
 package decision
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/optimizely/go-sdk/pkg/decision/evaluator"
 	"github.com/optimizely/go-sdk/pkg/decision/reasons"
 	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/metrics"
 
 	"github.com/optimizely/go-sdk/pkg/entities"
 )
 
 var rsLogger = logging.GetLogger("RolloutService")
 
+// RSOptionFunc is used to pass custom config options into the RolloutService.
+type RSOptionFunc func(*RolloutService)
+
+// WithRolloutUserProfileService makes rollout decisions sticky: the variation a user is bucketed into is saved in the
+// user profile and reused as long as the user meets the rollout targeting rule, even if the rollout traffic changes.
+func WithRolloutUserProfileService(userProfileService UserProfileServiceV2) RSOptionFunc {
+	return func(r *RolloutService) {
+		r.userProfileService = userProfileService
+	}
+}
+
+// WithRolloutMetricsRegistry sets the registry counting the failures of the user profile service
+func WithRolloutMetricsRegistry(metricsRegistry metrics.Registry) RSOptionFunc {
+	return func(r *RolloutService) {
+		r.metricsRegistry = metricsRegistry
+	}
+}
+
+// WithRolloutBucketerOptions customizes the bucketer and the audience evaluator of the rollout rules
+func WithRolloutBucketerOptions(options ...EBSOptionFunc) RSOptionFunc {
+	return func(r *RolloutService) {
+		experimentBucketerService := NewExperimentBucketerService(options...)
+		r.experimentBucketerService = experimentBucketerService
+		r.audienceTreeEvaluator = experimentBucketerService.audienceTreeEvaluator
+	}
+}
+
 // RolloutService makes a feature decision for a given feature rollout
 type RolloutService struct {
 	audienceTreeEvaluator     evaluator.TreeEvaluator
 	experimentBucketerService ExperimentService
+	userProfileService        UserProfileServiceV2
+	metricsRegistry           metrics.Registry
+	lookupErrorCounter        metrics.Counter
+	saveErrorCounter          metrics.Counter
 }
 
 // NewRolloutService returns a new instance of the Rollout service
-func NewRolloutService() *RolloutService {
-	return &RolloutService{
+func NewRolloutService(options ...RSOptionFunc) *RolloutService {
+	rolloutService := &RolloutService{
 		audienceTreeEvaluator:     evaluator.NewMixedTreeEvaluator(),
 		experimentBucketerService: NewExperimentBucketerService(),
 	}
+	for _, opt := range options {
+		opt(rolloutService)
+	}
+	if rolloutService.metricsRegistry == nil {
+		rolloutService.metricsRegistry = metrics.NewNoopRegistry()
+	}
+
+	rolloutService.lookupErrorCounter = rolloutService.metricsRegistry.GetCounter(metrics.UserProfileLookupError)
+	rolloutService.saveErrorCounter = rolloutService.metricsRegistry.GetCounter(metrics.UserProfileSaveError)
+	return rolloutService
 }
 
 // GetDecision returns a decision for the given feature and user context
@@ -66,6 +109,7 @@ func (r RolloutService) GetDecision(decisionContext FeatureDecisionContext, user
 	experimentDecisionContext := ExperimentDecisionContext{
 		Experiment:    &experiment,
 		ProjectConfig: decisionContext.ProjectConfig,
+		UserProfile:   decisionContext.UserProfile,
 	}
 
 	// if user fails rollout targeting rule we return out of it
@@ -79,6 +123,29 @@ func (r RolloutService) GetDecision(decisionContext FeatureDecisionContext, user
 		}
 	}
 
+	var tracker *UserProfileTracker
+	var savedAssignmentInvalidated bool
+	if r.userProfileService != nil {
+		tracker = decisionContext.UserProfile
+		if tracker == nil {
+			tracker = NewUserProfileTracker(userContext.ID, r.userProfileService, r.lookupErrorCounter)
+			defer r.save(tracker)
+		}
+
+		savedVariation, invalidated, err := r.getSavedVariation(tracker, experiment, userContext)
+		savedAssignmentInvalidated = invalidated
+		if err != nil {
+			// the decision is not saved, as that would overwrite the profile which could not be read
+			tracker = nil
+		} else if savedVariation != nil {
+			featureDecision.Decision = Decision{Reason: reasons.SavedRolloutAssignmentReused}
+			featureDecision.Experiment = experiment
+			featureDecision.Variation = savedVariation
+			rsLogger.Debug(fmt.Sprintf(`Decision made for user "%s" for feature rollout with key "%s": %s.`, userContext.ID, feature.Key, featureDecision.Reason))
+			return featureDecision, nil
+		}
+	}
+
 	decision, _ := r.experimentBucketerService.GetDecision(experimentDecisionContext, userContext)
 	// translate the experiment reason into a more rollouts-appropriate reason
 	switch decision.Reason {
@@ -90,9 +157,47 @@ func (r RolloutService) GetDecision(decisionContext FeatureDecisionContext, user
 		featureDecision.Decision = decision.Decision
 	}
 
+	if savedAssignmentInvalidated {
+		featureDecision.Decision = Decision{Reason: reasons.SavedRolloutAssignmentInvalidated}
+	}
+	if tracker != nil && decision.Variation != nil {
+		tracker.SaveDecision(experiment.ID, decision.Variation.ID)
+	}
+
 	featureDecision.Experiment = experiment
 	featureDecision.Variation = decision.Variation
 	rsLogger.Debug(fmt.Sprintf(`Decision made for user "%s" for feature rollout with key "%s": %s.`, userContext.ID, feature.Key, featureDecision.Reason))
 
 	return featureDecision, nil
 }
+
+// getSavedVariation returns the variation of the rollout rule saved in the user profile, and whether a saved
+// assignment was discarded because its variation is no longer part of the rule
+func (r RolloutService) getSavedVariation(tracker *UserProfileTracker, experiment entities.Experiment, userContext entities.UserContext) (*entities.Variation, bool, error) {
+	// the lookup error is logged and counted by the tracker
+	userProfile, err := tracker.Profile(context.Background())
+	if err != nil {
+		return nil, false, err
+	}
+
+	savedVariationID, ok := userProfile.ExperimentBucketMap[NewUserDecisionKey(experiment.ID)]
+	if !ok {
+		return nil, false, nil
+	}
+	if variation, ok := experiment.Variations[savedVariationID]; ok {
+		rsLogger.Debug(fmt.Sprintf(`User "%s" was previously bucketed into variation "%s" of rollout rule "%s".`, userContext.ID, variation.Key, experiment.Key))
+		return &variation, false, nil
+	}
+	rsLogger.Warning(fmt.Sprintf(`User "%s" was previously bucketed into variation with ID "%s" for rollout rule "%s", but no matching variation was found.`, userContext.ID, savedVariationID, experiment.Key))
+	return nil, true, nil
+}
+
+func (r RolloutService) save(tracker *UserProfileTracker) {
+	if !tracker.hasChanges() {
+		return
+	}
+	if err := tracker.Save(context.Background()); err != nil {
+		rsLogger.Error(fmt.Sprintf(`Unable to save rollout decision for user "%s".`, tracker.userID), err)
+		r.saveErrorCounter.Add(1)
+	}
+}
@@ -17,13 +17,17 @@
 package decision
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/optimizely/go-sdk/pkg/decision/evaluator"
 
 	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/metrics"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/optimizely/go-sdk/pkg/entities"
@@ -133,10 +137,139 @@ func (s *RolloutServiceTestSuite) TestGetDecisionFailsTargeting() {
 	s.mockExperimentService.AssertExpectations(s.T())
 }
 
+func (s *RolloutServiceTestSuite) newStickyRolloutService(userProfileService UserProfileServiceV2, metricsRegistry metrics.Registry) *RolloutService {
+	rolloutService := NewRolloutService(WithRolloutUserProfileService(userProfileService), WithRolloutMetricsRegistry(metricsRegistry))
+	rolloutService.audienceTreeEvaluator = s.mockAudienceTreeEvaluator
+	rolloutService.experimentBucketerService = s.mockExperimentService
+	return rolloutService
+}
+
+func (s *RolloutServiceTestSuite) TestGetDecisionSavesRolloutAssignment() {
+	s.mockAudienceTreeEvaluator.On("Evaluate", testExp1112.AudienceConditionTree, s.testConditionTreeParams).Return(true, true)
+	s.mockExperimentService.On("GetDecision", s.testExperimentDecisionContext, s.testUserContext).Return(ExperimentDecision{
+		Variation: &testExp1112Var2222,
+		Decision:  Decision{Reason: reasons.BucketedIntoVariation},
+	}, nil)
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", s.testUserContext.ID).Return(UserProfile{ID: s.testUserContext.ID}, nil)
+	mockUserProfileService.On("Save", UserProfile{
+		ID:                  s.testUserContext.ID,
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey(testExp1112.ID): testExp1112Var2222.ID},
+	}).Return(nil)
+
+	testRolloutService := s.newStickyRolloutService(mockUserProfileService, nil)
+	decision, _ := testRolloutService.GetDecision(s.testFeatureDecisionContext, s.testUserContext)
+	s.Equal(reasons.BucketedIntoRollout, decision.Reason)
+	s.Equal(&testExp1112Var2222, decision.Variation)
+	mockUserProfileService.AssertExpectations(s.T())
+}
+
+func (s *RolloutServiceTestSuite) TestGetDecisionReusesSavedRolloutAssignment() {
+	s.mockAudienceTreeEvaluator.On("Evaluate", testExp1112.AudienceConditionTree, s.testConditionTreeParams).Return(true, true)
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", s.testUserContext.ID).Return(UserProfile{
+		ID:                  s.testUserContext.ID,
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey(testExp1112.ID): testExp1112Var2222.ID},
+	}, nil)
+
+	testRolloutService := s.newStickyRolloutService(mockUserProfileService, nil)
+	expectedFeatureDecision := FeatureDecision{
+		Experiment: testExp1112,
+		Variation:  &testExp1112Var2222,
+		Source:     Rollout,
+		Decision:   Decision{Reason: reasons.SavedRolloutAssignmentReused},
+	}
+	decision, _ := testRolloutService.GetDecision(s.testFeatureDecisionContext, s.testUserContext)
+	s.Equal(expectedFeatureDecision, decision)
+	s.mockExperimentService.AssertNotCalled(s.T(), "GetDecision", mock.Anything, mock.Anything)
+	mockUserProfileService.AssertNotCalled(s.T(), "Save", mock.Anything)
+}
+
+func (s *RolloutServiceTestSuite) TestGetDecisionInvalidatesSavedRolloutAssignment() {
+	s.mockAudienceTreeEvaluator.On("Evaluate", testExp1112.AudienceConditionTree, s.testConditionTreeParams).Return(true, true)
+	s.mockExperimentService.On("GetDecision", s.testExperimentDecisionContext, s.testUserContext).Return(ExperimentDecision{
+		Variation: &testExp1112Var2222,
+		Decision:  Decision{Reason: reasons.BucketedIntoVariation},
+	}, nil)
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", s.testUserContext.ID).Return(UserProfile{
+		ID:                  s.testUserContext.ID,
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey(testExp1112.ID): "removed_variation"},
+	}, nil)
+	mockUserProfileService.On("Save", UserProfile{
+		ID:                  s.testUserContext.ID,
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey(testExp1112.ID): testExp1112Var2222.ID},
+	}).Return(nil)
+
+	testRolloutService := s.newStickyRolloutService(mockUserProfileService, nil)
+	decision, _ := testRolloutService.GetDecision(s.testFeatureDecisionContext, s.testUserContext)
+	s.Equal(reasons.SavedRolloutAssignmentInvalidated, decision.Reason)
+	s.Equal(&testExp1112Var2222, decision.Variation)
+	mockUserProfileService.AssertExpectations(s.T())
+}
+
+func (s *RolloutServiceTestSuite) TestGetDecisionSavedRolloutAssignmentFailsTargeting() {
+	s.mockAudienceTreeEvaluator.On("Evaluate", testExp1112.AudienceConditionTree, s.testConditionTreeParams).Return(false, true)
+	mockUserProfileService := new(MockUserProfileServiceV2)
+
+	testRolloutService := s.newStickyRolloutService(mockUserProfileService, nil)
+	decision, _ := testRolloutService.GetDecision(s.testFeatureDecisionContext, s.testUserContext)
+	s.Equal(reasons.FailedRolloutTargeting, decision.Reason)
+	s.Nil(decision.Variation)
+	mockUserProfileService.AssertNotCalled(s.T(), "Lookup", mock.Anything)
+}
+
+func (s *RolloutServiceTestSuite) TestGetDecisionRolloutLookupError() {
+	s.mockAudienceTreeEvaluator.On("Evaluate", testExp1112.AudienceConditionTree, s.testConditionTreeParams).Return(true, true)
+	s.mockExperimentService.On("GetDecision", s.testExperimentDecisionContext, s.testUserContext).Return(ExperimentDecision{
+		Variation: &testExp1112Var2222,
+		Decision:  Decision{Reason: reasons.BucketedIntoVariation},
+	}, nil)
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", s.testUserContext.ID).Return(UserProfile{}, errors.New("store down"))
+	metricsRegistry := newTestRegistry()
+
+	testRolloutService := s.newStickyRolloutService(mockUserProfileService, metricsRegistry)
+	decision, _ := testRolloutService.GetDecision(s.testFeatureDecisionContext, s.testUserContext)
+	s.Equal(reasons.BucketedIntoRollout, decision.Reason)
+	s.Equal(&testExp1112Var2222, decision.Variation)
+	mockUserProfileService.AssertNotCalled(s.T(), "Save", mock.Anything)
+	s.Equal(float64(1), metricsRegistry.counters[metrics.UserProfileLookupError].value)
+}
+
+func (s *RolloutServiceTestSuite) TestGetDecisionSharedTrackerIsNotSaved() {
+	s.mockAudienceTreeEvaluator.On("Evaluate", testExp1112.AudienceConditionTree, s.testConditionTreeParams).Return(true, true)
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", s.testUserContext.ID).Return(UserProfile{ID: s.testUserContext.ID}, nil)
+	tracker := NewUserProfileTracker(s.testUserContext.ID, mockUserProfileService, nil)
+	featureDecisionContext := s.testFeatureDecisionContext
+	featureDecisionContext.UserProfile = tracker
+	experimentDecisionContext := s.testExperimentDecisionContext
+	experimentDecisionContext.UserProfile = tracker
+	s.mockExperimentService.On("GetDecision", experimentDecisionContext, s.testUserContext).Return(ExperimentDecision{
+		Variation: &testExp1112Var2222,
+		Decision:  Decision{Reason: reasons.BucketedIntoVariation},
+	}, nil)
+
+	testRolloutService := s.newStickyRolloutService(mockUserProfileService, nil)
+	decision, _ := testRolloutService.GetDecision(featureDecisionContext, s.testUserContext)
+	s.Equal(&testExp1112Var2222, decision.Variation)
+	mockUserProfileService.AssertNotCalled(s.T(), "Save", mock.Anything)
+
+	profile, err := tracker.Profile(context.Background())
+	s.NoError(err)
+	s.Equal(testExp1112Var2222.ID, profile.ExperimentBucketMap[NewUserDecisionKey(testExp1112.ID)])
+}
+
 func TestNewRolloutService(t *testing.T) {
 	rolloutService := NewRolloutService()
 	assert.IsType(t, &evaluator.MixedTreeEvaluator{}, rolloutService.audienceTreeEvaluator)
 	assert.IsType(t, &ExperimentBucketerService{}, rolloutService.experimentBucketerService)
+	assert.Nil(t, rolloutService.userProfileService)
+
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	rolloutService = NewRolloutService(WithRolloutUserProfileService(mockUserProfileService))
+	assert.Equal(t, mockUserProfileService, rolloutService.userProfileService)
 }
 
 func TestRolloutServiceTestSuite(t *testing.T) {
@@ -0,0 +1,45 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package decision //
+package decision
+
+import "context"
+
+// userProfileServiceAdapter exposes a UserProfileService as a UserProfileServiceV2 which never fails
+type userProfileServiceAdapter struct {
+	userProfileService UserProfileService
+}
+
+// NewUserProfileServiceAdapter returns a UserProfileServiceV2 delegating to the given UserProfileService.
+// It returns nil if userProfileService is nil.
+func NewUserProfileServiceAdapter(userProfileService UserProfileService) UserProfileServiceV2 {
+	if userProfileService == nil {
+		return nil
+	}
+	return userProfileServiceAdapter{userProfileService: userProfileService}
+}
+
+// Lookup returns the profile of the user, the error is always nil
+func (a userProfileServiceAdapter) Lookup(ctx context.Context, userID string) (UserProfile, error) {
+	return a.userProfileService.Lookup(userID), nil
+}
+
+// Save saves the profile, the error is always nil
+func (a userProfileServiceAdapter) Save(ctx context.Context, profile UserProfile) error {
+	a.userProfileService.Save(profile)
+	return nil
+}
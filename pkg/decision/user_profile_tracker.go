@@ -0,0 +1,124 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package decision //
+package decision
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/metrics"
+)
+
+var uptLogger = logging.GetLogger("UserProfileTracker")
+
+// UserProfileTracker loads the profile of a user at most once and collects the decisions to save, so that all the
+// decisions of a single client call cost one lookup and one save. It is safe for concurrent use.
+type UserProfileTracker struct {
+	userID             string
+	userProfileService UserProfileServiceV2
+	lookupErrorCounter metrics.Counter
+
+	lock      sync.Mutex
+	loaded    bool
+	lookupErr error
+	profile   UserProfile
+	changed   bool
+}
+
+// NewUserProfileTracker returns a tracker for the profile of the user stored in the given service. A failed lookup
+// is added to the counter, which may be nil.
+func NewUserProfileTracker(userID string, userProfileService UserProfileServiceV2, lookupErrorCounter metrics.Counter) *UserProfileTracker {
+	return &UserProfileTracker{
+		userID:             userID,
+		userProfileService: userProfileService,
+		lookupErrorCounter: lookupErrorCounter,
+	}
+}
+
+// Profile returns a copy of the profile, looking it up on first use. The lookup error is returned on every call, but
+// logged and counted once.
+func (t *UserProfileTracker) Profile(ctx context.Context) (UserProfile, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.loaded {
+		t.profile, t.lookupErr = t.userProfileService.Lookup(ctx, t.userID)
+		t.profile.ID = t.userID
+		t.loaded = true
+		if t.lookupErr != nil {
+			uptLogger.Error(fmt.Sprintf(`Unable to look up the user profile of user "%s".`, t.userID), t.lookupErr)
+			if t.lookupErrorCounter != nil {
+				t.lookupErrorCounter.Add(1)
+			}
+		}
+	}
+	return copyUserProfile(t.profile), t.lookupErr
+}
+
+// SaveDecision records the variation of the experiment in the profile. It is saved on the next call of Save.
+func (t *UserProfileTracker) SaveDecision(experimentID, variationID string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	decisionKey := NewUserDecisionKey(experimentID)
+	if t.profile.ExperimentBucketMap == nil {
+		t.profile.ExperimentBucketMap = map[UserDecisionKey]string{}
+	}
+	if t.profile.ExperimentBucketMap[decisionKey] == variationID {
+		return
+	}
+	t.profile.ExperimentBucketMap[decisionKey] = variationID
+	t.changed = true
+}
+
+// Save saves the profile if decisions were recorded since the last save. Nothing is saved if the lookup failed,
+// as that would overwrite the decisions of the profile which could not be read.
+func (t *UserProfileTracker) Save(ctx context.Context) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.changed || t.lookupErr != nil {
+		return nil
+	}
+	t.profile.ID = t.userID
+	if err := t.userProfileService.Save(ctx, copyUserProfile(t.profile)); err != nil {
+		return err
+	}
+	t.changed = false
+	return nil
+}
+
+func (t *UserProfileTracker) hasChanges() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.changed && t.lookupErr == nil
+}
+
+func copyUserProfile(profile UserProfile) UserProfile {
+	if profile.ExperimentBucketMap == nil {
+		return profile
+	}
+	bucketMap := make(map[UserDecisionKey]string, len(profile.ExperimentBucketMap))
+	for key, value := range profile.ExperimentBucketMap {
+		bucketMap[key] = value
+	}
+	profile.ExperimentBucketMap = bucketMap
+	return profile
+}
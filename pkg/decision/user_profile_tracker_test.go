@@ -0,0 +1,128 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package decision
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserProfileTrackerLooksUpOnce(t *testing.T) {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	savedProfile := UserProfile{
+		ID:                  "test_user",
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey("exp_1"): "var_1"},
+	}
+	mockUserProfileService.On("Lookup", "test_user").Return(savedProfile, nil).Once()
+
+	tracker := NewUserProfileTracker("test_user", mockUserProfileService, nil)
+	profile, err := tracker.Profile(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, savedProfile, profile)
+
+	// the returned profile is a copy
+	profile.ExperimentBucketMap[NewUserDecisionKey("exp_1")] = "var_2"
+	profile, err = tracker.Profile(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "var_1", profile.ExperimentBucketMap[NewUserDecisionKey("exp_1")])
+	mockUserProfileService.AssertExpectations(t)
+}
+
+func TestUserProfileTrackerSavesDecisionsOnce(t *testing.T) {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", "test_user").Return(UserProfile{
+		ID:                  "test_user",
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey("exp_1"): "var_1"},
+	}, nil)
+	expectedProfile := UserProfile{
+		ID: "test_user",
+		ExperimentBucketMap: map[UserDecisionKey]string{
+			NewUserDecisionKey("exp_1"): "var_1",
+			NewUserDecisionKey("exp_2"): "var_2",
+			NewUserDecisionKey("exp_3"): "var_3",
+		},
+	}
+	mockUserProfileService.On("Save", expectedProfile).Return(nil).Once()
+
+	tracker := NewUserProfileTracker("test_user", mockUserProfileService, nil)
+	_, err := tracker.Profile(context.Background())
+	assert.NoError(t, err)
+	tracker.SaveDecision("exp_1", "var_1")
+	tracker.SaveDecision("exp_2", "var_2")
+	tracker.SaveDecision("exp_3", "var_3")
+	assert.NoError(t, tracker.Save(context.Background()))
+
+	// nothing changed since the last save
+	assert.NoError(t, tracker.Save(context.Background()))
+	mockUserProfileService.AssertExpectations(t)
+}
+
+func TestUserProfileTrackerSkipsUnchangedProfile(t *testing.T) {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", "test_user").Return(UserProfile{
+		ID:                  "test_user",
+		ExperimentBucketMap: map[UserDecisionKey]string{NewUserDecisionKey("exp_1"): "var_1"},
+	}, nil)
+
+	tracker := NewUserProfileTracker("test_user", mockUserProfileService, nil)
+	_, err := tracker.Profile(context.Background())
+	assert.NoError(t, err)
+	tracker.SaveDecision("exp_1", "var_1")
+	assert.NoError(t, tracker.Save(context.Background()))
+	mockUserProfileService.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestUserProfileTrackerLookupError(t *testing.T) {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	lookupErr := errors.New("store down")
+	mockUserProfileService.On("Lookup", "test_user").Return(UserProfile{}, lookupErr).Once()
+
+	lookupErrors := &testCounter{}
+	tracker := NewUserProfileTracker("test_user", mockUserProfileService, lookupErrors)
+	_, err := tracker.Profile(context.Background())
+	assert.Equal(t, lookupErr, err)
+	_, err = tracker.Profile(context.Background())
+	assert.Equal(t, lookupErr, err)
+	// the failed lookup is counted once, however many decisions read the profile
+	assert.Equal(t, 1.0, lookupErrors.value)
+
+	tracker.SaveDecision("exp_1", "var_1")
+	assert.NoError(t, tracker.Save(context.Background()))
+	mockUserProfileService.AssertNotCalled(t, "Save", mock.Anything)
+	mockUserProfileService.AssertExpectations(t)
+}
+
+func TestUserProfileTrackerSaveError(t *testing.T) {
+	mockUserProfileService := new(MockUserProfileServiceV2)
+	mockUserProfileService.On("Lookup", "test_user").Return(UserProfile{}, nil)
+	saveErr := errors.New("store down")
+	mockUserProfileService.On("Save", mock.Anything).Return(saveErr).Twice()
+
+	tracker := NewUserProfileTracker("test_user", mockUserProfileService, nil)
+	_, err := tracker.Profile(context.Background())
+	assert.NoError(t, err)
+	tracker.SaveDecision("exp_1", "var_1")
+	assert.Equal(t, saveErr, tracker.Save(context.Background()))
+
+	// the decisions are kept for the next save
+	assert.Equal(t, saveErr, tracker.Save(context.Background()))
+	mockUserProfileService.AssertExpectations(t)
+}
@@ -0,0 +1,153 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package userprofile //
+package userprofile
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/optimizely/go-sdk/pkg/utils"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheSize holds the default number of profiles kept by a CachingService
+const DefaultCacheSize = 10000
+
+// DefaultCacheTTL holds the default time a profile is kept by a CachingService
+const DefaultCacheTTL = time.Minute
+
+// CachingOptionFunc is used to configure the CachingService
+type CachingOptionFunc func(s *CachingService)
+
+// WithCacheSize sets the number of profiles kept in memory
+func WithCacheSize(size int) CachingOptionFunc {
+	return func(s *CachingService) {
+		s.size = size
+	}
+}
+
+// WithCacheTTL sets the time a profile is kept in memory. A ttl of zero keeps profiles until they are evicted.
+func WithCacheTTL(ttl time.Duration) CachingOptionFunc {
+	return func(s *CachingService) {
+		s.ttl = ttl
+	}
+}
+
+// WithCacheMetricsRegistry sets the registry counting the cache hits and misses
+func WithCacheMetricsRegistry(metricsRegistry metrics.Registry) CachingOptionFunc {
+	return func(s *CachingService) {
+		s.metricsRegistry = metricsRegistry
+	}
+}
+
+// CachingService is a UserProfileServiceV2 decorator keeping the most recently used profiles in memory. Concurrent
+// lookups of the same user are sent to the wrapped service once, and a profile is invalidated when it is saved.
+// A decision.UserProfileService can be wrapped with decision.NewUserProfileServiceAdapter.
+type CachingService struct {
+	userProfileService decision.UserProfileServiceV2
+	size               int
+	ttl                time.Duration
+	metricsRegistry    metrics.Registry
+
+	cache       *utils.LRUCache
+	lookups     singleflight.Group
+	lock        sync.Mutex
+	generation  uint64 // incremented by every save, so that lookups racing a save do not cache a stale profile
+	hitCounter  metrics.Counter
+	missCounter metrics.Counter
+}
+
+// NewCachingService returns a CachingService in front of the given service
+func NewCachingService(userProfileService decision.UserProfileServiceV2, options ...CachingOptionFunc) *CachingService {
+	s := &CachingService{
+		userProfileService: userProfileService,
+		size:               DefaultCacheSize,
+		ttl:                DefaultCacheTTL,
+		metricsRegistry:    metrics.NewNoopRegistry(),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	s.cache = utils.NewLRUCache(s.size, s.ttl)
+	s.hitCounter = s.metricsRegistry.GetCounter(metrics.UserProfileCacheHit)
+	s.missCounter = s.metricsRegistry.GetCounter(metrics.UserProfileCacheMiss)
+	return s
+}
+
+// Lookup returns the cached profile of the user, or looks it up in the wrapped service. Concurrent lookups of a
+// missing profile share the result of the first one, which is looked up with its context.
+func (s *CachingService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	if profile, ok := s.cache.Get(userID); ok {
+		s.hitCounter.Add(1)
+		return copyProfile(profile.(decision.UserProfile)), nil
+	}
+	s.missCounter.Add(1)
+
+	profile, err, _ := s.lookups.Do(userID, func() (interface{}, error) {
+		generation := s.currentGeneration()
+		profile, err := s.userProfileService.Lookup(ctx, userID)
+		if err == nil {
+			s.store(userID, profile, generation)
+		}
+		return profile, err
+	})
+	return copyProfile(profile.(decision.UserProfile)), err
+}
+
+// Save saves the profile in the wrapped service and invalidates its cached copy
+func (s *CachingService) Save(ctx context.Context, profile decision.UserProfile) error {
+	err := s.userProfileService.Save(ctx, profile)
+
+	s.lock.Lock()
+	s.generation++
+	s.cache.Remove(profile.ID)
+	s.lock.Unlock()
+	s.lookups.Forget(profile.ID)
+	return err
+}
+
+func (s *CachingService) currentGeneration() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.generation
+}
+
+func (s *CachingService) store(userID string, profile decision.UserProfile, generation uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.generation == generation {
+		s.cache.Set(userID, copyProfile(profile))
+	}
+}
+
+func copyProfile(profile decision.UserProfile) decision.UserProfile {
+	if profile.ExperimentBucketMap == nil {
+		return profile
+	}
+	bucketMap := make(map[decision.UserDecisionKey]string, len(profile.ExperimentBucketMap))
+	for key, value := range profile.ExperimentBucketMap {
+		bucketMap[key] = value
+	}
+	profile.ExperimentBucketMap = bucketMap
+	return profile
+}
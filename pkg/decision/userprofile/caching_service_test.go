@@ -0,0 +1,208 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package userprofile
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/metrics"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingService struct {
+	*memoryService
+	lookupLock sync.Mutex
+	lookups    int
+	lookupErr  error
+	release    chan struct{} // blocks lookups until closed, if set
+}
+
+func (c *countingService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	c.lookupLock.Lock()
+	c.lookups++
+	release, lookupErr := c.release, c.lookupErr
+	c.lookupLock.Unlock()
+
+	if release != nil {
+		<-release
+	}
+	if lookupErr != nil {
+		return decision.UserProfile{}, lookupErr
+	}
+	return c.memoryService.Lookup(ctx, userID)
+}
+
+func (c *countingService) lookupCount() int {
+	c.lookupLock.Lock()
+	defer c.lookupLock.Unlock()
+	return c.lookups
+}
+
+type testCounter struct {
+	lock  sync.Mutex
+	value float64
+}
+
+func (c *testCounter) Add(value float64) {
+	c.lock.Lock()
+	c.value += value
+	c.lock.Unlock()
+}
+
+func (c *testCounter) get() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.value
+}
+
+type testRegistry struct {
+	metrics.Registry
+	counters map[string]*testCounter
+}
+
+func (r *testRegistry) GetCounter(key string) metrics.Counter {
+	counter := &testCounter{}
+	r.counters[key] = counter
+	return counter
+}
+
+func newCountingService() *countingService {
+	store := &countingService{memoryService: newMemoryService()}
+	store.profiles["user_1"] = testProfile("user_1")
+	return store
+}
+
+func TestCachingServiceHitsAndMisses(t *testing.T) {
+	store := newCountingService()
+	registry := &testRegistry{counters: map[string]*testCounter{}}
+	service := NewCachingService(store, WithCacheMetricsRegistry(registry))
+
+	assertLookup(t, service, testProfile("user_1"))
+	assertLookup(t, service, testProfile("user_1"))
+	assert.Equal(t, 1, store.lookupCount())
+	assert.Equal(t, float64(1), registry.counters[metrics.UserProfileCacheHit].get())
+	assert.Equal(t, float64(1), registry.counters[metrics.UserProfileCacheMiss].get())
+
+	// cached profiles can't be modified by callers
+	profile, _ := service.Lookup(context.Background(), "user_1")
+	profile.ExperimentBucketMap[decision.NewUserDecisionKey("exp_1")] = "var_2"
+	assertLookup(t, service, testProfile("user_1"))
+}
+
+func TestCachingServiceSizeAndTTL(t *testing.T) {
+	store := newCountingService()
+	store.profiles["user_2"] = testProfile("user_2")
+	service := NewCachingService(store, WithCacheSize(1), WithCacheTTL(50*time.Millisecond))
+
+	assertLookup(t, service, testProfile("user_1"))
+	assertLookup(t, service, testProfile("user_2"))
+	assertLookup(t, service, testProfile("user_1"))
+	assert.Equal(t, 3, store.lookupCount())
+
+	assertLookup(t, service, testProfile("user_1"))
+	assert.Equal(t, 3, store.lookupCount())
+	time.Sleep(60 * time.Millisecond)
+	assertLookup(t, service, testProfile("user_1"))
+	assert.Equal(t, 4, store.lookupCount())
+}
+
+func TestCachingServiceSharesConcurrentLookups(t *testing.T) {
+	store := newCountingService()
+	store.release = make(chan struct{})
+	service := NewCachingService(store)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assertLookup(t, service, testProfile("user_1"))
+		}()
+	}
+	waitFor(t, func() bool { return store.lookupCount() == 1 })
+	time.Sleep(10 * time.Millisecond)
+	close(store.release)
+	wg.Wait()
+	assert.Equal(t, 1, store.lookupCount())
+}
+
+func TestCachingServiceInvalidatesOnSave(t *testing.T) {
+	store := newCountingService()
+	service := NewCachingService(store)
+	assertLookup(t, service, testProfile("user_1"))
+
+	updated := decision.UserProfile{
+		ID:                  "user_1",
+		ExperimentBucketMap: map[decision.UserDecisionKey]string{decision.NewUserDecisionKey("exp_1"): "var_3"},
+	}
+	assert.NoError(t, service.Save(context.Background(), updated))
+	assertLookup(t, service, updated)
+	assert.Equal(t, 2, store.lookupCount())
+}
+
+func TestCachingServiceDoesNotCacheRacingLookup(t *testing.T) {
+	store := newCountingService()
+	store.release = make(chan struct{})
+	service := NewCachingService(store)
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = service.Lookup(context.Background(), "user_1")
+		close(done)
+	}()
+	waitFor(t, func() bool { return store.lookupCount() == 1 })
+
+	// the profile is saved while the lookup is in flight, so its result must not be cached
+	updated := decision.UserProfile{ID: "user_1"}
+	assert.NoError(t, service.Save(context.Background(), updated))
+	close(store.release)
+	<-done
+
+	assertLookup(t, service, updated)
+	assert.Equal(t, 2, store.lookupCount())
+}
+
+func TestCachingServiceDoesNotCacheErrors(t *testing.T) {
+	store := newCountingService()
+	store.lookupErr = errors.New("store down")
+	service := NewCachingService(store)
+
+	_, err := service.Lookup(context.Background(), "user_1")
+	assert.Error(t, err)
+
+	store.lookupLock.Lock()
+	store.lookupErr = nil
+	store.lookupLock.Unlock()
+	assertLookup(t, service, testProfile("user_1"))
+	assert.Equal(t, 2, store.lookupCount())
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,67 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package userprofile provides UserProfileService implementations backed by files, SQL databases and Redis
+package userprofile
+
+import (
+	"encoding/json"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+)
+
+// profileDocument is the serialized form of a user profile, compatible with the other Optimizely SDKs:
+// {"user_id": "...", "experiment_bucket_map": {"<experiment id>": {"variation_id": "..."}}}
+type profileDocument struct {
+	UserID              string                       `json:"user_id"`
+	ExperimentBucketMap map[string]map[string]string `json:"experiment_bucket_map"`
+}
+
+// Marshal serializes the user profile to JSON, nesting the saved decisions by experiment ID and field
+func Marshal(profile decision.UserProfile) ([]byte, error) {
+	document := profileDocument{
+		UserID:              profile.ID,
+		ExperimentBucketMap: make(map[string]map[string]string, len(profile.ExperimentBucketMap)),
+	}
+	for key, value := range profile.ExperimentBucketMap {
+		fields, ok := document.ExperimentBucketMap[key.ExperimentID]
+		if !ok {
+			fields = map[string]string{}
+			document.ExperimentBucketMap[key.ExperimentID] = fields
+		}
+		fields[key.Field] = value
+	}
+	return json.Marshal(document)
+}
+
+// Unmarshal parses a user profile serialized by Marshal
+func Unmarshal(data []byte) (decision.UserProfile, error) {
+	var document profileDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return decision.UserProfile{}, err
+	}
+
+	profile := decision.UserProfile{
+		ID:                  document.UserID,
+		ExperimentBucketMap: map[decision.UserDecisionKey]string{},
+	}
+	for experimentID, fields := range document.ExperimentBucketMap {
+		for field, value := range fields {
+			profile.ExperimentBucketMap[decision.UserDecisionKey{ExperimentID: experimentID, Field: field}] = value
+		}
+	}
+	return profile, nil
+}
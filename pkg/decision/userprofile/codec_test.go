@@ -0,0 +1,61 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package userprofile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testProfile(userID string) decision.UserProfile {
+	return decision.UserProfile{
+		ID: userID,
+		ExperimentBucketMap: map[decision.UserDecisionKey]string{
+			decision.NewUserDecisionKey("exp_1"): "var_1",
+			decision.NewUserDecisionKey("exp_2"): "var_2",
+		},
+	}
+}
+
+func assertLookup(t *testing.T, service decision.UserProfileServiceV2, expected decision.UserProfile) {
+	profile, err := service.Lookup(context.Background(), expected.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, profile)
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(testProfile("user_1"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"user_id":"user_1","experiment_bucket_map":{"exp_1":{"variation_id":"var_1"},"exp_2":{"variation_id":"var_2"}}}`, string(data))
+}
+
+func TestUnmarshal(t *testing.T) {
+	profile, err := Unmarshal([]byte(`{"user_id":"user_1","experiment_bucket_map":{"exp_1":{"variation_id":"var_1"},"exp_2":{"variation_id":"var_2"}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, testProfile("user_1"), profile)
+
+	profile, err = Unmarshal([]byte(`{"user_id":"user_1"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, decision.UserProfile{ID: "user_1", ExperimentBucketMap: map[decision.UserDecisionKey]string{}}, profile)
+
+	_, err = Unmarshal([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package userprofile //
+package userprofile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+)
+
+// FileService is a UserProfileServiceV2 storing every profile as a JSON document in its own file of a directory.
+// Profiles are written atomically, so the directory stays consistent if the process dies during a save.
+type FileService struct {
+	dir  string
+	lock sync.RWMutex
+}
+
+// NewFileService returns a FileService storing profiles in dir, creating the directory if needed
+func NewFileService(dir string) (*FileService, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileService{dir: dir}, nil
+}
+
+// Lookup returns the saved profile of the user, or an empty profile if there is none
+func (s *FileService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	if err := ctx.Err(); err != nil {
+		return decision.UserProfile{ID: userID}, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	data, err := ioutil.ReadFile(s.path(userID))
+	if os.IsNotExist(err) {
+		return decision.UserProfile{ID: userID}, nil
+	}
+	if err != nil {
+		return decision.UserProfile{ID: userID}, err
+	}
+	return Unmarshal(data)
+}
+
+// Save stores the profile, replacing the previously saved one
+func (s *FileService) Save(ctx context.Context, profile decision.UserProfile) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := Marshal(profile)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// write to a temporary file and rename it over the profile so readers never see a partial document
+	tmp, err := ioutil.TempFile(s.dir, ".profile-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(profile.ID))
+}
+
+// maxFileNameLength keeps the file names well under the limit of common file systems
+const maxFileNameLength = 200
+
+// path returns the file of the user, hex encoding the ID so that any user ID is a valid file name.
+// Long IDs are hashed instead.
+func (s *FileService) path(userID string) string {
+	name := hex.EncodeToString([]byte(userID))
+	if len(name) > maxFileNameLength {
+		sum := sha256.Sum256([]byte(userID))
+		name = hex.EncodeToString(sum[:])
+	}
+	return filepath.Join(s.dir, name+".json")
+}
@@ -0,0 +1,70 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package userprofile
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileService(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ups")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	service, err := NewFileService(filepath.Join(dir, "profiles"))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	assertLookup(t, service, decision.UserProfile{ID: "user/1"})
+
+	assert.NoError(t, service.Save(ctx, testProfile("user/1")))
+	assertLookup(t, service, testProfile("user/1"))
+
+	updated := testProfile("user/1")
+	updated.ExperimentBucketMap[decision.NewUserDecisionKey("exp_3")] = "var_3"
+	assert.NoError(t, service.Save(ctx, updated))
+	assertLookup(t, service, updated)
+
+	longID := strings.Repeat("x", 500)
+	assert.NoError(t, service.Save(ctx, testProfile(longID)))
+	assertLookup(t, service, testProfile(longID))
+
+	// no temporary files are left behind
+	files, err := ioutil.ReadDir(filepath.Join(dir, "profiles"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// a persisted profile is visible to a new service on the same directory
+	reopened, err := NewFileService(filepath.Join(dir, "profiles"))
+	assert.NoError(t, err)
+	assertLookup(t, reopened, updated)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = service.Lookup(cancelled, "user/1")
+	assert.Error(t, err)
+	assert.Error(t, service.Save(cancelled, updated))
+}
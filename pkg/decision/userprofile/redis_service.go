@@ -0,0 +1,274 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package userprofile //
+package userprofile
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+)
+
+// DefaultKeyPrefix is prepended to the user ID to build the Redis key of a profile
+const DefaultKeyPrefix = "optimizely:ups:"
+
+const defaultRedisTimeout = 5 * time.Second
+
+// redisError is an error reply of the server, which leaves the connection usable
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// RedisOptionFunc is used to configure the RedisService
+type RedisOptionFunc func(s *RedisService)
+
+// WithRedisPassword sets the password sent with AUTH when connecting
+func WithRedisPassword(password string) RedisOptionFunc {
+	return func(s *RedisService) {
+		s.password = password
+	}
+}
+
+// WithRedisDB sets the database selected when connecting
+func WithRedisDB(db int) RedisOptionFunc {
+	return func(s *RedisService) {
+		s.db = db
+	}
+}
+
+// WithKeyPrefix sets the prefix of the profile keys
+func WithKeyPrefix(prefix string) RedisOptionFunc {
+	return func(s *RedisService) {
+		s.keyPrefix = prefix
+	}
+}
+
+// WithExpiration sets a time to live on the saved profiles. Profiles never expire by default.
+func WithExpiration(expiration time.Duration) RedisOptionFunc {
+	return func(s *RedisService) {
+		s.expiration = expiration
+	}
+}
+
+// WithRedisTimeout sets the timeout of connecting and of every command
+func WithRedisTimeout(timeout time.Duration) RedisOptionFunc {
+	return func(s *RedisService) {
+		s.timeout = timeout
+	}
+}
+
+// RedisService is a UserProfileServiceV2 storing profiles in Redis or any server speaking the Redis protocol.
+// Commands are sent over a single connection which is reopened after network errors.
+type RedisService struct {
+	addr       string
+	password   string
+	db         int
+	keyPrefix  string
+	expiration time.Duration
+	timeout    time.Duration
+
+	lock   sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisService returns a RedisService for the server at addr (host:port). The connection is opened lazily.
+func NewRedisService(addr string, options ...RedisOptionFunc) *RedisService {
+	s := &RedisService{
+		addr:      addr,
+		keyPrefix: DefaultKeyPrefix,
+		timeout:   defaultRedisTimeout,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// Close closes the connection to the server
+func (s *RedisService) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// Lookup returns the saved profile of the user, or an empty profile if there is none
+func (s *RedisService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	reply, err := s.do(ctx, "GET", s.keyPrefix+userID)
+	if err != nil {
+		return decision.UserProfile{ID: userID}, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return decision.UserProfile{ID: userID}, nil
+	}
+	return Unmarshal(data)
+}
+
+// Save stores the profile, replacing the previously saved one
+func (s *RedisService) Save(ctx context.Context, profile decision.UserProfile) error {
+	data, err := Marshal(profile)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"SET", s.keyPrefix + profile.ID, string(data)}
+	if s.expiration > 0 {
+		args = append(args, "PX", strconv.FormatInt(int64(s.expiration/time.Millisecond), 10))
+	}
+	_, err = s.do(ctx, args...)
+	return err
+}
+
+// do sends the command and returns its reply, connecting first if needed. The configured timeout is shortened
+// to the deadline of the context.
+func (s *RedisService) do(ctx context.Context, args ...string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	if s.conn == nil {
+		if err := s.connect(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := s.roundTrip(args, deadline)
+	if _, ok := err.(redisError); err != nil && !ok {
+		// the connection is in an unknown state after a network or protocol error
+		s.conn.Close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+func (s *RedisService) connect(deadline time.Time) error {
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.Dial("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+
+	if s.password != "" {
+		if _, err = s.roundTrip([]string{"AUTH", s.password}, deadline); err != nil {
+			return s.abort(err)
+		}
+	}
+	if s.db != 0 {
+		if _, err = s.roundTrip([]string{"SELECT", strconv.Itoa(s.db)}, deadline); err != nil {
+			return s.abort(err)
+		}
+	}
+	return nil
+}
+
+func (s *RedisService) abort(err error) error {
+	s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *RedisService) roundTrip(args []string, deadline time.Time) (interface{}, error) {
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := s.conn.Write(encodeCommand(args)); err != nil {
+		return nil, err
+	}
+	return readReply(s.reader)
+}
+
+// encodeCommand encodes the command as a RESP array of bulk strings
+func encodeCommand(args []string) []byte {
+	buffer := []byte(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, arg := range args {
+		buffer = append(buffer, fmt.Sprintf("$%d\r\n", len(arg))...)
+		buffer = append(buffer, arg...)
+		buffer = append(buffer, "\r\n"...)
+	}
+	return buffer
+}
+
+// readReply parses a RESP reply. Bulk strings are returned as []byte, nil bulk strings and arrays as nil.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("malformed redis reply")
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(payload)
+		if err != nil || length < 0 {
+			return nil, err
+		}
+		data := make([]byte, length+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:length], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unknown redis reply type %q", kind)
+}
@@ -0,0 +1,93 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package userprofile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisService(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	service := NewRedisService(server.Addr())
+	defer service.Close()
+
+	assertLookup(t, service, decision.UserProfile{ID: "user_1"})
+
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_1")))
+	assertLookup(t, service, testProfile("user_1"))
+	assert.True(t, server.Exists(DefaultKeyPrefix+"user_1"))
+	assert.Equal(t, time.Duration(0), server.TTL(DefaultKeyPrefix+"user_1"))
+}
+
+func TestRedisServiceOptions(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+	server.RequireAuth("secret")
+
+	service := NewRedisService(server.Addr(), WithRedisPassword("secret"), WithRedisDB(2),
+		WithKeyPrefix("ups:"), WithExpiration(time.Hour))
+	defer service.Close()
+
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_1")))
+	assertLookup(t, service, testProfile("user_1"))
+
+	server.Select(2)
+	assert.True(t, server.Exists("ups:user_1"))
+	assert.Equal(t, time.Hour, server.TTL("ups:user_1"))
+}
+
+func TestRedisServiceReconnects(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	ctx := context.Background()
+	service := NewRedisService(server.Addr())
+	defer service.Close()
+	assert.NoError(t, service.Save(ctx, testProfile("user_1")))
+
+	server.Close()
+	_, err = service.Lookup(ctx, "user_1")
+	assert.Error(t, err)
+
+	assert.NoError(t, server.Restart())
+	assert.NoError(t, service.Save(ctx, testProfile("user_2")))
+	assertLookup(t, service, testProfile("user_2"))
+}
+
+func TestRedisServiceWrongPassword(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+	server.RequireAuth("secret")
+
+	service := NewRedisService(server.Addr(), WithRedisPassword("wrong"))
+	profile, err := service.Lookup(context.Background(), "user_1")
+	assert.Error(t, err)
+	assert.Equal(t, decision.UserProfile{ID: "user_1"}, profile)
+}
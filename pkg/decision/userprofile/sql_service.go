@@ -0,0 +1,124 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package userprofile //
+package userprofile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+)
+
+// DefaultTableName is the table used by the SQLService unless configured otherwise
+const DefaultTableName = "optimizely_user_profiles"
+
+// Dialect is the SQL flavor of the database, which sets the placeholders and the upsert statement
+type Dialect int
+
+const (
+	// SQLiteDialect is the dialect of SQLite 3.24 and later
+	SQLiteDialect Dialect = iota
+	// PostgresDialect is the dialect of PostgreSQL 9.5 and later
+	PostgresDialect
+	// MySQLDialect is the dialect of MySQL and MariaDB
+	MySQLDialect
+)
+
+// placeholder returns the bind parameter for the n-th (1-based) argument of a statement
+func (d Dialect) placeholder(n int) string {
+	if d == PostgresDialect {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// upsert returns the statement inserting the profile of a user or replacing the saved one, atomically
+func (d Dialect) upsert(tableName string) string {
+	insert := fmt.Sprintf("INSERT INTO %s (user_id, profile) VALUES (%s, %s)", tableName, d.placeholder(1), d.placeholder(2))
+	if d == MySQLDialect {
+		return insert + " ON DUPLICATE KEY UPDATE profile = VALUES(profile)"
+	}
+	return insert + " ON CONFLICT (user_id) DO UPDATE SET profile = excluded.profile"
+}
+
+// SQLOptionFunc is used to configure the SQLService
+type SQLOptionFunc func(s *SQLService)
+
+// WithTableName sets the table storing the profiles
+func WithTableName(tableName string) SQLOptionFunc {
+	return func(s *SQLService) {
+		s.tableName = tableName
+	}
+}
+
+// WithDialect sets the dialect of the database, SQLiteDialect by default
+func WithDialect(dialect Dialect) SQLOptionFunc {
+	return func(s *SQLService) {
+		s.dialect = dialect
+	}
+}
+
+// SQLService is a UserProfileServiceV2 storing profiles in a table of any database/sql database.
+// The table needs a unique text column user_id and a text column profile, for instance:
+//
+//	CREATE TABLE optimizely_user_profiles (user_id VARCHAR(255) PRIMARY KEY, profile TEXT NOT NULL)
+type SQLService struct {
+	db        *sql.DB
+	tableName string
+	dialect   Dialect
+}
+
+// NewSQLService returns a SQLService using the given database
+func NewSQLService(db *sql.DB, options ...SQLOptionFunc) *SQLService {
+	s := &SQLService{
+		db:        db,
+		tableName: DefaultTableName,
+		dialect:   SQLiteDialect,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// Lookup returns the saved profile of the user, or an empty profile if there is none
+func (s *SQLService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	query := fmt.Sprintf("SELECT profile FROM %s WHERE user_id = %s", s.tableName, s.dialect.placeholder(1))
+
+	var data string
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return decision.UserProfile{ID: userID}, nil
+	}
+	if err != nil {
+		return decision.UserProfile{ID: userID}, err
+	}
+	return Unmarshal([]byte(data))
+}
+
+// Save stores the profile, replacing the previously saved one in a single upsert statement
+func (s *SQLService) Save(ctx context.Context, profile decision.UserProfile) error {
+	data, err := Marshal(profile)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.dialect.upsert(s.tableName), profile.ID, string(data))
+	return err
+}
@@ -0,0 +1,80 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package userprofile
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLService(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	// every connection to :memory: is a new database
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE profiles (user_id VARCHAR(255) PRIMARY KEY, profile TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	service := NewSQLService(db, WithTableName("profiles"))
+	assertLookup(t, service, decision.UserProfile{ID: "user_1"})
+
+	assert.NoError(t, service.Save(ctx, testProfile("user_1")))
+	assertLookup(t, service, testProfile("user_1"))
+
+	updated := testProfile("user_1")
+	updated.ExperimentBucketMap[decision.NewUserDecisionKey("exp_3")] = "var_3"
+	assert.NoError(t, service.Save(ctx, updated))
+	assertLookup(t, service, updated)
+
+	// saving an unchanged profile again upserts the same row instead of inserting a duplicate
+	assert.NoError(t, service.Save(ctx, updated))
+	assertLookup(t, service, updated)
+
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM profiles").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestSQLServiceMissingTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := NewSQLService(db)
+	assert.Error(t, service.Save(context.Background(), testProfile("user_1")))
+	_, err = service.Lookup(context.Background(), "user_1")
+	assert.Error(t, err)
+}
+
+func TestDialects(t *testing.T) {
+	assert.Equal(t, "INSERT INTO profiles (user_id, profile) VALUES (?, ?) ON CONFLICT (user_id) DO UPDATE SET profile = excluded.profile",
+		SQLiteDialect.upsert("profiles"))
+	assert.Equal(t, "INSERT INTO profiles (user_id, profile) VALUES ($1, $2) ON CONFLICT (user_id) DO UPDATE SET profile = excluded.profile",
+		PostgresDialect.upsert("profiles"))
+	assert.Equal(t, "INSERT INTO profiles (user_id, profile) VALUES (?, ?) ON DUPLICATE KEY UPDATE profile = VALUES(profile)",
+		MySQLDialect.upsert("profiles"))
+	assert.Equal(t, "$2", PostgresDialect.placeholder(2))
+}
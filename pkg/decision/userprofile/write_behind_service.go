@@ -0,0 +1,174 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package userprofile //
+package userprofile
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/logging"
+)
+
+// DefaultMaxPendingSaves holds the default number of profiles waiting to be written by a WriteBehindService
+const DefaultMaxPendingSaves = 1000
+
+// DefaultWriteBehindInterval holds the default interval at which pending profiles are written
+const DefaultWriteBehindInterval = time.Second
+
+var writeBehindLogger = logging.GetLogger("WriteBehindUserProfileService")
+
+// WriteBehindOptionFunc is used to configure the WriteBehindService
+type WriteBehindOptionFunc func(s *WriteBehindService)
+
+// WithMaxPendingSaves sets the number of profiles which can wait to be written. When it is reached, saves are
+// written synchronously until the pending profiles are flushed.
+func WithMaxPendingSaves(maxPending int) WriteBehindOptionFunc {
+	return func(s *WriteBehindService) {
+		s.maxPending = maxPending
+	}
+}
+
+// WithWriteBehindInterval sets the interval at which pending profiles are written
+func WithWriteBehindInterval(interval time.Duration) WriteBehindOptionFunc {
+	return func(s *WriteBehindService) {
+		s.interval = interval
+	}
+}
+
+// WriteBehindService is a UserProfileServiceV2 decorator acknowledging saves immediately and writing them to the
+// wrapped service in the background. Lookups see the pending profiles, and only the latest pending profile of a user
+// is written. Pending profiles are flushed when the context passed to Start is done.
+type WriteBehindService struct {
+	userProfileService decision.UserProfileServiceV2
+	maxPending         int
+	interval           time.Duration
+
+	flushLock sync.Mutex
+	lock      sync.Mutex
+	pending   map[string]decision.UserProfile
+	writing   map[string]decision.UserProfile // profiles being written by Flush
+	flush     chan struct{}
+}
+
+// NewWriteBehindService returns a WriteBehindService writing to the given service
+func NewWriteBehindService(userProfileService decision.UserProfileServiceV2, options ...WriteBehindOptionFunc) *WriteBehindService {
+	s := &WriteBehindService{
+		userProfileService: userProfileService,
+		maxPending:         DefaultMaxPendingSaves,
+		interval:           DefaultWriteBehindInterval,
+		pending:            map[string]decision.UserProfile{},
+		flush:              make(chan struct{}, 1),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// Lookup returns the pending profile of the user, or looks it up in the wrapped service
+func (s *WriteBehindService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	s.lock.Lock()
+	profile, ok := s.pending[userID]
+	if !ok {
+		profile, ok = s.writing[userID]
+	}
+	s.lock.Unlock()
+
+	if ok {
+		return profile, nil
+	}
+	return s.userProfileService.Lookup(ctx, userID)
+}
+
+// Save queues the profile to be written. The profile is written synchronously if too many are pending.
+func (s *WriteBehindService) Save(ctx context.Context, profile decision.UserProfile) error {
+	s.lock.Lock()
+	_, replaces := s.pending[profile.ID]
+	if !replaces && len(s.pending) >= s.maxPending {
+		s.lock.Unlock()
+		s.signal()
+		writeBehindLogger.Debug("Too many pending user profiles, saving synchronously")
+		return s.userProfileService.Save(ctx, profile)
+	}
+	s.pending[profile.ID] = profile
+	full := len(s.pending) >= s.maxPending
+	s.lock.Unlock()
+
+	if full {
+		s.signal()
+	}
+	return nil
+}
+
+// Start writes the pending profiles until the context is done, then flushes them
+func (s *WriteBehindService) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush(context.Background())
+		case <-s.flush:
+			s.Flush(context.Background())
+		case <-ctx.Done():
+			writeBehindLogger.Debug("Write behind user profile service stopped, flushing profiles.")
+			s.Flush(context.Background())
+			return
+		}
+	}
+}
+
+// Flush writes the pending profiles. Profiles which fail to be written are kept pending unless they were
+// replaced in the meantime.
+func (s *WriteBehindService) Flush(ctx context.Context) {
+	s.flushLock.Lock()
+	defer s.flushLock.Unlock()
+
+	s.lock.Lock()
+	writing := s.pending
+	s.writing = writing
+	s.pending = make(map[string]decision.UserProfile, len(writing))
+	s.lock.Unlock()
+
+	failed := map[string]decision.UserProfile{}
+	for userID, profile := range writing {
+		if err := s.userProfileService.Save(ctx, profile); err != nil {
+			writeBehindLogger.Error("Unable to save user profile, retrying on next flush", err)
+			failed[userID] = profile
+		}
+	}
+
+	s.lock.Lock()
+	for userID, profile := range failed {
+		if _, replaced := s.pending[userID]; !replaced {
+			s.pending[userID] = profile
+		}
+	}
+	s.writing = nil
+	s.lock.Unlock()
+}
+
+func (s *WriteBehindService) signal() {
+	select {
+	case s.flush <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,139 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package userprofile
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryService stores profiles in memory, failing saves while failSaves is set
+type memoryService struct {
+	lock      sync.Mutex
+	profiles  map[string]decision.UserProfile
+	saves     int
+	failSaves bool
+}
+
+func newMemoryService() *memoryService {
+	return &memoryService{profiles: map[string]decision.UserProfile{}}
+}
+
+func (m *memoryService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.profiles[userID], nil
+}
+
+func (m *memoryService) Save(ctx context.Context, profile decision.UserProfile) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.failSaves {
+		return errors.New("store down")
+	}
+	m.saves++
+	m.profiles[profile.ID] = profile
+	return nil
+}
+
+func (m *memoryService) saveCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.saves
+}
+
+func TestWriteBehindServiceReadsPendingProfiles(t *testing.T) {
+	store := newMemoryService()
+	service := NewWriteBehindService(store)
+
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_1")))
+	assert.Equal(t, 0, store.saveCount())
+	assertLookup(t, service, testProfile("user_1"))
+
+	service.Flush(context.Background())
+	assert.Equal(t, 1, store.saveCount())
+	assertLookup(t, store, testProfile("user_1"))
+	assertLookup(t, service, testProfile("user_1"))
+}
+
+func TestWriteBehindServiceWritesLatestProfile(t *testing.T) {
+	store := newMemoryService()
+	service := NewWriteBehindService(store)
+
+	assert.NoError(t, service.Save(context.Background(), decision.UserProfile{ID: "user_1"}))
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_1")))
+	service.Flush(context.Background())
+	assert.Equal(t, 1, store.saveCount())
+	assertLookup(t, store, testProfile("user_1"))
+}
+
+func TestWriteBehindServiceMaxPendingSaves(t *testing.T) {
+	store := newMemoryService()
+	service := NewWriteBehindService(store, WithMaxPendingSaves(2))
+
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_1")))
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_2")))
+	assert.Equal(t, 0, store.saveCount())
+
+	// the pending profiles are full, so the next one is written synchronously
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_3")))
+	assert.Equal(t, 1, store.saveCount())
+	assertLookup(t, store, testProfile("user_3"))
+}
+
+func TestWriteBehindServiceRetriesFailedSaves(t *testing.T) {
+	store := newMemoryService()
+	store.failSaves = true
+	service := NewWriteBehindService(store)
+
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_1")))
+	service.Flush(context.Background())
+	assert.Equal(t, 0, store.saveCount())
+	assertLookup(t, service, testProfile("user_1"))
+
+	store.lock.Lock()
+	store.failSaves = false
+	store.lock.Unlock()
+	service.Flush(context.Background())
+	assert.Equal(t, 1, store.saveCount())
+	assertLookup(t, store, testProfile("user_1"))
+}
+
+func TestWriteBehindServiceFlushesOnStop(t *testing.T) {
+	store := newMemoryService()
+	service := NewWriteBehindService(store, WithWriteBehindInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Start(ctx)
+		close(done)
+	}()
+
+	assert.NoError(t, service.Save(context.Background(), testProfile("user_1")))
+	cancel()
+	<-done
+	assert.Equal(t, 1, store.saveCount())
+	assertLookup(t, store, testProfile("user_1"))
+}
@@ -18,6 +18,7 @@
 package event
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -34,6 +35,9 @@ const sleepTime = 1 * time.Second
 
 var dispatcherLogger = logging.GetLogger("EventDispatcher")
 
+// ErrPayloadTooLarge is returned by a Dispatcher when the endpoint rejected the batch because of its size
+var ErrPayloadTooLarge = errors.New("event payload too large")
+
 // Dispatcher dispatches events
 type Dispatcher interface {
 	DispatchEvent(event LogEvent) (bool, error)
@@ -44,11 +48,25 @@ type HTTPEventDispatcher struct {
 	requester *utils.HTTPRequester
 }
 
+// NewHTTPEventDispatcher creates an HTTPEventDispatcher posting with the given requester, for instance one
+// configured with utils.GzipThreshold to compress large batches. A default requester is used if nil.
+func NewHTTPEventDispatcher(requester *utils.HTTPRequester) *HTTPEventDispatcher {
+	if requester == nil {
+		requester = utils.NewHTTPRequester()
+	}
+	return &HTTPEventDispatcher{requester: requester}
+}
+
 // DispatchEvent dispatches event with callback
 func (ed *HTTPEventDispatcher) DispatchEvent(event LogEvent) (bool, error) {
 
 	_, _, code, err := ed.requester.Post(event.EndPoint, event.Event)
 
+	if code == http.StatusRequestEntityTooLarge {
+		dispatcherLogger.Warning(fmt.Sprintf("http.Post rejected batch of %d visitors as too large", len(event.Event.Visitors)))
+		return false, ErrPayloadTooLarge
+	}
+
 	// also check response codes
 	// resp.StatusCode == 400 is an error
 	var success bool
@@ -122,6 +140,21 @@ func (ed *QueueEventDispatcher) flushEvents() {
 
 		success, err := ed.Dispatcher.DispatchEvent(event)
 
+		if err == ErrPayloadTooLarge {
+			// split the batch instead of retrying it forever, the halves are dispatched after the queued events
+			ed.eventQueue.Remove(1)
+			if first, second, ok := splitLogEvent(event); ok {
+				dispatcherLogger.Debug(fmt.Sprintf("Splitting log event of %d visitors", len(event.Event.Visitors)))
+				ed.eventQueue.Add(first)
+				ed.eventQueue.Add(second)
+			} else {
+				dispatcherLogger.Error("log event of a single visitor is too large, discarding it", err)
+				ed.failFlushCounter.Add(1)
+			}
+			retryCount = 0
+			continue
+		}
+
 		if err == nil {
 			if success {
 				dispatcherLogger.Debug(fmt.Sprintf("Dispatched log event %+v", event))
@@ -150,6 +183,20 @@ func (ed *QueueEventDispatcher) flushEvents() {
 	ed.queueSize.Set(float64(ed.eventQueue.Size()))
 }
 
+// splitLogEvent bisects the visitors of the log event. It returns false if there is a single visitor.
+func splitLogEvent(event LogEvent) (first, second LogEvent, ok bool) {
+	visitors := event.Event.Visitors
+	if len(visitors) < 2 {
+		return event, event, false
+	}
+
+	half := len(visitors) / 2
+	first, second = event, event
+	first.Event.Visitors = visitors[:half:half]
+	second.Event.Visitors = visitors[half:]
+	return first, second, true
+}
+
 // NewQueueEventDispatcher creates a Dispatcher that queues in memory and then sends via go routine.
 func NewQueueEventDispatcher(metricsRegistry metrics.Registry) *QueueEventDispatcher {
 
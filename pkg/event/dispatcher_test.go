@@ -18,12 +18,17 @@
 package event
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/optimizely/go-sdk/pkg/entities"
 	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/optimizely/go-sdk/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -100,6 +105,76 @@ func NewMetricsRegistry() *MetricsRegistry {
 	}
 }
 
+func TestHTTPEventDispatcher_Gzip(t *testing.T) {
+	var received Batch
+	var contentEncoding string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentEncoding = r.Header.Get("Content-Encoding")
+		reader, err := gzip.NewReader(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.NewDecoder(reader).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	conversionUserEvent := CreateConversionUserEvent(TestConfig{}, entities.Event{ExperimentIds: []string{"15402980349"}, ID: "15368860886", Key: "sample_conversion"}, userContext, nil)
+	logEvent := createLogEvent(createBatchEvent(conversionUserEvent, createVisitorFromUserEvent(conversionUserEvent)))
+	logEvent.EndPoint = ts.URL
+
+	dispatcher := NewHTTPEventDispatcher(utils.NewHTTPRequester(utils.GzipThreshold(1)))
+	success, err := dispatcher.DispatchEvent(logEvent)
+
+	assert.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "gzip", contentEncoding)
+	assert.Equal(t, logEvent.Event.Visitors[0].VisitorID, received.Visitors[0].VisitorID)
+	assert.Equal(t, logEvent.Event.ProjectID, received.ProjectID)
+}
+
+func TestHTTPEventDispatcher_PayloadTooLarge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer ts.Close()
+
+	logEvent := createLogEvent(createBatchEvent(BuildTestImpressionEvent(), Visitor{}))
+	logEvent.EndPoint = ts.URL
+
+	success, err := NewHTTPEventDispatcher(nil).DispatchEvent(logEvent)
+	assert.False(t, success)
+	assert.Equal(t, ErrPayloadTooLarge, err)
+}
+
+func TestQueueEventDispatcher_SplitsTooLargeEvents(t *testing.T) {
+	metricsRegistry := NewMetricsRegistry()
+
+	q := NewQueueEventDispatcher(metricsRegistry)
+	sender := &SizeLimitedDispatcher{maxVisitors: 1}
+	q.Dispatcher = sender
+
+	batch := createBatchEvent(BuildTestImpressionEvent(), Visitor{VisitorID: "1"})
+	batch.Visitors = append(batch.Visitors, Visitor{VisitorID: "2"}, Visitor{VisitorID: "3"})
+	q.eventQueue.Add(createLogEvent(batch))
+	q.flushEvents()
+
+	assert.Equal(t, 0, q.eventQueue.Size())
+	var visitorIDs []string
+	for _, logEvent := range sender.Events {
+		assert.Len(t, logEvent.Event.Visitors, 1)
+		visitorIDs = append(visitorIDs, logEvent.Event.Visitors[0].VisitorID)
+	}
+	assert.ElementsMatch(t, []string{"1", "2", "3"}, visitorIDs)
+	assert.Equal(t, float64(0), metricsRegistry.GetCounter(metrics.DispatcherFailedFlush).(*MetricsCounter).Get())
+
+	// a single visitor which is too large is dropped
+	sender.maxVisitors = 0
+	q.eventQueue.Add(createLogEvent(createBatchEvent(BuildTestImpressionEvent(), Visitor{VisitorID: "4"})))
+	q.flushEvents()
+
+	assert.Equal(t, 0, q.eventQueue.Size())
+	assert.Equal(t, float64(1), metricsRegistry.GetCounter(metrics.DispatcherFailedFlush).(*MetricsCounter).Get())
+}
+
 func TestQueueEventDispatcher_DispatchEvent(t *testing.T) {
 	metricsRegistry := NewMetricsRegistry()
 
@@ -62,8 +62,8 @@ type ConversionEvent struct {
 
 // LogEvent represents a log event
 type LogEvent struct {
-	EndPoint string
-	Event    Batch
+	EndPoint string `json:"endpoint"`
+	Event    Batch  `json:"event"`
 }
 
 // Batch - Context about the event to send in batch
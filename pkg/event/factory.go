@@ -20,6 +20,7 @@ package event
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -44,8 +45,64 @@ func createLogEvent(event Batch) LogEvent {
 	return LogEvent{EndPoint: eventEndPoint, Event: event}
 }
 
-func makeTimestamp() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
+// Clock tells the time events are created at
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator generates the unique IDs of events
+type IDGenerator interface {
+	NewID() string
+}
+
+// Factory creates user events and the visitors sent for them, timestamping them with its clock and identifying
+// them with its ID generator. A nil Factory uses the system clock and random UUIDs.
+type Factory struct {
+	clock       Clock
+	idGenerator IDGenerator
+}
+
+// FactoryOptionFunc is used to provide custom clock and ID generator to the Factory.
+type FactoryOptionFunc func(*Factory)
+
+// WithClock sets the clock used to timestamp events
+func WithClock(clock Clock) FactoryOptionFunc {
+	return func(f *Factory) {
+		f.clock = clock
+	}
+}
+
+// WithIDGenerator sets the generator of the event UUIDs
+func WithIDGenerator(idGenerator IDGenerator) FactoryOptionFunc {
+	return func(f *Factory) {
+		f.idGenerator = idGenerator
+	}
+}
+
+// NewFactory returns a new event factory
+func NewFactory(options ...FactoryOptionFunc) *Factory {
+	f := &Factory{}
+	for _, opt := range options {
+		opt(f)
+	}
+	return f
+}
+
+var defaultFactory = NewFactory()
+
+func (f *Factory) makeTimestamp() int64 {
+	now := time.Now()
+	if f != nil && f.clock != nil {
+		now = f.clock.Now()
+	}
+	return now.UnixNano() / int64(time.Millisecond)
+}
+
+func (f *Factory) makeUUID() string {
+	if f != nil && f.idGenerator != nil {
+		return f.idGenerator.NewID()
+	}
+	return guuid.New().String()
 }
 
 // CreateEventContext creates and returns EventContext
@@ -80,13 +137,20 @@ func createImpressionEvent(projectConfig config.ProjectConfig, experiment entiti
 func CreateImpressionUserEvent(projectConfig config.ProjectConfig, experiment entities.Experiment,
 	variation entities.Variation,
 	userContext entities.UserContext) UserEvent {
+	return defaultFactory.CreateImpressionUserEvent(projectConfig, experiment, variation, userContext)
+}
+
+// CreateImpressionUserEvent creates and returns ImpressionEvent for user
+func (f *Factory) CreateImpressionUserEvent(projectConfig config.ProjectConfig, experiment entities.Experiment,
+	variation entities.Variation,
+	userContext entities.UserContext) UserEvent {
 
 	impression := createImpressionEvent(projectConfig, experiment, variation, userContext.Attributes)
 
 	userEvent := UserEvent{}
-	userEvent.Timestamp = makeTimestamp()
+	userEvent.Timestamp = f.makeTimestamp()
 	userEvent.VisitorID = userContext.ID
-	userEvent.UUID = guuid.New().String()
+	userEvent.UUID = f.makeUUID()
 	userEvent.Impression = &impression
 	userEvent.EventContext = CreateEventContext(projectConfig)
 
@@ -94,17 +158,18 @@ func CreateImpressionUserEvent(projectConfig config.ProjectConfig, experiment en
 }
 
 // create an impression visitor
-func createImpressionVisitor(userEvent UserEvent) Visitor {
+func (f *Factory) createImpressionVisitor(userEvent UserEvent) Visitor {
 	decision := Decision{}
 	decision.CampaignID = userEvent.Impression.CampaignID
 	decision.ExperimentID = userEvent.Impression.ExperimentID
 	decision.VariationID = userEvent.Impression.VariationID
 
 	dispatchEvent := SnapshotEvent{}
-	dispatchEvent.Timestamp = makeTimestamp()
+	dispatchEvent.Timestamp = f.makeTimestamp()
 	dispatchEvent.Key = userEvent.Impression.Key
 	dispatchEvent.EntityID = userEvent.Impression.EntityID
-	dispatchEvent.UUID = guuid.New().String()
+	// the UUID of the user event, stable across the retries of the batch, lets the backend deduplicate impressions
+	dispatchEvent.UUID = userEvent.UUID
 	dispatchEvent.Tags = make(map[string]interface{})
 
 	visitor := createVisitor(userEvent, userEvent.Impression.Attributes, []Decision{decision}, []SnapshotEvent{dispatchEvent})
@@ -126,11 +191,16 @@ func createConversionEvent(projectConfig config.ProjectConfig, event entities.Ev
 
 // CreateConversionUserEvent creates and returns ConversionEvent for user
 func CreateConversionUserEvent(projectConfig config.ProjectConfig, event entities.Event, userContext entities.UserContext, eventTags map[string]interface{}) UserEvent {
+	return defaultFactory.CreateConversionUserEvent(projectConfig, event, userContext, eventTags)
+}
+
+// CreateConversionUserEvent creates and returns ConversionEvent for user
+func (f *Factory) CreateConversionUserEvent(projectConfig config.ProjectConfig, event entities.Event, userContext entities.UserContext, eventTags map[string]interface{}) UserEvent {
 
 	userEvent := UserEvent{}
-	userEvent.Timestamp = makeTimestamp()
+	userEvent.Timestamp = f.makeTimestamp()
 	userEvent.VisitorID = userContext.ID
-	userEvent.UUID = guuid.New().String()
+	userEvent.UUID = f.makeUUID()
 
 	userEvent.EventContext = CreateEventContext(projectConfig)
 	conversion := createConversionEvent(projectConfig, event, userContext.Attributes, eventTags)
@@ -151,21 +221,26 @@ func CreateConversionUserEvent(projectConfig config.ProjectConfig, event entitie
 
 // create visitor from user event
 func createVisitorFromUserEvent(event UserEvent) Visitor {
+	return defaultFactory.createVisitorFromUserEvent(event)
+}
+
+// create visitor from user event
+func (f *Factory) createVisitorFromUserEvent(event UserEvent) Visitor {
 	if event.Impression != nil {
-		return createImpressionVisitor(event)
+		return f.createImpressionVisitor(event)
 	}
 	if event.Conversion != nil {
-		return createConversionVisitor(event)
+		return f.createConversionVisitor(event)
 	}
 
 	return Visitor{}
 }
 
 // create a conversion visitor
-func createConversionVisitor(userEvent UserEvent) Visitor {
+func (f *Factory) createConversionVisitor(userEvent UserEvent) Visitor {
 
 	dispatchEvent := SnapshotEvent{}
-	dispatchEvent.Timestamp = makeTimestamp()
+	dispatchEvent.Timestamp = f.makeTimestamp()
 	dispatchEvent.Key = userEvent.Conversion.Key
 	dispatchEvent.EntityID = userEvent.Conversion.EntityID
 	dispatchEvent.UUID = userEvent.UUID
@@ -221,7 +296,15 @@ func createBatchEvent(userEvent UserEvent, visitor Visitor) Batch {
 func getEventAttributes(projectConfig config.ProjectConfig, attributes map[string]interface{}) []VisitorAttribute {
 	var eventAttributes = []VisitorAttribute{}
 
-	for key, value := range attributes {
+	// sorted so that the payloads are the same for the same attributes
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := attributes[key]
 		if value == nil {
 			continue
 		}
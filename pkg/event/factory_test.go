@@ -19,6 +19,8 @@ package event
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"math/rand"
 	"testing"
 	"time"
@@ -172,3 +174,72 @@ func TestCreateConversionEventRevenue(t *testing.T) {
 	assert.Equal(t, 25.1, *batch.Visitors[0].Snapshots[0].Events[0].Value)
 
 }
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type sequentialIDGenerator struct {
+	next int
+}
+
+func (g *sequentialIDGenerator) NewID() string {
+	g.next++
+	return fmt.Sprintf("uuid-%d", g.next)
+}
+
+func TestFactoryWithClockAndIDGenerator(t *testing.T) {
+	factory := NewFactory(WithClock(fixedClock(time.Unix(1580000000, 0))), WithIDGenerator(&sequentialIDGenerator{}))
+	dispatcher := NewMockDispatcher(10, false)
+	processor := NewBatchEventProcessor(WithEventDispatcher(dispatcher), WithEventFactory(factory))
+
+	experiment := entities.Experiment{Key: "background_experiment", LayerID: "15399420423", ID: "15402980349"}
+	variation := entities.Variation{Key: "variation_a", ID: "15410990633"}
+	userContext := entities.UserContext{ID: "test_user", Attributes: map[string]interface{}{"b": 2, "a": "x"}}
+	conversion := entities.Event{ID: "15368860886", Key: "sample_conversion"}
+
+	processor.ProcessEvent(factory.CreateImpressionUserEvent(TestConfig{}, experiment, variation, userContext))
+	processor.ProcessEvent(factory.CreateConversionUserEvent(TestConfig{}, conversion, userContext, map[string]interface{}{"revenue": 10}))
+	processor.flushEvents()
+
+	logEvents := dispatcher.Events.Get(1)
+	assert.Len(t, logEvents, 1)
+	payload, err := json.Marshal(logEvents[0].(LogEvent).Event)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"account_id": "8362480420",
+		"anonymize_ip": true,
+		"client_name": "`+ClientName+`",
+		"client_version": "`+Version+`",
+		"enrich_decisions": true,
+		"project_id": "15389410617",
+		"revision": "7",
+		"visitors": [
+			{
+				"attributes": [
+					{"entity_id": "100000", "key": "a", "type": "custom", "value": "x"},
+					{"entity_id": "100000", "key": "b", "type": "custom", "value": 2},
+					{"entity_id": "$opt_bot_filtering", "key": "$opt_bot_filtering", "type": "custom", "value": false}
+				],
+				"snapshots": [{
+					"decisions": [{"campaign_id": "15399420423", "experiment_id": "15402980349", "variation_id": "15410990633"}],
+					"events": [{"entity_id": "15399420423", "key": "campaign_activated", "timestamp": 1580000000000, "uuid": "uuid-1"}]
+				}],
+				"visitor_id": "test_user"
+			},
+			{
+				"attributes": [
+					{"entity_id": "100000", "key": "a", "type": "custom", "value": "x"},
+					{"entity_id": "100000", "key": "b", "type": "custom", "value": 2},
+					{"entity_id": "$opt_bot_filtering", "key": "$opt_bot_filtering", "type": "custom", "value": false}
+				],
+				"snapshots": [{
+					"events": [{"entity_id": "15368860886", "key": "sample_conversion", "timestamp": 1580000000000, "uuid": "uuid-2", "tags": {"revenue": 10}, "revenue": 10}]
+				}],
+				"visitor_id": "test_user"
+			}
+		]
+	}`, string(payload))
+}
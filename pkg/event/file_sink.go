@@ -0,0 +1,73 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterSink is a Sink writing user events as newline delimited JSON to an io.Writer
+type WriterSink struct {
+	mu     sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+// NewWriterSink returns a sink writing one JSON document per event to the given writer
+func NewWriterSink(writer io.Writer) *WriterSink {
+	return &WriterSink{writer: writer}
+}
+
+// NewFileSink returns a sink appending one JSON document per event to the file at path, creating it if needed
+func NewFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterSink{writer: file, closer: file}, nil
+}
+
+// Send writes the events, one per line
+func (s *WriterSink) Send(events []UserEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buffered := bufio.NewWriter(s.writer)
+	encoder := json.NewEncoder(buffered)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+// Close closes the underlying file for sinks created with NewFileSink
+func (s *WriterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
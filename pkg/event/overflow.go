@@ -0,0 +1,184 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/optimizely/go-sdk/pkg/notification"
+	"github.com/optimizely/go-sdk/pkg/registry"
+)
+
+const blockPollInterval = 10 * time.Millisecond
+
+type overflowMode int
+
+const (
+	overflowDropNewest overflowMode = iota
+	overflowDropOldest
+	overflowBlock
+	overflowSpill
+)
+
+// OverflowPolicy decides what the BatchEventProcessor does with new events when its queue is full
+type OverflowPolicy struct {
+	mode          overflowMode
+	timeout       time.Duration
+	spill         Queue
+	spillCapacity int
+}
+
+// OverflowDropNewest drops the new events, the default policy
+func OverflowDropNewest() OverflowPolicy {
+	return OverflowPolicy{mode: overflowDropNewest}
+}
+
+// OverflowDropOldest evicts the oldest queued event for each new one. The events being dispatched by an ongoing flush
+// are only reported as dropped if the dispatch fails, as they are delivered otherwise.
+func OverflowDropOldest() OverflowPolicy {
+	return OverflowPolicy{mode: overflowDropOldest}
+}
+
+// OverflowBlock flushes the queue and waits up to timeout for it to have room, dropping the new event afterwards
+func OverflowBlock(timeout time.Duration) OverflowPolicy {
+	return OverflowPolicy{mode: overflowBlock, timeout: timeout}
+}
+
+// OverflowSpill adds the new events to the secondary queue, for instance one backed by a file, while the queue is
+// full. They are moved back in order as the queue is flushed. The new events are dropped once the secondary queue
+// holds capacity events.
+func OverflowSpill(queue Queue, capacity int) OverflowPolicy {
+	return OverflowPolicy{mode: overflowSpill, spill: queue, spillCapacity: capacity}
+}
+
+// enqueue adds the event to the queue according to the overflow policy. It returns false if the event was dropped.
+func (p *BatchEventProcessor) enqueue(event UserEvent) bool {
+	switch p.overflowPolicy.mode {
+	case overflowSpill:
+		p.spillLock.Lock()
+		defer p.spillLock.Unlock()
+		// once spilling, events keep going to the secondary queue to stay in order
+		if p.overflowPolicy.spill.Size() > 0 || p.Q.Size() >= p.MaxQueueSize {
+			if p.overflowPolicy.spill.Size() >= p.overflowPolicy.spillCapacity {
+				pLogger.Warning("MaxQueueSize and the spill capacity have been met. Discarding event")
+				p.dropEvent(event, notification.SpillFull)
+				return false
+			}
+			p.overflowPolicy.spill.Add(event)
+			return true
+		}
+	case overflowDropOldest:
+		p.evictOldest()
+	case overflowBlock:
+		if !p.waitForRoom(p.overflowPolicy.timeout) {
+			pLogger.Warning("MaxQueueSize has been met for the blocking timeout. Discarding event")
+			p.dropEvent(event, notification.BlockTimeout)
+			return false
+		}
+	}
+
+	if p.Q.Size() >= p.MaxQueueSize {
+		pLogger.Warning("MaxQueueSize has been met. Discarding event")
+		p.dropEvent(event, notification.QueueFull)
+		return false
+	}
+
+	p.Q.Add(event)
+	return true
+}
+
+// evictOldest removes the oldest queued event if the queue is full. It does not wait for an ongoing flush: an evicted
+// event being dispatched is reported as dropped once the dispatch fails, and not at all if it succeeds.
+func (p *BatchEventProcessor) evictOldest() {
+	p.queueLock.Lock()
+	defer p.queueLock.Unlock()
+
+	if p.Q.Size() < p.MaxQueueSize {
+		return
+	}
+	for _, oldest := range p.Q.Remove(1) {
+		if len(p.evictedInFlight) < p.inFlight {
+			p.evictedInFlight = append(p.evictedInFlight, oldest)
+			continue
+		}
+		p.dropEvent(oldest, notification.QueueEvicted)
+	}
+}
+
+// waitForRoom flushes the queue if it is full and returns whether it has room before the timeout
+func (p *BatchEventProcessor) waitForRoom(timeout time.Duration) bool {
+	if p.Q.Size() < p.MaxQueueSize {
+		return true
+	}
+	p.startFlush()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(blockPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-deadline.C:
+			return p.Q.Size() < p.MaxQueueSize
+		case <-ticker.C:
+			if p.Q.Size() < p.MaxQueueSize {
+				return true
+			}
+		}
+	}
+}
+
+// unspill moves the spilled events back to the queue, as many as it has room for
+func (p *BatchEventProcessor) unspill() {
+	if p.overflowPolicy.spill == nil {
+		return
+	}
+
+	p.spillLock.Lock()
+	defer p.spillLock.Unlock()
+
+	count := p.MaxQueueSize - p.Q.Size()
+	if count <= 0 || p.overflowPolicy.spill.Size() == 0 {
+		return
+	}
+	items := p.overflowPolicy.spill.Get(count)
+	for _, item := range items {
+		p.Q.Add(item)
+	}
+	p.overflowPolicy.spill.Remove(len(items))
+}
+
+// dropEvent counts the dropped event and sends the EventDrop notification
+func (p *BatchEventProcessor) dropEvent(event interface{}, reason notification.EventDropReason) {
+	p.droppedCounter(reason).Add(1)
+
+	notificationCenter := registry.GetNotificationCenter(p.sdkKey)
+	dropNotification := notification.EventDropNotification{Reason: reason, Event: event}
+	if err := notificationCenter.Send(notification.EventDrop, dropNotification); err != nil {
+		pLogger.Error("Send Event Drop notification failed.", err)
+	}
+}
+
+func (p *BatchEventProcessor) droppedCounter(reason notification.EventDropReason) metrics.Counter {
+	if p.metricsRegistry == nil {
+		return &metrics.NoopCounter{}
+	}
+	return p.metricsRegistry.GetCounter(fmt.Sprintf("%s.%s", metrics.EventDropped, reason))
+}
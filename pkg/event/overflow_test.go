@@ -0,0 +1,224 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/optimizely/go-sdk/pkg/notification"
+	"github.com/optimizely/go-sdk/pkg/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+type overflowTest struct {
+	processor       *BatchEventProcessor
+	dispatcher      *MockDispatcher
+	metricsRegistry *MetricsRegistry
+	drops           chan notification.EventDropNotification
+}
+
+func newOverflowTest(t *testing.T, sdkKey string, policy OverflowPolicy) overflowTest {
+	test := overflowTest{
+		dispatcher:      NewMockDispatcher(100, true),
+		metricsRegistry: NewMetricsRegistry(),
+		drops:           make(chan notification.EventDropNotification, 10),
+	}
+	test.processor = NewBatchEventProcessor(
+		WithSDKKey(sdkKey),
+		WithQueueSize(3),
+		WithBatchSize(3),
+		WithEventDispatcher(test.dispatcher),
+		WithEventDispatcherMetrics(test.metricsRegistry),
+		WithOverflowPolicy(policy))
+
+	_, err := registry.GetNotificationCenter(sdkKey).AddHandler(notification.EventDrop, func(payload interface{}) {
+		select {
+		case test.drops <- payload.(notification.EventDropNotification):
+		default:
+		}
+	})
+	assert.NoError(t, err)
+	return test
+}
+
+// process processes the events of the given users and waits for the flushes they started
+func (test overflowTest) process(users ...string) (results []bool) {
+	for _, user := range users {
+		userEvent := BuildTestImpressionEvent()
+		userEvent.VisitorID = user
+		results = append(results, test.processor.ProcessEvent(userEvent))
+	}
+	_ = test.processor.processing.Acquire(context.Background(), 1)
+	test.processor.processing.Release(1)
+	return results
+}
+
+func (test overflowTest) dropped(reason notification.EventDropReason) float64 {
+	return test.metricsRegistry.GetCounter(fmt.Sprintf("%s.%s", metrics.EventDropped, reason)).(*MetricsCounter).Get()
+}
+
+// blockingDispatcher holds each dispatch until it is given its result
+type blockingDispatcher struct {
+	started chan LogEvent
+	results chan bool
+}
+
+func (d blockingDispatcher) DispatchEvent(event LogEvent) (bool, error) {
+	d.started <- event
+	if <-d.results {
+		return true, nil
+	}
+	return false, errors.New("failed to dispatch")
+}
+
+func queuedUsers(queue Queue) (users []string) {
+	for _, item := range queue.Get(queue.Size()) {
+		users = append(users, item.(UserEvent).VisitorID)
+	}
+	return users
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	test := newOverflowTest(t, "overflow_drop_newest", OverflowDropNewest())
+
+	assert.Equal(t, []bool{true, true, true, false}, test.process("1", "2", "3", "4"))
+	assert.Equal(t, []string{"1", "2", "3"}, queuedUsers(test.processor.Q))
+	assert.Equal(t, 1.0, test.dropped(notification.QueueFull))
+
+	drop := <-test.drops
+	assert.Equal(t, notification.QueueFull, drop.Reason)
+	assert.Equal(t, "4", drop.Event.(UserEvent).VisitorID)
+}
+
+func TestOverflowSpillWithoutQueue(t *testing.T) {
+	test := newOverflowTest(t, "overflow_spill_nil", OverflowSpill(nil, 2))
+
+	assert.Equal(t, []bool{true, true, true, false}, test.process("1", "2", "3", "4"))
+	assert.Equal(t, []string{"1", "2", "3"}, queuedUsers(test.processor.Q))
+	assert.Equal(t, 1.0, test.dropped(notification.QueueFull))
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	test := newOverflowTest(t, "overflow_drop_oldest", OverflowDropOldest())
+
+	assert.Equal(t, []bool{true, true, true, true, true}, test.process("1", "2", "3", "4", "5"))
+	assert.Equal(t, []string{"3", "4", "5"}, queuedUsers(test.processor.Q))
+	assert.Equal(t, 2.0, test.dropped(notification.QueueEvicted))
+
+	drop := <-test.drops
+	assert.Equal(t, notification.QueueEvicted, drop.Reason)
+	assert.Equal(t, "1", drop.Event.(UserEvent).VisitorID)
+}
+
+func TestOverflowDropOldestDuringDispatch(t *testing.T) {
+	for _, delivered := range []bool{true, false} {
+		test := newOverflowTest(t, fmt.Sprintf("overflow_drop_oldest_%v", delivered), OverflowDropOldest())
+		dispatcher := blockingDispatcher{started: make(chan LogEvent), results: make(chan bool)}
+		test.processor.EventDispatcher = dispatcher
+
+		for _, user := range []string{"1", "2", "3"} {
+			userEvent := BuildTestImpressionEvent()
+			userEvent.VisitorID = user
+			assert.True(t, test.processor.ProcessEvent(userEvent))
+		}
+		<-dispatcher.started
+
+		// the eviction does not wait for the dispatch
+		for _, user := range []string{"4", "5"} {
+			userEvent := BuildTestImpressionEvent()
+			userEvent.VisitorID = user
+			assert.True(t, test.processor.ProcessEvent(userEvent))
+		}
+		assert.Equal(t, []string{"3", "4", "5"}, queuedUsers(test.processor.Q))
+		assert.Equal(t, 0.0, test.dropped(notification.QueueEvicted))
+
+		dispatcher.results <- delivered
+		if delivered {
+			// the evicted events were delivered, only the remaining ones are dispatched next
+			var users []string
+			for _, visitor := range (<-dispatcher.started).Event.Visitors {
+				users = append(users, visitor.VisitorID)
+			}
+			assert.Equal(t, []string{"4", "5"}, users)
+			dispatcher.results <- true
+		}
+		_ = test.processor.processing.Acquire(context.Background(), 1)
+		test.processor.processing.Release(1)
+
+		if delivered {
+			assert.Equal(t, 0, test.processor.Q.Size())
+			assert.Equal(t, 0.0, test.dropped(notification.QueueEvicted))
+		} else {
+			assert.Equal(t, []string{"3", "4", "5"}, queuedUsers(test.processor.Q))
+			assert.Equal(t, 2.0, test.dropped(notification.QueueEvicted))
+		}
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	test := newOverflowTest(t, "overflow_block", OverflowBlock(30*time.Millisecond))
+
+	start := time.Now()
+	assert.Equal(t, []bool{true, true, true, false}, test.process("1", "2", "3", "4"))
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+	assert.Equal(t, 1.0, test.dropped(notification.BlockTimeout))
+	assert.Equal(t, notification.BlockTimeout, (<-test.drops).Reason)
+
+	// the blocked event is queued once the flush makes room
+	test.dispatcher.ShouldFail = false
+	assert.Equal(t, []bool{true}, test.process("5"))
+	assert.Equal(t, []string{"5"}, queuedUsers(test.processor.Q))
+	if assert.Equal(t, 1, test.dispatcher.Events.Size()) {
+		assert.Len(t, test.dispatcher.Events.Get(1)[0].(LogEvent).Event.Visitors, 3)
+	}
+}
+
+func TestOverflowSpill(t *testing.T) {
+	spill := NewInMemoryQueue(10)
+	test := newOverflowTest(t, "overflow_spill", OverflowSpill(spill, 2))
+
+	assert.Equal(t, []bool{true, true, true, true, true, false}, test.process("1", "2", "3", "4", "5", "6"))
+	assert.Equal(t, []string{"1", "2", "3"}, queuedUsers(test.processor.Q))
+	assert.Equal(t, []string{"4", "5"}, queuedUsers(spill))
+
+	// the events are dropped once the spill queue is full
+	assert.Equal(t, 1.0, test.dropped(notification.SpillFull))
+	drop := <-test.drops
+	assert.Equal(t, notification.SpillFull, drop.Reason)
+	assert.Equal(t, "6", drop.Event.(UserEvent).VisitorID)
+
+	// spilled events are flushed in order once the dispatch succeeds
+	test.dispatcher.ShouldFail = false
+	test.processor.flushEvents()
+	assert.Equal(t, 0, test.processor.Q.Size())
+	assert.Equal(t, 0, spill.Size())
+
+	var users []string
+	for _, item := range test.dispatcher.Events.Get(test.dispatcher.Events.Size()) {
+		for _, visitor := range item.(LogEvent).Event.Visitors {
+			users = append(users, visitor.VisitorID)
+		}
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, users)
+	assert.Empty(t, test.drops)
+}
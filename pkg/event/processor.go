@@ -19,8 +19,10 @@ package event
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,6 +32,7 @@ import (
 	"github.com/optimizely/go-sdk/pkg/metrics"
 	"github.com/optimizely/go-sdk/pkg/notification"
 	"github.com/optimizely/go-sdk/pkg/registry"
+	"github.com/optimizely/go-sdk/pkg/utils"
 )
 
 // Processor processes events
@@ -46,13 +49,29 @@ type BatchEventProcessor struct {
 	MaxQueueSize    int           // max size of the queue before flush
 	FlushInterval   time.Duration // in milliseconds
 	BatchSize       int
+	MaxPayloadBytes int // max serialized size of a batch, no limit if zero
 	Q               Queue
 	flushLock       sync.Mutex
+	queueLock       sync.Mutex // guards the events in flight against their eviction
+	inFlight        int
+	evictedInFlight []interface{}
+	overflowPolicy  OverflowPolicy
+	spillLock       sync.Mutex
 	Ticker          *time.Ticker
 	EventDispatcher Dispatcher
+	eventFactory    *Factory
 	processing      *semaphore.Weighted
+	sinks           []*sinkRunner
 
-	metricsRegistry metrics.Registry
+	compressionThreshold int
+
+	impressionCache     *utils.LRUCache
+	deduplicatedCounter metrics.Counter
+	sampler             *impressionSampler
+	sampledOutCounter   metrics.Counter
+	impressionLimiter   *utils.TokenBucket
+	rateLimitedCounter  metrics.Counter
+	metricsRegistry     metrics.Registry
 }
 
 // DefaultBatchSize holds the default value for the batch size
@@ -78,6 +97,14 @@ func WithBatchSize(bsize int) BPOptionConfig {
 	}
 }
 
+// WithMaxPayloadBytes sets the max serialized size of a batch as a config option to be passed into the NewProcessor method.
+// A batch is sent early when adding the next event would exceed it.
+func WithMaxPayloadBytes(maxBytes int) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		qp.MaxPayloadBytes = maxBytes
+	}
+}
+
 // WithQueueSize sets the queue size as a config option to be passed into the NewProcessor method
 func WithQueueSize(qsize int) BPOptionConfig {
 	return func(qp *BatchEventProcessor) {
@@ -106,6 +133,50 @@ func WithEventDispatcher(d Dispatcher) BPOptionConfig {
 	}
 }
 
+// WithImpressionDeduplication drops the impressions of a user for an experiment and variation already queued within
+// the window, remembering the impressions of at most capacity such combinations
+func WithImpressionDeduplication(capacity int, window time.Duration) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		qp.impressionCache = utils.NewLRUCache(capacity, window)
+	}
+}
+
+// WithImpressionSampling keeps the impressions of a fraction of the users of the experiments, keyed by ID, with a
+// sampling rate between 0 and 1. Users are sampled by a salted hash of their bucketing ID, independent of their
+// variation, and the impressions kept report the rate in the SamplingRateAttribute attribute.
+func WithImpressionSampling(rates map[string]float64) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		qp.sampler = newImpressionSampler(rates)
+	}
+}
+
+// WithImpressionRateLimit drops the impressions exceeding perSecond impressions per second, in bursts of up to burst
+// impressions
+func WithImpressionRateLimit(perSecond float64, burst int) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		qp.impressionLimiter = utils.NewTokenBucket(perSecond, burst)
+	}
+}
+
+// WithOverflowPolicy sets what ProcessEvent does with new events when the queue is full, OverflowDropNewest by default.
+// A spill policy without a secondary queue falls back to OverflowDropNewest.
+func WithOverflowPolicy(policy OverflowPolicy) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		if policy.mode == overflowSpill && policy.spill == nil {
+			pLogger.Warning("Spill overflow policy has no secondary queue. Dropping the new events instead")
+			policy = OverflowDropNewest()
+		}
+		qp.overflowPolicy = policy
+	}
+}
+
+// WithEventFactory sets the factory creating the visitors of the batches, to control their timestamps and UUIDs
+func WithEventFactory(eventFactory *Factory) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		qp.eventFactory = eventFactory
+	}
+}
+
 // WithSDKKey sets the SDKKey used to register for notifications.  This should be removed when the project
 // config supports sdk key.
 func WithSDKKey(sdkKey string) BPOptionConfig {
@@ -121,6 +192,14 @@ func WithEventDispatcherMetrics(metricsRegistry metrics.Registry) BPOptionConfig
 	}
 }
 
+// WithCompressionThreshold gzips the payloads of at least minBytes bytes sent by the default event dispatcher.
+// It has no effect when a custom dispatcher is set.
+func WithCompressionThreshold(minBytes int) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		qp.compressionThreshold = minBytes
+	}
+}
+
 // NewBatchEventProcessor returns a new instance of BatchEventProcessor with queueSize and flushInterval
 func NewBatchEventProcessor(options ...BPOptionConfig) *BatchEventProcessor {
 	p := &BatchEventProcessor{processing: semaphore.NewWeighted(int64(maxFlushWorkers))}
@@ -154,6 +233,18 @@ func NewBatchEventProcessor(options ...BPOptionConfig) *BatchEventProcessor {
 		p.Q = NewInMemoryQueue(p.MaxQueueSize)
 	}
 
+	for _, sink := range p.sinks {
+		sink.init(p.metricsRegistry)
+	}
+
+	metricsRegistry := p.metricsRegistry
+	if metricsRegistry == nil {
+		metricsRegistry = metrics.NewNoopRegistry()
+	}
+	p.deduplicatedCounter = metricsRegistry.GetCounter(metrics.EventImpressionDeduplicated)
+	p.sampledOutCounter = metricsRegistry.GetCounter(metrics.EventImpressionSampledOut)
+	p.rateLimitedCounter = metricsRegistry.GetCounter(metrics.EventImpressionRateLimited)
+
 	return p
 }
 
@@ -161,41 +252,105 @@ func NewBatchEventProcessor(options ...BPOptionConfig) *BatchEventProcessor {
 func (p *BatchEventProcessor) Start(ctx context.Context) {
 	if p.EventDispatcher == nil {
 		dispatcher := NewQueueEventDispatcher(p.metricsRegistry)
+		if p.compressionThreshold > 0 {
+			dispatcher.Dispatcher = NewHTTPEventDispatcher(utils.NewHTTPRequester(utils.GzipThreshold(p.compressionThreshold)))
+		}
 		defer dispatcher.flushEvents()
 		p.EventDispatcher = dispatcher
 	}
 
+	var sinksWg sync.WaitGroup
+	for _, sink := range p.sinks {
+		sinksWg.Add(1)
+		go sink.run(ctx, &sinksWg)
+	}
+
 	pLogger.Debug("Batch event processor started")
 	p.startTicker(ctx)
+	sinksWg.Wait()
 }
 
 // ProcessEvent takes the given user event (can be an impression or conversion event) and queues it up to be dispatched
 // to the Optimizely log endpoint. A dispatch happens when we flush the events, which can happen on a set interval or
-// when the specified batch size (defaulted to 10) is reached.
+// when the specified batch size (defaulted to 10) is reached. A copy of the event is also offered to every event sink.
+// Impressions dropped as duplicates or by sampling reach neither the queue nor the sinks, and true is returned. Those
+// dropped by the impression rate limit, like the new events dropped by the overflow policy, make it return false.
 func (p *BatchEventProcessor) ProcessEvent(event UserEvent) bool {
 
-	if p.Q.Size() >= p.MaxQueueSize {
-		pLogger.Warning("MaxQueueSize has been met. Discarding event")
+	if !p.reserveImpression(event) {
+		pLogger.Debug(fmt.Sprintf(`Dropping duplicate impression of user "%s" for experiment "%s"`,
+			event.VisitorID, event.Impression.ExperimentID))
+		p.deduplicatedCounter.Add(1)
+		return true
+	}
+
+	if p.sampler != nil {
+		var sampled bool
+		if event, sampled = p.sampler.sample(event); !sampled {
+			p.releaseImpression(event)
+			p.sampledOutCounter.Add(1)
+			return true
+		}
+	}
+
+	if event.Impression != nil && p.impressionLimiter != nil && !p.impressionLimiter.Allow() {
+		pLogger.Debug("Impression rate limit has been met. Discarding impression")
+		p.releaseImpression(event)
+		p.rateLimitedCounter.Add(1)
 		return false
 	}
 
-	p.Q.Add(event)
+	for _, sink := range p.sinks {
+		sink.offer(event)
+	}
+
+	if !p.enqueue(event) {
+		p.releaseImpression(event)
+		return false
+	}
 
 	if p.Q.Size() < p.BatchSize {
 		return true
 	}
 
+	pLogger.Debug("batch size reached.  Flushing routine being called")
+	p.startFlush()
+
+	return true
+}
+
+// startFlush flushes the events in a new go routine, unless one is already flushing
+func (p *BatchEventProcessor) startFlush() {
 	if p.processing.TryAcquire(1) {
 		// it doesn't matter if the timer has kicked in here.
 		// we just want to start one go routine when the batch size is met.
-		pLogger.Debug("batch size reached.  Flushing routine being called")
 		go func() {
 			p.flushEvents()
 			p.processing.Release(1)
 		}()
 	}
+}
 
-	return true
+// reserveImpression atomically starts the deduplication window of the impression, and returns false if the same
+// impression was already queued, or is being queued concurrently, within the window
+func (p *BatchEventProcessor) reserveImpression(event UserEvent) bool {
+	if p.impressionCache == nil || event.Impression == nil {
+		return true
+	}
+	return p.impressionCache.Add(dedupKey(event), true)
+}
+
+// releaseImpression ends the deduplication window of an impression dropped on the way to the queue, so that the
+// next identical impression is still sent
+func (p *BatchEventProcessor) releaseImpression(event UserEvent) {
+	if p.impressionCache == nil || event.Impression == nil {
+		return
+	}
+	p.impressionCache.Remove(dedupKey(event))
+}
+
+func dedupKey(event UserEvent) string {
+	return strings.Join([]string{event.VisitorID, event.Impression.ExperimentID, event.Impression.VariationID}, "\x00")
 }
 
 // eventsCount returns size of an event queue
@@ -203,14 +358,33 @@ func (p *BatchEventProcessor) eventsCount() int {
 	return p.Q.Size()
 }
 
-// getEvents returns events from event queue for count
+// getEvents returns events from event queue for count, which are in flight until the dispatch is done
 func (p *BatchEventProcessor) getEvents(count int) []interface{} {
-	return p.Q.Get(count)
+	p.queueLock.Lock()
+	defer p.queueLock.Unlock()
+	events := p.Q.Get(count)
+	p.inFlight = len(events)
+	return events
 }
 
-// remove removes events from queue for count
-func (p *BatchEventProcessor) remove(count int) []interface{} {
-	return p.Q.Remove(count)
+// dispatchDone removes the count events dispatched from the queue, less the ones evicted meanwhile. The evicted events
+// which were not dispatched are dropped.
+func (p *BatchEventProcessor) dispatchDone(count int) {
+	p.queueLock.Lock()
+	evicted := p.evictedInFlight
+	p.evictedInFlight = nil
+	p.inFlight = 0
+	if count > len(evicted) {
+		p.Q.Remove(count - len(evicted))
+	}
+	p.queueLock.Unlock()
+
+	if count < len(evicted) {
+		for _, event := range evicted[count:] {
+			p.dropEvent(event, notification.QueueEvicted)
+		}
+	}
+	p.unspill()
 }
 
 // StartTicker starts new ticker for flushing events
@@ -252,15 +426,27 @@ func (p *BatchEventProcessor) addToBatch(current *Batch, visitor Visitor) {
 	current.Visitors = visitors
 }
 
+// payloadSize returns the serialized size of v, used to keep batches under MaxPayloadBytes
+func payloadSize(v interface{}) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
 // flushEvents flushes events in queue
 func (p *BatchEventProcessor) flushEvents() {
 	// we flush when queue size is reached.
 	// however, if there is a ticker cycle already processing, we should wait
 	p.flushLock.Lock()
 	defer p.flushLock.Unlock()
+	p.unspill()
 
 	var batchEvent Batch
 	var batchEventCount = 0
+	var batchBytes = 0
+	var batchLimit = p.BatchSize
 	var failedToSend = false
 
 	for p.eventsCount() > 0 {
@@ -268,27 +454,39 @@ func (p *BatchEventProcessor) flushEvents() {
 			pLogger.Error("last Event Batch failed to send; retry on next flush", errors.New("dispatcher failed"))
 			break
 		}
-		events := p.getEvents(p.BatchSize)
+		events := p.getEvents(batchLimit)
 
 		if len(events) > 0 {
 			for i := 0; i < len(events); i++ {
 				userEvent, ok := events[i].(UserEvent)
 				if ok {
 					if batchEventCount == 0 {
-						batchEvent = createBatchEvent(userEvent, createVisitorFromUserEvent(userEvent))
+						batchEvent = createBatchEvent(userEvent, p.eventFactory.createVisitorFromUserEvent(userEvent))
 						batchEventCount = 1
+						if p.MaxPayloadBytes > 0 {
+							batchBytes = payloadSize(batchEvent)
+						}
 					} else {
 						if !p.canBatch(&batchEvent, userEvent) {
 							// this could happen if the project config was updated for instance.
 							pLogger.Info("Can't batch last event. Sending current batch.")
 							break
-						} else {
-							p.addToBatch(&batchEvent, createVisitorFromUserEvent(userEvent))
-							batchEventCount++
 						}
+						visitor := p.eventFactory.createVisitorFromUserEvent(userEvent)
+						if p.MaxPayloadBytes > 0 {
+							// account for the separating comma
+							visitorBytes := payloadSize(visitor) + 1
+							if batchBytes+visitorBytes > p.MaxPayloadBytes {
+								pLogger.Debug("Max payload size reached. Sending current batch.")
+								break
+							}
+							batchBytes += visitorBytes
+						}
+						p.addToBatch(&batchEvent, visitor)
+						batchEventCount++
 					}
 
-					if batchEventCount >= p.BatchSize {
+					if batchEventCount >= batchLimit {
 						// the batch size is reached so take the current batchEvent and send it.
 						break
 					}
@@ -296,7 +494,6 @@ func (p *BatchEventProcessor) flushEvents() {
 			}
 		}
 		if batchEventCount > 0 {
-			// TODO: figure out what to do with the error
 			logEvent := createLogEvent(batchEvent)
 			notificationCenter := registry.GetNotificationCenter(p.sdkKey)
 
@@ -305,15 +502,28 @@ func (p *BatchEventProcessor) flushEvents() {
 			if err != nil {
 				pLogger.Error("Send Log Event notification failed.", err)
 			}
-			if success, _ := p.EventDispatcher.DispatchEvent(logEvent); success {
+			success, err := p.EventDispatcher.DispatchEvent(logEvent)
+			switch {
+			case success:
 				pLogger.Debug("Dispatched event successfully")
-				p.remove(batchEventCount)
-				batchEventCount = 0
-				batchEvent = Batch{}
-			} else {
+				p.dispatchDone(batchEventCount)
+				batchLimit = p.BatchSize
+			case err == ErrPayloadTooLarge && batchEventCount > 1:
+				// bisect the batch and retry instead of blocking the queue, the limit is reset after the next success
+				batchLimit = batchEventCount / 2
+				pLogger.Warning(fmt.Sprintf("Batch of %d events is too large. Retrying with %d events", batchEventCount, batchLimit))
+				p.dispatchDone(0)
+			case err == ErrPayloadTooLarge:
+				pLogger.Error("Event is too large to be dispatched. Discarding event", err)
+				p.dispatchDone(batchEventCount)
+			default:
 				pLogger.Warning("Failed to dispatch event successfully")
 				failedToSend = true
+				p.dispatchDone(0)
 			}
+			batchEventCount = 0
+			batchBytes = 0
+			batchEvent = Batch{}
 		}
 	}
 }
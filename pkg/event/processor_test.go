@@ -22,9 +22,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/metrics"
 	"github.com/optimizely/go-sdk/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"math"
+	"sync"
 	"testing"
 	"time"
 )
@@ -566,3 +568,222 @@ func benchmarkProcessor(q Queue, bSize int, b *testing.B) {
 		b.Fail()
 	}
 }
+
+// SizeLimitedDispatcher rejects batches with more than maxVisitors visitors as too large
+type SizeLimitedDispatcher struct {
+	maxVisitors int
+	Events      []LogEvent
+}
+
+func (s *SizeLimitedDispatcher) DispatchEvent(event LogEvent) (bool, error) {
+	if len(event.Event.Visitors) > s.maxVisitors {
+		return false, ErrPayloadTooLarge
+	}
+	s.Events = append(s.Events, event)
+	return true, nil
+}
+
+func TestDefaultEventProcessor_BisectsTooLargeBatches(t *testing.T) {
+	dispatcher := &SizeLimitedDispatcher{maxVisitors: 2}
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(dispatcher),
+		WithBatchSize(10))
+
+	for i := 0; i < 10; i++ {
+		processor.Q.Add(BuildTestImpressionEvent())
+	}
+	processor.flushEvents()
+
+	assert.Equal(t, 0, processor.eventsCount())
+	visitors := 0
+	for _, logEvent := range dispatcher.Events {
+		assert.True(t, len(logEvent.Event.Visitors) <= 2)
+		visitors += len(logEvent.Event.Visitors)
+	}
+	assert.Equal(t, 10, visitors)
+}
+
+// TooLargeOnceDispatcher rejects the first batch it receives as too large
+type TooLargeOnceDispatcher struct {
+	rejected bool
+	Events   []LogEvent
+}
+
+func (d *TooLargeOnceDispatcher) DispatchEvent(event LogEvent) (bool, error) {
+	if !d.rejected {
+		d.rejected = true
+		return false, ErrPayloadTooLarge
+	}
+	d.Events = append(d.Events, event)
+	return true, nil
+}
+
+func TestDefaultEventProcessor_ResetsBatchLimitAfterSuccess(t *testing.T) {
+	dispatcher := &TooLargeOnceDispatcher{}
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(dispatcher),
+		WithBatchSize(4))
+
+	for i := 0; i < 10; i++ {
+		processor.Q.Add(BuildTestImpressionEvent())
+	}
+	processor.flushEvents()
+
+	assert.Equal(t, 0, processor.eventsCount())
+	var sizes []int
+	for _, logEvent := range dispatcher.Events {
+		sizes = append(sizes, len(logEvent.Event.Visitors))
+	}
+	assert.Equal(t, []int{2, 4, 4}, sizes)
+}
+
+func TestDefaultEventProcessor_DropsSingleTooLargeEvent(t *testing.T) {
+	dispatcher := &SizeLimitedDispatcher{maxVisitors: 0}
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(dispatcher),
+		WithBatchSize(10))
+
+	for i := 0; i < 3; i++ {
+		processor.Q.Add(BuildTestImpressionEvent())
+	}
+	processor.flushEvents()
+
+	// the flush does not stop on the rejected events
+	assert.Equal(t, 0, processor.eventsCount())
+	assert.Empty(t, dispatcher.Events)
+}
+
+func TestDefaultEventProcessor_MaxPayloadBytes(t *testing.T) {
+	impression := BuildTestImpressionEvent()
+	batchBytes := payloadSize(createBatchEvent(impression, createVisitorFromUserEvent(impression)))
+	visitorBytes := payloadSize(createVisitorFromUserEvent(impression)) + 1
+
+	dispatcher := NewMockDispatcher(100, false)
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(dispatcher),
+		WithBatchSize(10),
+		WithMaxPayloadBytes(batchBytes+2*visitorBytes))
+
+	for i := 0; i < 10; i++ {
+		processor.Q.Add(impression)
+	}
+	processor.flushEvents()
+
+	assert.Equal(t, 0, processor.eventsCount())
+	assert.Equal(t, 4, dispatcher.Events.Size())
+	for _, item := range dispatcher.Events.Get(4) {
+		logEvent := item.(LogEvent)
+		assert.True(t, len(logEvent.Event.Visitors) <= 3)
+		assert.True(t, payloadSize(logEvent.Event) <= batchBytes+2*visitorBytes)
+	}
+}
+
+func TestDefaultEventProcessor_DeduplicatesImpressions(t *testing.T) {
+	metricsRegistry := NewMetricsRegistry()
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventDispatcherMetrics(metricsRegistry),
+		WithImpressionDeduplication(10, 50*time.Millisecond))
+
+	impression := BuildTestImpressionEvent()
+	otherVariation := BuildTestImpressionEvent()
+	otherVariation.Impression.VariationID = "15410990634"
+
+	assert.True(t, processor.ProcessEvent(impression))
+	assert.True(t, processor.ProcessEvent(BuildTestImpressionEvent()))
+	assert.True(t, processor.ProcessEvent(otherVariation))
+	assert.True(t, processor.ProcessEvent(BuildTestConversionEvent()))
+	assert.True(t, processor.ProcessEvent(BuildTestConversionEvent()))
+	assert.Equal(t, 4, processor.eventsCount())
+	assert.Equal(t, 1.0, metricsRegistry.GetCounter(metrics.EventImpressionDeduplicated).(*MetricsCounter).Get())
+
+	// the impression is sent again once the window is over
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, processor.ProcessEvent(impression))
+	assert.Equal(t, 5, processor.eventsCount())
+}
+
+func TestDefaultEventProcessor_DeduplicatesOnlyQueuedImpressions(t *testing.T) {
+	metricsRegistry := NewMetricsRegistry()
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventDispatcherMetrics(metricsRegistry),
+		WithImpressionDeduplication(10, time.Minute),
+		WithImpressionRateLimit(0.001, 1))
+
+	other := BuildTestImpressionEvent()
+	other.VisitorID = "other"
+	assert.True(t, processor.ProcessEvent(other))
+
+	// the rate limited impression does not block the identical impressions
+	impression := BuildTestImpressionEvent()
+	assert.False(t, processor.ProcessEvent(impression))
+	processor.impressionLimiter = nil
+	assert.True(t, processor.ProcessEvent(impression))
+	assert.True(t, processor.ProcessEvent(impression))
+	assert.Equal(t, 2, processor.eventsCount())
+	assert.Equal(t, 1.0, metricsRegistry.GetCounter(metrics.EventImpressionDeduplicated).(*MetricsCounter).Get())
+}
+
+func TestDefaultEventProcessor_DeduplicatesConcurrentImpressions(t *testing.T) {
+	metricsRegistry := NewMetricsRegistry()
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventDispatcherMetrics(metricsRegistry),
+		WithImpressionDeduplication(10, time.Minute))
+
+	impression := BuildTestImpressionEvent()
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			assert.True(t, processor.ProcessEvent(impression))
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, 1, processor.eventsCount())
+	assert.Equal(t, 19.0, metricsRegistry.GetCounter(metrics.EventImpressionDeduplicated).(*MetricsCounter).Get())
+}
+
+func TestDefaultEventProcessor_ImpressionUUIDIsStable(t *testing.T) {
+	impression := BuildTestImpressionEvent()
+	first := createVisitorFromUserEvent(impression)
+	second := createVisitorFromUserEvent(impression)
+
+	assert.Equal(t, impression.UUID, first.Snapshots[0].Events[0].UUID)
+	assert.Equal(t, first.Snapshots[0].Events[0].UUID, second.Snapshots[0].Events[0].UUID)
+}
+
+func TestDefaultEventProcessor_SamplesImpressions(t *testing.T) {
+	metricsRegistry := NewMetricsRegistry()
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventDispatcherMetrics(metricsRegistry),
+		WithImpressionSampling(map[string]float64{"15402980349": 0}))
+
+	assert.True(t, processor.ProcessEvent(BuildTestImpressionEvent()))
+	assert.True(t, processor.ProcessEvent(BuildTestConversionEvent()))
+	assert.Equal(t, 1, processor.eventsCount())
+	assert.Equal(t, 1.0, metricsRegistry.GetCounter(metrics.EventImpressionSampledOut).(*MetricsCounter).Get())
+}
+
+func TestDefaultEventProcessor_RateLimitsImpressions(t *testing.T) {
+	metricsRegistry := NewMetricsRegistry()
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventDispatcherMetrics(metricsRegistry),
+		WithImpressionRateLimit(0.001, 2))
+
+	assert.True(t, processor.ProcessEvent(BuildTestImpressionEvent()))
+	assert.True(t, processor.ProcessEvent(BuildTestImpressionEvent()))
+	assert.False(t, processor.ProcessEvent(BuildTestImpressionEvent()))
+	// conversions are not limited
+	assert.True(t, processor.ProcessEvent(BuildTestConversionEvent()))
+	assert.Equal(t, 3, processor.eventsCount())
+	assert.Equal(t, 1.0, metricsRegistry.GetCounter(metrics.EventImpressionRateLimited).(*MetricsCounter).Get())
+}
@@ -0,0 +1,216 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/optimizely/go-sdk/pkg/utils"
+)
+
+// DefaultReplayRate is the default number of log events replayed per second
+const DefaultReplayRate = 10
+
+var errDispatchFailed = errors.New("dispatch failed")
+
+// WriteLogEvents writes the log events as newline delimited JSON, the format replayed by the Replayer
+func WriteLogEvents(writer io.Writer, events ...LogEvent) error {
+	buffered := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(buffered)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+// ReplayResult is the outcome of replaying one captured log event
+type ReplayResult struct {
+	Line       int    `json:"line"`
+	EndPoint   string `json:"endpoint,omitempty"`
+	Events     int    `json:"events"`
+	Duplicates int    `json:"duplicates"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ReplayReport sums up the replay of captured log events
+type ReplayReport struct {
+	Dispatched int            `json:"dispatched"` // log events dispatched
+	Failed     int            `json:"failed"`     // log events that could not be read or dispatched
+	Skipped    int            `json:"skipped"`    // log events holding only already replayed snapshot events
+	Duplicates int            `json:"duplicates"` // snapshot events dropped as already replayed
+	Results    []ReplayResult `json:"results"`
+}
+
+// Replayer re-dispatches captured log events at a limited rate, dropping the snapshot events whose UUID it has
+// already dispatched
+type Replayer struct {
+	dispatcher Dispatcher
+	limiter    *utils.TokenBucket
+	replayed   map[string]bool
+}
+
+// ReplayOptionFunc is used to provide custom replay configuration to the Replayer.
+type ReplayOptionFunc func(*Replayer)
+
+// WithReplayRate limits the replay to perSecond log events per second, in bursts of up to burst log events.
+// A rate of zero or less disables the limit.
+func WithReplayRate(perSecond float64, burst int) ReplayOptionFunc {
+	return func(r *Replayer) {
+		r.limiter = utils.NewTokenBucket(perSecond, burst)
+	}
+}
+
+// NewReplayer returns a replayer dispatching with the given dispatcher, a default HTTPEventDispatcher if nil
+func NewReplayer(dispatcher Dispatcher, options ...ReplayOptionFunc) *Replayer {
+	if dispatcher == nil {
+		dispatcher = NewHTTPEventDispatcher(nil)
+	}
+	r := &Replayer{
+		dispatcher: dispatcher,
+		limiter:    utils.NewTokenBucket(DefaultReplayRate, 1),
+		replayed:   map[string]bool{},
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// Replay dispatches the newline delimited JSON log events read from reader. Lines that can't be decoded or
+// dispatched are reported as failed and the replay goes on. An error is returned, along with the report so far,
+// if reading fails or the context is done.
+func (r *Replayer) Replay(ctx context.Context, reader io.Reader) (ReplayReport, error) {
+	report := ReplayReport{Results: []ReplayResult{}}
+	buffered := bufio.NewReader(reader)
+
+	for line := 1; ; line++ {
+		data, readErr := buffered.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return report, readErr
+		}
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			result, err := r.replayLine(ctx, line, data)
+			if err != nil {
+				return report, err
+			}
+
+			report.Results = append(report.Results, result)
+			report.Duplicates += result.Duplicates
+			switch {
+			case !result.Success:
+				report.Failed++
+			case result.Events == 0:
+				report.Skipped++
+			default:
+				report.Dispatched++
+			}
+		}
+
+		if readErr == io.EOF {
+			return report, nil
+		}
+	}
+}
+
+func (r *Replayer) replayLine(ctx context.Context, line int, data []byte) (ReplayResult, error) {
+	result := ReplayResult{Line: line}
+
+	var event LogEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		result.Error = fmt.Sprintf("invalid log event: %s", err.Error())
+		return result, nil
+	}
+	result.EndPoint = event.EndPoint
+
+	event, uuids, duplicates := r.dedupe(event)
+	result.Duplicates = duplicates
+	for _, visitor := range event.Event.Visitors {
+		for _, snapshot := range visitor.Snapshots {
+			result.Events += len(snapshot.Events)
+		}
+	}
+	if result.Events == 0 {
+		result.Success = true
+		return result, nil
+	}
+
+	if err := r.limiter.Wait(ctx); err != nil {
+		return result, err
+	}
+
+	success, err := r.dispatcher.DispatchEvent(event)
+	if err == nil && !success {
+		err = errDispatchFailed
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	result.Success = true
+	for _, uuid := range uuids {
+		r.replayed[uuid] = true
+	}
+	return result, nil
+}
+
+// dedupe drops the snapshot events already replayed, or repeated within the log event, along with the snapshots
+// and visitors left empty. It returns the UUIDs of the remaining snapshot events and the number dropped.
+func (r *Replayer) dedupe(event LogEvent) (deduped LogEvent, uuids []string, duplicates int) {
+	seen := map[string]bool{}
+	visitors := []Visitor{}
+	for _, visitor := range event.Event.Visitors {
+		snapshots := []Snapshot{}
+		for _, snapshot := range visitor.Snapshots {
+			events := []SnapshotEvent{}
+			for _, snapshotEvent := range snapshot.Events {
+				if snapshotEvent.UUID != "" {
+					if r.replayed[snapshotEvent.UUID] || seen[snapshotEvent.UUID] {
+						duplicates++
+						continue
+					}
+					seen[snapshotEvent.UUID] = true
+					uuids = append(uuids, snapshotEvent.UUID)
+				}
+				events = append(events, snapshotEvent)
+			}
+			if len(events) > 0 {
+				snapshot.Events = events
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+		if len(snapshots) > 0 {
+			visitor.Snapshots = snapshots
+			visitors = append(visitors, visitor)
+		}
+	}
+
+	deduped = event
+	deduped.Event.Visitors = visitors
+	return deduped, uuids, duplicates
+}
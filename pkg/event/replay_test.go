@@ -0,0 +1,142 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func replayLogEvent(endPoint string, visitorID string, uuids ...string) LogEvent {
+	snapshot := Snapshot{}
+	for _, uuid := range uuids {
+		snapshot.Events = append(snapshot.Events, SnapshotEvent{EntityID: "1", Key: "purchase", Timestamp: 1, UUID: uuid})
+	}
+	visitor := Visitor{VisitorID: visitorID, Attributes: []VisitorAttribute{}, Snapshots: []Snapshot{snapshot}}
+	return LogEvent{EndPoint: endPoint, Event: Batch{ProjectID: "1", Revision: "2", Visitors: []Visitor{visitor}}}
+}
+
+type toggleDispatcher struct {
+	fail   bool
+	events []LogEvent
+}
+
+func (d *toggleDispatcher) DispatchEvent(event LogEvent) (bool, error) {
+	if d.fail {
+		return false, nil
+	}
+	d.events = append(d.events, event)
+	return true, nil
+}
+
+func TestReplayDeduplicatesSnapshotEvents(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteLogEvents(&buffer,
+		replayLogEvent("endpoint", "a", "uuid-1", "uuid-2", "uuid-1"),
+		replayLogEvent("endpoint", "b", "uuid-2", "uuid-3"),
+	))
+	buffer.WriteString("\n{not json\n")
+	assert.NoError(t, WriteLogEvents(&buffer, replayLogEvent("endpoint", "c", "uuid-3")))
+
+	dispatcher := &toggleDispatcher{}
+	report, err := NewReplayer(dispatcher, WithReplayRate(0, 1)).Replay(context.Background(), &buffer)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, report.Dispatched)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 3, report.Duplicates)
+	assert.Equal(t, []int{1, 2, 4, 5}, []int{report.Results[0].Line, report.Results[1].Line, report.Results[2].Line, report.Results[3].Line})
+	assert.Contains(t, report.Results[2].Error, "invalid log event")
+	assert.Equal(t, ReplayResult{Line: 5, EndPoint: "endpoint", Duplicates: 1, Success: true}, report.Results[3])
+
+	if assert.Len(t, dispatcher.events, 2) {
+		assert.Equal(t, replayLogEvent("endpoint", "a", "uuid-1", "uuid-2"), dispatcher.events[0])
+		assert.Equal(t, replayLogEvent("endpoint", "b", "uuid-3"), dispatcher.events[1])
+	}
+}
+
+func TestReplayRetriesFailedEvents(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteLogEvents(&buffer, replayLogEvent("endpoint", "a", "uuid-1")))
+	captured := buffer.String()
+
+	dispatcher := &toggleDispatcher{fail: true}
+	replayer := NewReplayer(dispatcher, WithReplayRate(0, 1))
+	report, err := replayer.Replay(context.Background(), strings.NewReader(captured))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, errDispatchFailed.Error(), report.Results[0].Error)
+
+	// failed snapshot events are not considered replayed
+	dispatcher.fail = false
+	report, err = replayer.Replay(context.Background(), strings.NewReader(captured))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Dispatched)
+	assert.Len(t, dispatcher.events, 1)
+}
+
+func TestReplayIsRateLimited(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteLogEvents(&buffer,
+		replayLogEvent("endpoint", "a", "uuid-1"),
+		replayLogEvent("endpoint", "b", "uuid-2"),
+	))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	dispatcher := &toggleDispatcher{}
+	report, err := NewReplayer(dispatcher, WithReplayRate(0.001, 1)).Replay(ctx, &buffer)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, report.Dispatched)
+	assert.Len(t, dispatcher.events, 1)
+}
+
+func TestReplayThroughHTTPEventDispatcher(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteLogEvents(&buffer,
+		replayLogEvent(server.URL, "a", "uuid-1"),
+		replayLogEvent(server.URL, "b", "uuid-2"),
+	))
+
+	report, err := NewReplayer(nil, WithReplayRate(0, 1)).Replay(context.Background(), &buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Dispatched)
+	assert.Equal(t, 1, report.Failed)
+	assert.True(t, report.Results[0].Success)
+	assert.False(t, report.Results[1].Success)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,91 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"github.com/optimizely/go-sdk/pkg/decision/bucketer"
+)
+
+// SamplingRateAttribute is the attribute reporting the sampling rate of sampled impressions, so that the analysis can
+// re-weight them
+const SamplingRateAttribute = "$opt_sampling_rate"
+
+const maxSamplingValue = 10000
+
+// samplingSalt keeps the sampling independent of the bucketing, which hashes the same IDs with the same seed
+const samplingSalt = "sampling:"
+
+const bucketingIDKey = "$opt_bucketing_id"
+
+// impressionSampler keeps the impressions of a deterministic fraction of the users, per experiment
+type impressionSampler struct {
+	rates    map[string]float64
+	bucketer *bucketer.MurmurhashBucketer
+}
+
+func newImpressionSampler(rates map[string]float64) *impressionSampler {
+	sampler := &impressionSampler{
+		rates:    make(map[string]float64, len(rates)),
+		bucketer: bucketer.NewMurmurhashBucketer(bucketer.DefaultHashSeed),
+	}
+	for experimentID, rate := range rates {
+		sampler.rates[experimentID] = rate
+	}
+	return sampler
+}
+
+// sample returns whether the impression is kept, along with the impression reporting its sampling rate.
+// Conversions and the impressions of the experiments without a sampling rate below 1 are always kept unchanged.
+func (s *impressionSampler) sample(event UserEvent) (UserEvent, bool) {
+	if event.Impression == nil {
+		return event, true
+	}
+	rate, ok := s.rates[event.Impression.ExperimentID]
+	if !ok || rate >= 1 {
+		return event, true
+	}
+	if rate <= 0 {
+		return event, false
+	}
+	samplingKey := samplingSalt + samplingID(event) + event.Impression.ExperimentID
+	if float64(s.bucketer.Generate(samplingKey)) >= rate*maxSamplingValue {
+		return event, false
+	}
+
+	impression := *event.Impression
+	impression.Attributes = make([]VisitorAttribute, len(event.Impression.Attributes), len(event.Impression.Attributes)+1)
+	copy(impression.Attributes, event.Impression.Attributes)
+	impression.Attributes = append(impression.Attributes, VisitorAttribute{
+		Value:         rate,
+		Key:           SamplingRateAttribute,
+		AttributeType: attributeType,
+		EntityID:      SamplingRateAttribute,
+	})
+	event.Impression = &impression
+	return event, true
+}
+
+// samplingID returns the bucketing ID of the user of the impression if set, the visitor ID otherwise
+func samplingID(event UserEvent) string {
+	for _, attribute := range event.Impression.Attributes {
+		if bucketingID, ok := attribute.Value.(string); ok && attribute.Key == bucketingIDKey {
+			return bucketingID
+		}
+	}
+	return event.VisitorID
+}
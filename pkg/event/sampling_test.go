@@ -0,0 +1,111 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/optimizely/go-sdk/pkg/decision/bucketer"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpressionSamplerKeepsAFractionOfTheUsers(t *testing.T) {
+	sampler := newImpressionSampler(map[string]float64{"15402980349": 0.3})
+
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		impression := BuildTestImpressionEvent()
+		impression.VisitorID = fmt.Sprintf("user_%d", i)
+		sampled, ok := sampler.sample(impression)
+
+		// users are sampled deterministically
+		_, again := sampler.sample(impression)
+		assert.Equal(t, ok, again)
+
+		if ok {
+			kept++
+			attributes := sampled.Impression.Attributes
+			assert.Equal(t, VisitorAttribute{Value: 0.3, Key: SamplingRateAttribute, AttributeType: "custom", EntityID: SamplingRateAttribute},
+				attributes[len(attributes)-1])
+			assert.Len(t, impression.Impression.Attributes, len(attributes)-1)
+		}
+	}
+	assert.InDelta(t, 3000, kept, 200)
+}
+
+func TestImpressionSamplerKeepsOtherEvents(t *testing.T) {
+	sampler := newImpressionSampler(map[string]float64{"other_experiment": 0, "15402980349": 1})
+
+	impression := BuildTestImpressionEvent()
+	sampled, ok := sampler.sample(impression)
+	assert.True(t, ok)
+	assert.Equal(t, impression, sampled)
+
+	conversion := BuildTestConversionEvent()
+	sampled, ok = sampler.sample(conversion)
+	assert.True(t, ok)
+	assert.Equal(t, conversion, sampled)
+
+	impression.Impression.ExperimentID = "other_experiment"
+	_, ok = sampler.sample(impression)
+	assert.False(t, ok)
+}
+
+func TestImpressionSamplerIsIndependentOfTheVariation(t *testing.T) {
+	experiment := entities.Experiment{
+		ID: "15402980349",
+		Variations: map[string]entities.Variation{
+			"a": {ID: "a", Key: "a"},
+			"b": {ID: "b", Key: "b"},
+		},
+		TrafficAllocation: []entities.Range{{EntityID: "a", EndOfRange: 5000}, {EntityID: "b", EndOfRange: 10000}},
+	}
+	experimentBucketer := bucketer.NewMurmurhashExperimentBucketer(bucketer.DefaultHashSeed)
+	sampler := newImpressionSampler(map[string]float64{experiment.ID: 0.5})
+
+	kept := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		impression := BuildTestImpressionEvent()
+		impression.VisitorID = fmt.Sprintf("user_%d", i)
+		variation, _, err := experimentBucketer.Bucket(impression.VisitorID, experiment, entities.Group{})
+		assert.NoError(t, err)
+		impression.Impression.VariationID = variation.ID
+		if _, ok := sampler.sample(impression); ok {
+			kept[variation.ID]++
+		}
+	}
+	assert.InDelta(t, 2500, kept["a"], 250)
+	assert.InDelta(t, 2500, kept["b"], 250)
+}
+
+func TestImpressionSamplerUsesTheBucketingID(t *testing.T) {
+	sampler := newImpressionSampler(map[string]float64{"15402980349": 0.5})
+
+	// users sharing a bucketing ID are sampled together
+	first := BuildTestImpressionEvent()
+	first.Impression.Attributes = append(first.Impression.Attributes, VisitorAttribute{Key: bucketingIDKey, Value: "shared"})
+	for i := 0; i < 20; i++ {
+		other := first
+		other.VisitorID = fmt.Sprintf("user_%d", i)
+		_, firstKept := sampler.sample(first)
+		_, otherKept := sampler.sample(other)
+		assert.Equal(t, firstKept, otherKept)
+	}
+}
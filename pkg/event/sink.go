@@ -0,0 +1,200 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/metrics"
+)
+
+// DefaultSinkBatchSize holds the default number of events handed to a sink at once
+const DefaultSinkBatchSize = 100
+
+// DefaultSinkQueueSize holds the default number of events buffered per sink
+const DefaultSinkQueueSize = 1000
+
+// DefaultSinkFlushInterval holds the default interval at which a partial batch is handed to a sink
+const DefaultSinkFlushInterval = 5 * time.Second
+
+var sinkLogger = logging.GetLogger("EventSink")
+
+// Sink receives a copy of every user event processed by the BatchEventProcessor, independently of the dispatch to
+// the Optimizely log endpoint. Send is called from a single go routine per sink. Sinks implementing io.Closer are
+// closed once the processor stops, after the last buffered batch has been sent.
+type Sink interface {
+	Send(events []UserEvent) error
+}
+
+// SinkOptionFunc is used to configure the batching of a single event sink
+type SinkOptionFunc func(r *sinkRunner)
+
+// WithSinkBatchSize sets the maximum number of events passed to the sink in one call
+func WithSinkBatchSize(batchSize int) SinkOptionFunc {
+	return func(r *sinkRunner) {
+		r.batchSize = batchSize
+	}
+}
+
+// WithSinkQueueSize sets the number of events buffered for the sink. Events are dropped when the buffer is full.
+func WithSinkQueueSize(queueSize int) SinkOptionFunc {
+	return func(r *sinkRunner) {
+		r.queueSize = queueSize
+	}
+}
+
+// WithSinkFlushInterval sets the interval at which a partial batch is passed to the sink
+func WithSinkFlushInterval(flushInterval time.Duration) SinkOptionFunc {
+	return func(r *sinkRunner) {
+		r.flushInterval = flushInterval
+	}
+}
+
+// WithEventSink adds a named sink to the processor. Every sink has its own buffer and go routine, so a slow or
+// failing sink never blocks event dispatch or the other sinks.
+func WithEventSink(name string, sink Sink, options ...SinkOptionFunc) BPOptionConfig {
+	return func(qp *BatchEventProcessor) {
+		runner := &sinkRunner{
+			name:          name,
+			sink:          sink,
+			batchSize:     DefaultSinkBatchSize,
+			queueSize:     DefaultSinkQueueSize,
+			flushInterval: DefaultSinkFlushInterval,
+		}
+		for _, opt := range options {
+			opt(runner)
+		}
+		qp.sinks = append(qp.sinks, runner)
+	}
+}
+
+// sinkRunner buffers and batches the events for a single sink
+type sinkRunner struct {
+	name          string
+	sink          Sink
+	batchSize     int
+	queueSize     int
+	flushInterval time.Duration
+
+	events         chan UserEvent
+	droppedCounter metrics.Counter
+	failedCounter  metrics.Counter
+}
+
+func (r *sinkRunner) init(metricsRegistry metrics.Registry) {
+	if r.batchSize <= 0 {
+		r.batchSize = DefaultSinkBatchSize
+	}
+	if r.queueSize <= 0 {
+		r.queueSize = DefaultSinkQueueSize
+	}
+	if r.flushInterval <= 0 {
+		r.flushInterval = DefaultSinkFlushInterval
+	}
+	if metricsRegistry == nil {
+		metricsRegistry = metrics.NewNoopRegistry()
+	}
+	r.events = make(chan UserEvent, r.queueSize)
+	r.droppedCounter = metricsRegistry.GetCounter(fmt.Sprintf("%s.%s", metrics.SinkDropped, r.name))
+	r.failedCounter = metricsRegistry.GetCounter(fmt.Sprintf("%s.%s", metrics.SinkFailed, r.name))
+}
+
+// offer adds the event to the sink buffer without blocking
+func (r *sinkRunner) offer(event UserEvent) {
+	select {
+	case r.events <- event:
+	default:
+		sinkLogger.Warning(fmt.Sprintf("Buffer of event sink %q is full. Discarding event", r.name))
+		r.droppedCounter.Add(1)
+	}
+}
+
+// run passes batches to the sink until the context is done, then flushes the buffered events and closes the sink
+func (r *sinkRunner) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer r.close()
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]UserEvent, 0, r.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		r.send(batch)
+		batch = make([]UserEvent, 0, r.batchSize)
+	}
+
+	for {
+		select {
+		case event := <-r.events:
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-r.events:
+					batch = append(batch, event)
+					if len(batch) >= r.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					sinkLogger.Debug(fmt.Sprintf("Event sink %q stopped", r.name))
+					return
+				}
+			}
+		}
+	}
+}
+
+// send hands the batch to the sink, recovering from any panic in the sink
+func (r *sinkRunner) send(batch []UserEvent) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			sinkLogger.Error(fmt.Sprintf("Event sink %q panicked", r.name), fmt.Errorf("%v", rec))
+			r.failedCounter.Add(float64(len(batch)))
+		}
+	}()
+
+	if err := r.sink.Send(batch); err != nil {
+		sinkLogger.Error(fmt.Sprintf("Event sink %q failed to send %d events", r.name, len(batch)), err)
+		r.failedCounter.Add(float64(len(batch)))
+	}
+}
+
+// close closes the sink if it implements io.Closer
+func (r *sinkRunner) close() {
+	closer, ok := r.sink.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		sinkLogger.Error(fmt.Sprintf("Event sink %q failed to close", r.name), err)
+	}
+}
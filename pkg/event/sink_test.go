@@ -0,0 +1,248 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	lock    sync.Mutex
+	batches [][]UserEvent
+	err     error
+}
+
+func (s *recordingSink) Send(events []UserEvent) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batches = append(s.batches, events)
+	return s.err
+}
+
+func (s *recordingSink) count() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	count := 0
+	for _, batch := range s.batches {
+		count += len(batch)
+	}
+	return count
+}
+
+type panickingSink struct{}
+
+func (panickingSink) Send(events []UserEvent) error {
+	panic("I'm panicking")
+}
+
+type blockingSink struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s blockingSink) Send(events []UserEvent) error {
+	s.entered <- struct{}{}
+	<-s.release
+	return nil
+}
+
+// waitUntil polls the condition until it holds or a second has passed
+func waitUntil(condition func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return condition()
+}
+
+func uuids(events []UserEvent) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.UUID
+	}
+	return ids
+}
+
+func TestEventSinkReceivesEventsInBatches(t *testing.T) {
+	eg := newExecutionContext()
+	sink := &recordingSink{}
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventSink("recording", sink, WithSinkBatchSize(2), WithSinkFlushInterval(time.Hour)))
+	eg.Go(processor.Start)
+
+	for i := 0; i < 5; i++ {
+		processor.ProcessEvent(BuildTestImpressionEvent())
+	}
+
+	assert.True(t, waitUntil(func() bool { return sink.count() == 4 }))
+
+	// remaining partial batch is flushed on shutdown
+	eg.TerminateAndWait()
+	assert.Equal(t, 5, sink.count())
+	assert.Len(t, sink.batches, 3)
+}
+
+func TestEventSinkIsClosedOnShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.ndjson")
+
+	sink, err := NewFileSink(path)
+	assert.NoError(t, err)
+	eg := newExecutionContext()
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventSink("file", sink, WithSinkFlushInterval(time.Hour)))
+	eg.Go(processor.Start)
+
+	processor.ProcessEvent(BuildTestImpressionEvent())
+	processor.ProcessEvent(BuildTestConversionEvent())
+	eg.TerminateAndWait()
+
+	// the last partial batch is written before the file is closed
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(content, []byte("\n")))
+	assert.Error(t, sink.Send([]UserEvent{BuildTestImpressionEvent()}))
+}
+
+func TestEventSinkFlushInterval(t *testing.T) {
+	eg := newExecutionContext()
+	sink := &recordingSink{}
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(NewMockDispatcher(100, false)),
+		WithEventSink("recording", sink, WithSinkFlushInterval(10*time.Millisecond)))
+	eg.Go(processor.Start)
+
+	processor.ProcessEvent(BuildTestConversionEvent())
+
+	assert.True(t, waitUntil(func() bool { return sink.count() == 1 }))
+	eg.TerminateAndWait()
+}
+
+func TestEventSinkFailureIsolation(t *testing.T) {
+	eg := newExecutionContext()
+	metricsRegistry := NewMetricsRegistry()
+	healthy := &recordingSink{}
+	blocked := blockingSink{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	dispatcher := NewMockDispatcher(100, false)
+	processor := NewBatchEventProcessor(
+		WithEventDispatcher(dispatcher),
+		WithBatchSize(1),
+		WithEventDispatcherMetrics(metricsRegistry),
+		WithEventSink("panicking", panickingSink{}, WithSinkBatchSize(1)),
+		WithEventSink("failing", &recordingSink{err: errors.New("failed")}, WithSinkBatchSize(1)),
+		WithEventSink("blocked", blocked, WithSinkBatchSize(1), WithSinkQueueSize(1)),
+		WithEventSink("healthy", healthy, WithSinkBatchSize(1)))
+	eg.Go(processor.Start)
+
+	assert.True(t, processor.ProcessEvent(BuildTestImpressionEvent()))
+	<-blocked.entered
+	for i := 0; i < 2; i++ {
+		assert.True(t, processor.ProcessEvent(BuildTestImpressionEvent()))
+	}
+
+	assert.True(t, waitUntil(func() bool { return healthy.count() == 3 }))
+	assert.True(t, waitUntil(func() bool { return dispatcher.Events.Size() == 3 }))
+	assert.True(t, waitUntil(func() bool {
+		return metricsRegistry.GetCounter("sink.failed.panicking").(*MetricsCounter).Get() == 3 &&
+			metricsRegistry.GetCounter("sink.failed.failing").(*MetricsCounter).Get() == 3
+	}))
+	// the blocked sink holds one event in Send and one in its buffer, the last one is dropped
+	assert.Equal(t, float64(1), metricsRegistry.GetCounter("sink.dropped.blocked").(*MetricsCounter).Get())
+
+	close(blocked.release)
+	eg.TerminateAndWait()
+}
+
+func TestWriterSink(t *testing.T) {
+	var buffer bytes.Buffer
+	sink := NewWriterSink(&buffer)
+
+	impression := BuildTestImpressionEvent()
+	conversion := BuildTestConversionEvent()
+	assert.NoError(t, sink.Send([]UserEvent{impression, conversion}))
+
+	scanner := bufio.NewScanner(&buffer)
+	var lines []UserEvent
+	for scanner.Scan() {
+		var userEvent UserEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &userEvent))
+		lines = append(lines, userEvent)
+	}
+	assert.Equal(t, uuids([]UserEvent{impression, conversion}), uuids(lines))
+	assert.NotNil(t, lines[0].Impression)
+	assert.NotNil(t, lines[1].Conversion)
+	assert.NoError(t, sink.Close())
+}
+
+func TestFileSinkAppends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.ndjson")
+
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSink(path)
+		assert.NoError(t, err)
+		assert.NoError(t, sink.Send([]UserEvent{BuildTestImpressionEvent()}))
+		assert.NoError(t, sink.Close())
+	}
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(content, []byte("\n")))
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []UserEvent
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, nil, utils.Header{Name: "X-Token", Value: "secret"})
+	events := []UserEvent{BuildTestImpressionEvent(), BuildTestConversionEvent()}
+	assert.NoError(t, sink.Send(events))
+	assert.Equal(t, uuids(events), uuids(received))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, sink.Send(events))
+}
@@ -0,0 +1,51 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package event //
+package event
+
+import (
+	"fmt"
+
+	"github.com/optimizely/go-sdk/pkg/utils"
+)
+
+// WebhookSink is a Sink posting batches of user events as a JSON array to an HTTP endpoint
+type WebhookSink struct {
+	url       string
+	requester utils.Requester
+	headers   []utils.Header
+}
+
+// NewWebhookSink returns a sink posting to url with the given requester. A default requester is used if nil.
+func NewWebhookSink(url string, requester utils.Requester, headers ...utils.Header) *WebhookSink {
+	if requester == nil {
+		requester = utils.NewHTTPRequester()
+	}
+	return &WebhookSink{url: url, requester: requester, headers: headers}
+}
+
+// Send posts the events and treats any non 2xx response as a failure
+func (s *WebhookSink) Send(events []UserEvent) error {
+	_, _, code, err := s.requester.Post(s.url, events, s.headers...)
+	if err != nil {
+		return err
+	}
+	if code < 200 || code > 299 {
+		return fmt.Errorf("webhook %s responded with status %d", s.url, code)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package explain reports the decision path of a user through the decision service, to tell why a user gets a
+// variation.
+package explain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/decision/bucketer"
+	"github.com/optimizely/go-sdk/pkg/decision/evaluator"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/entities"
+)
+
+// Stages of the decision path
+const (
+	OverrideStage          = decision.OverrideStage
+	WhitelistStage         = decision.WhitelistStage
+	BucketingStage         = decision.BucketingStage
+	FeatureExperimentStage = decision.FeatureExperimentStage
+	RolloutStage           = decision.RolloutStage
+	UserProfileStage       = "userProfile"
+	AudienceStage          = "audience"
+	ConditionStage         = "condition"
+	GroupBucketingStage    = "groupBucketing"
+	TrafficBucketingStage  = "trafficBucketing"
+)
+
+// Step is a step of the decision path
+type Step struct {
+	Entity  string `json:"entity,omitempty"` // experiment or rollout rule being decided
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+	Depth   int    `json:"depth,omitempty"` // nesting of the audience conditions
+}
+
+// Explanation holds the decision path of a user for an experiment or a feature
+type Explanation struct {
+	UserID        string                 `json:"userId"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	ExperimentKey string                 `json:"experimentKey,omitempty"`
+	FeatureKey    string                 `json:"featureKey,omitempty"`
+	Steps         []Step                 `json:"steps"`
+	Source        string                 `json:"source,omitempty"`    // source of the feature decision
+	DecidedBy     string                 `json:"decidedBy,omitempty"` // experiment or rollout rule which decided the variation
+	VariationKey  string                 `json:"variationKey,omitempty"`
+	Enabled       bool                   `json:"enabled"`
+	Reason        reasons.Reason         `json:"reason"`
+}
+
+// String returns the decision path in a human readable form
+func (e Explanation) String() string {
+	var b strings.Builder
+	subject := fmt.Sprintf(`experiment "%s"`, e.ExperimentKey)
+	if e.FeatureKey != "" {
+		subject = fmt.Sprintf(`feature "%s"`, e.FeatureKey)
+	}
+	fmt.Fprintf(&b, "Decision of %s for user \"%s\"\n", subject, e.UserID)
+
+	entity := ""
+	for _, step := range e.Steps {
+		if step.Entity != entity {
+			entity = step.Entity
+			fmt.Fprintf(&b, "%s\n", entity)
+		}
+		fmt.Fprintf(&b, "  %-18s %s%s\n", step.Stage, strings.Repeat("  ", step.Depth), step.Message)
+	}
+
+	variation := "no variation"
+	if e.VariationKey != "" {
+		variation = fmt.Sprintf(`variation "%s" of %s`, e.VariationKey, e.DecidedBy)
+	}
+	fmt.Fprintf(&b, "Result: %s", variation)
+	if e.FeatureKey != "" {
+		fmt.Fprintf(&b, ", feature enabled: %v", e.Enabled)
+		if e.Source != "" {
+			fmt.Fprintf(&b, ", source: %s", e.Source)
+		}
+	}
+	fmt.Fprintf(&b, "\nReason: %s\n", e.Reason)
+	return b.String()
+}
+
+// OptionFunc is used to configure the Explainer
+type OptionFunc func(e *Explainer)
+
+// WithOverrideStore sets the experiment override store of the decisions
+func WithOverrideStore(overrideStore decision.ExperimentOverrideStore) OptionFunc {
+	return func(e *Explainer) {
+		e.overrideStore = overrideStore
+	}
+}
+
+// WithUserProfileService sets the user profile service the saved decisions are looked up in. The explained
+// decisions are never saved.
+func WithUserProfileService(userProfileService decision.UserProfileServiceV2) OptionFunc {
+	return func(e *Explainer) {
+		e.userProfileService = userProfileService
+	}
+}
+
+// WithPipelineBuilder explains the decisions of the pipelines built by the given builder, such as the ones of a
+// client, instead of the default pipelines. The override store and the user profile service are then the ones of
+// the builder.
+func WithPipelineBuilder(pipelineBuilder decision.PipelineBuilder) OptionFunc {
+	return func(e *Explainer) {
+		e.pipelineBuilder = pipelineBuilder
+	}
+}
+
+// Explainer explains the decisions of a project. The decisions are made by the decision service of the SDK, with
+// the steps recorded along the way.
+type Explainer struct {
+	projectConfig      config.ProjectConfig
+	overrideStore      decision.ExperimentOverrideStore
+	userProfileService decision.UserProfileServiceV2
+	pipelineBuilder    decision.PipelineBuilder
+}
+
+// NewExplainer returns an explainer of the decisions of the given project
+func NewExplainer(projectConfig config.ProjectConfig, options ...OptionFunc) *Explainer {
+	e := &Explainer{projectConfig: projectConfig}
+	for _, opt := range options {
+		opt(e)
+	}
+	return e
+}
+
+// ExplainExperiment returns the decision path of the user for the experiment
+func (e *Explainer) ExplainExperiment(experimentKey string, userContext entities.UserContext) (Explanation, error) {
+	experiment, err := e.projectConfig.GetExperimentByKey(experimentKey)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	t := &trace{}
+	decisionService, err := e.decisionService(t)
+	if err != nil {
+		return Explanation{}, err
+	}
+	decisionContext := decision.ExperimentDecisionContext{Experiment: &experiment, ProjectConfig: e.projectConfig}
+	experimentDecision, err := decisionService.GetExperimentDecision(decisionContext, userContext)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	explanation := Explanation{
+		UserID:        userContext.ID,
+		Attributes:    userContext.Attributes,
+		ExperimentKey: experimentKey,
+		Steps:         t.steps,
+		Reason:        experimentDecision.Reason,
+	}
+	if experimentDecision.Variation != nil {
+		explanation.DecidedBy = fmt.Sprintf(`experiment "%s"`, experiment.Key)
+		explanation.VariationKey = experimentDecision.Variation.Key
+	}
+	return explanation, nil
+}
+
+// ExplainFeature returns the decision path of the user for the feature
+func (e *Explainer) ExplainFeature(featureKey string, userContext entities.UserContext) (Explanation, error) {
+	feature, err := e.projectConfig.GetFeatureByKey(featureKey)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	t := &trace{}
+	decisionService, err := e.decisionService(t)
+	if err != nil {
+		return Explanation{}, err
+	}
+	decisionContext := decision.FeatureDecisionContext{Feature: &feature, ProjectConfig: e.projectConfig}
+	featureDecision, err := decisionService.GetFeatureDecision(decisionContext, userContext)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	explanation := Explanation{
+		UserID:     userContext.ID,
+		Attributes: userContext.Attributes,
+		FeatureKey: featureKey,
+		Steps:      t.steps,
+		Source:     string(featureDecision.Source),
+		Reason:     featureDecision.Reason,
+	}
+	if featureDecision.Variation != nil {
+		explanation.DecidedBy = entityName(featureDecision.Experiment, featureDecision.Source == decision.Rollout)
+		explanation.VariationKey = featureDecision.Variation.Key
+		explanation.Enabled = featureDecision.Variation.FeatureEnabled
+	}
+	return explanation, nil
+}
+
+// decisionService returns the decision service of the explained pipelines, with their stages, bucketer, audience
+// evaluator and user profile service recording their steps in the trace
+func (e *Explainer) decisionService(t *trace) (decision.Service, error) {
+	instrumentation := decision.Instrumentation{
+		BucketerOptions: []decision.EBSOptionFunc{
+			decision.WithExperimentBucketer(tracingBucketer{
+				experimentBucketer: bucketer.NewMurmurhashExperimentBucketer(bucketer.DefaultHashSeed),
+				bucketer:           bucketer.NewMurmurhashBucketer(bucketer.DefaultHashSeed),
+				projectConfig:      e.projectConfig,
+				trace:              t,
+			}),
+			decision.WithAudienceTreeEvaluator(tracingTreeEvaluator{evaluator: evaluator.NewMixedTreeEvaluator(), trace: t}),
+		},
+		UserProfileService: func(userProfileService decision.UserProfileServiceV2) decision.UserProfileServiceV2 {
+			return tracingUserProfileService{userProfileService: userProfileService, trace: t}
+		},
+		ExperimentStage: func(name string, experimentService decision.ExperimentService) decision.ExperimentService {
+			return tracingExperimentService{stage: name, experimentService: experimentService, trace: t}
+		},
+		FeatureStage: func(name string, featureService decision.FeatureService) decision.FeatureService {
+			return tracingFeatureService{stage: name, featureService: featureService, trace: t}
+		},
+	}
+
+	pipelineBuilder := e.pipelineBuilder
+	if pipelineBuilder == nil {
+		pipelineBuilder = e.defaultPipelines
+	}
+	compositeExperimentService, compositeFeatureService, err := pipelineBuilder(instrumentation)
+	if err != nil {
+		return nil, err
+	}
+	return decision.NewCompositeService("explain",
+		decision.WithCompositeExperimentService(compositeExperimentService),
+		decision.WithCompositeFeatureService(compositeFeatureService),
+	), nil
+}
+
+// defaultPipelines builds the default pipelines of the SDK with the override store and the user profile service of
+// the explainer
+func (e *Explainer) defaultPipelines(instrumentation decision.Instrumentation) (*decision.CompositeExperimentService, *decision.CompositeFeatureService, error) {
+	experimentOptions := []decision.CESOptionFunc{decision.WithExperimentBucketerOptions(instrumentation.BucketerOptions...)}
+	if e.overrideStore != nil {
+		experimentOptions = append(experimentOptions, decision.WithOverrideStore(e.overrideStore))
+	}
+	if e.userProfileService != nil {
+		experimentOptions = append(experimentOptions, decision.WithUserProfileServiceV2(instrumentation.UserProfileService(e.userProfileService)))
+	}
+	compositeExperimentService, err := decision.NewExperimentPipeline(experimentOptions...).Wrap(instrumentation.ExperimentStage).Build()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rolloutService := decision.NewRolloutService(decision.WithRolloutBucketerOptions(instrumentation.BucketerOptions...))
+	compositeFeatureService, err := decision.NewFeaturePipeline(compositeExperimentService, decision.WithRolloutService(rolloutService)).
+		Wrap(instrumentation.FeatureStage).
+		Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return compositeExperimentService, compositeFeatureService, nil
+}
+
+func entityName(experiment entities.Experiment, rolloutRule bool) string {
+	if rolloutRule {
+		return fmt.Sprintf(`rollout rule "%s"`, experiment.Key)
+	}
+	return fmt.Sprintf(`experiment "%s"`, experiment.Key)
+}
@@ -0,0 +1,162 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package explain
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/entities"
+)
+
+func loadProjectConfig(t *testing.T) config.ProjectConfig {
+	datafile, err := ioutil.ReadFile("testdata/datafile.json")
+	require.NoError(t, err)
+	projectConfig, err := datafileprojectconfig.NewDatafileProjectConfig(datafile)
+	require.NoError(t, err)
+	return projectConfig
+}
+
+func stagesOf(explanation Explanation) []string {
+	var stages []string
+	for _, step := range explanation.Steps {
+		stages = append(stages, step.Stage)
+	}
+	return stages
+}
+
+type profileService struct {
+	profile decision.UserProfile
+	err     error
+	saved   bool
+}
+
+func (s *profileService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	return s.profile, s.err
+}
+
+func (s *profileService) Save(ctx context.Context, profile decision.UserProfile) error {
+	s.saved = true
+	return nil
+}
+
+func TestExplainWhitelistedExperiment(t *testing.T) {
+	explanation, err := NewExplainer(loadProjectConfig(t)).ExplainExperiment("split_test", entities.UserContext{ID: "vip"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{WhitelistStage}, stagesOf(explanation))
+	assert.Equal(t, `experiment "split_test"`, explanation.Steps[0].Entity)
+	assert.Equal(t, "treatment", explanation.VariationKey)
+	assert.Equal(t, reasons.WhitelistVariationAssignmentFound, explanation.Reason)
+}
+
+func TestExplainBucketedExperiment(t *testing.T) {
+	explanation, err := NewExplainer(loadProjectConfig(t)).ExplainExperiment("split_test", entities.UserContext{ID: "u4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{WhitelistStage, TrafficBucketingStage, BucketingStage}, stagesOf(explanation))
+	assert.Equal(t, `bucket value 3114 of bucketing ID "u4" falls into variation "control"`, explanation.Steps[1].Message)
+	assert.Equal(t, "control", explanation.VariationKey)
+	assert.Equal(t, reasons.BucketedIntoVariation, explanation.Reason)
+}
+
+func TestExplainFailedAudience(t *testing.T) {
+	userContext := entities.UserContext{ID: "u1", Attributes: map[string]interface{}{"country": "ca"}}
+	explanation, err := NewExplainer(loadProjectConfig(t)).ExplainExperiment("targeted_test", userContext)
+	require.NoError(t, err)
+	assert.Equal(t, []string{WhitelistStage, ConditionStage, ConditionStage, ConditionStage, ConditionStage, ConditionStage,
+		AudienceStage, BucketingStage}, stagesOf(explanation))
+	assert.Equal(t, `audience "us users" (200): false`, explanation.Steps[2].Message)
+	assert.Equal(t, `country exact "us" (user: "ca"): false`, explanation.Steps[5].Message)
+	assert.Equal(t, 5, explanation.Steps[5].Depth)
+	assert.Equal(t, "", explanation.VariationKey)
+	assert.Equal(t, reasons.FailedAudienceTargeting, explanation.Reason)
+	assert.Contains(t, explanation.String(), "Result: no variation\nReason: Does not meet audience targeting conditions\n")
+}
+
+func TestExplainFeatureRollout(t *testing.T) {
+	userContext := entities.UserContext{ID: "u3", Attributes: map[string]interface{}{"country": "us"}}
+	explanation, err := NewExplainer(loadProjectConfig(t)).ExplainFeature("rollout_feature", userContext)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		WhitelistStage, GroupBucketingStage, BucketingStage, FeatureExperimentStage,
+		ConditionStage, ConditionStage, ConditionStage, ConditionStage, ConditionStage, AudienceStage,
+		TrafficBucketingStage, RolloutStage,
+	}, stagesOf(explanation))
+	assert.Equal(t, `bucket value 2762 of bucketing ID "u3" in mutually exclusive group "500" falls into experiment "group_test_a"`,
+		explanation.Steps[1].Message)
+	assert.Equal(t, `rollout rule "3200"`, explanation.Steps[4].Entity)
+	assert.Equal(t, "on", explanation.VariationKey)
+	assert.Equal(t, `rollout rule "3200"`, explanation.DecidedBy)
+	assert.True(t, explanation.Enabled)
+	assert.Equal(t, string(decision.Rollout), explanation.Source)
+	assert.Equal(t, reasons.BucketedIntoRollout, explanation.Reason)
+}
+
+func TestExplainOverride(t *testing.T) {
+	overrideStore := decision.NewMapExperimentOverridesStore()
+	overrideStore.SetVariation(decision.ExperimentOverrideKey{ExperimentKey: "split_test", UserID: "vip"}, "control")
+	explainer := NewExplainer(loadProjectConfig(t), WithOverrideStore(overrideStore))
+
+	explanation, err := explainer.ExplainExperiment("split_test", entities.UserContext{ID: "vip"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{OverrideStage}, stagesOf(explanation))
+	assert.Equal(t, "control", explanation.VariationKey)
+}
+
+func TestExplainUserProfile(t *testing.T) {
+	userProfileService := &profileService{profile: decision.UserProfile{
+		ID:                  "u4",
+		ExperimentBucketMap: map[decision.UserDecisionKey]string{decision.NewUserDecisionKey("1000"): "1002"},
+	}}
+	explainer := NewExplainer(loadProjectConfig(t), WithUserProfileService(userProfileService))
+
+	explanation, err := explainer.ExplainExperiment("split_test", entities.UserContext{ID: "u4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{WhitelistStage, UserProfileStage, BucketingStage}, stagesOf(explanation))
+	assert.Equal(t, "saved variation IDs by experiment ID: 1000=1002", explanation.Steps[1].Message)
+	assert.Equal(t, "treatment", explanation.VariationKey)
+
+	// the decision computed for the user without a saved decision is not saved
+	userProfileService.profile = decision.UserProfile{ID: "u4"}
+	explanation, err = explainer.ExplainExperiment("split_test", entities.UserContext{ID: "u4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{WhitelistStage, UserProfileStage, TrafficBucketingStage, UserProfileStage, BucketingStage}, stagesOf(explanation))
+	assert.Equal(t, "control", explanation.VariationKey)
+	assert.False(t, userProfileService.saved)
+
+	userProfileService.err = errors.New("unreachable")
+	explanation, err = explainer.ExplainExperiment("split_test", entities.UserContext{ID: "u4"})
+	require.NoError(t, err)
+	assert.Equal(t, "lookup failed, the decision is computed but not saved: unreachable", explanation.Steps[1].Message)
+	assert.Equal(t, "control", explanation.VariationKey)
+}
+
+func TestExplainUnknownKeys(t *testing.T) {
+	explainer := NewExplainer(loadProjectConfig(t))
+	_, err := explainer.ExplainExperiment("unknown", entities.UserContext{ID: "u1"})
+	assert.Error(t, err)
+	_, err = explainer.ExplainFeature("unknown", entities.UserContext{ID: "u1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,246 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package explain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/decision/bucketer"
+	"github.com/optimizely/go-sdk/pkg/decision/evaluator"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/entities"
+)
+
+// trace records the steps of a decision
+type trace struct {
+	steps  []Step
+	entity string
+
+	// the rollout service evaluates the audience of a rule before bucketing into it, which evaluates it again
+	lastTree       *entities.TreeNode
+	lastTreeEntity string
+}
+
+func (t *trace) add(stage string, depth int, format string, args ...interface{}) {
+	t.steps = append(t.steps, Step{Entity: t.entity, Stage: stage, Message: fmt.Sprintf(format, args...), Depth: depth})
+}
+
+func decisionMessage(variation *entities.Variation, reason reasons.Reason, err error) string {
+	switch {
+	case err != nil:
+		return fmt.Sprintf("error: %v", err)
+	case variation != nil && reason != "":
+		return fmt.Sprintf(`variation "%s" (%s)`, variation.Key, reason)
+	case variation != nil:
+		return fmt.Sprintf(`variation "%s"`, variation.Key)
+	case reason != "":
+		return string(reason)
+	}
+	return "no variation"
+}
+
+// tracingExperimentService records the decision of an experiment stage
+type tracingExperimentService struct {
+	stage             string
+	experimentService decision.ExperimentService
+	trace             *trace
+}
+
+func (s tracingExperimentService) GetDecision(decisionContext decision.ExperimentDecisionContext, userContext entities.UserContext) (decision.ExperimentDecision, error) {
+	if decisionContext.Experiment != nil {
+		s.trace.entity = entityName(*decisionContext.Experiment, false)
+	}
+	experimentDecision, err := s.experimentService.GetDecision(decisionContext, userContext)
+	s.trace.add(s.stage, 0, decisionMessage(experimentDecision.Variation, experimentDecision.Reason, err))
+	return experimentDecision, err
+}
+
+// tracingFeatureService records the decision of a feature stage
+type tracingFeatureService struct {
+	stage          string
+	featureService decision.FeatureService
+	trace          *trace
+}
+
+func (s tracingFeatureService) GetDecision(decisionContext decision.FeatureDecisionContext, userContext entities.UserContext) (decision.FeatureDecision, error) {
+	entity := fmt.Sprintf(`feature "%s"`, decisionContext.Feature.Key)
+	if s.stage == RolloutStage && len(decisionContext.Feature.Rollout.Experiments) > 0 {
+		entity = entityName(decisionContext.Feature.Rollout.Experiments[0], true)
+	}
+	s.trace.entity = entity
+
+	featureDecision, err := s.featureService.GetDecision(decisionContext, userContext)
+	s.trace.entity = entity
+	s.trace.add(s.stage, 0, decisionMessage(featureDecision.Variation, featureDecision.Reason, err))
+	return featureDecision, err
+}
+
+// tracingBucketer records the bucket values of the user, computed the way the experiment bucketer computes them
+type tracingBucketer struct {
+	experimentBucketer bucketer.ExperimentBucketer
+	bucketer           bucketer.Bucketer
+	projectConfig      config.ProjectConfig
+	trace              *trace
+}
+
+func (b tracingBucketer) Bucket(bucketingID string, experiment entities.Experiment, group entities.Group) (*entities.Variation, reasons.Reason, error) {
+	variation, reason, err := b.experimentBucketer.Bucket(bucketingID, experiment, group)
+
+	if experiment.GroupID != "" && group.Policy == "random" {
+		bucketKey := bucketingID + group.ID
+		bucketedExperimentID := b.bucketer.BucketToEntity(bucketKey, group.TrafficAllocation)
+		b.trace.add(GroupBucketingStage, 0, `bucket value %d of bucketing ID "%s" in mutually exclusive group "%s" falls into %s`,
+			b.bucketer.Generate(bucketKey), bucketingID, group.ID, b.experimentName(bucketedExperimentID))
+		if bucketedExperimentID != experiment.ID {
+			return variation, reason, err
+		}
+	}
+
+	bucketKey := bucketingID + experiment.ID
+	bucketedVariationID := b.bucketer.BucketToEntity(bucketKey, experiment.TrafficAllocation)
+	destination := "no traffic allocation range"
+	if bucketedVariation, ok := experiment.Variations[bucketedVariationID]; ok {
+		destination = fmt.Sprintf(`variation "%s"`, bucketedVariation.Key)
+	} else if bucketedVariationID != "" {
+		destination = fmt.Sprintf(`unknown variation ID "%s"`, bucketedVariationID)
+	}
+	b.trace.add(TrafficBucketingStage, 0, `bucket value %d of bucketing ID "%s" falls into %s`,
+		b.bucketer.Generate(bucketKey), bucketingID, destination)
+	return variation, reason, err
+}
+
+func (b tracingBucketer) experimentName(experimentID string) string {
+	if experimentID == "" {
+		return "no traffic allocation range"
+	}
+	for _, experiment := range b.projectConfig.GetExperimentList() {
+		if experiment.ID == experimentID {
+			return fmt.Sprintf(`experiment "%s"`, experiment.Key)
+		}
+	}
+	return fmt.Sprintf(`unknown experiment ID "%s"`, experimentID)
+}
+
+// tracingTreeEvaluator records the evaluation of every audience condition
+type tracingTreeEvaluator struct {
+	evaluator evaluator.TreeEvaluator
+	trace     *trace
+}
+
+func (e tracingTreeEvaluator) Evaluate(node *entities.TreeNode, condTreeParams *entities.TreeParameters) (evalResult, isValid bool) {
+	evalResult, isValid = e.evaluator.Evaluate(node, condTreeParams)
+	if node == e.trace.lastTree && e.trace.entity == e.trace.lastTreeEntity {
+		return evalResult, isValid
+	}
+	e.trace.lastTree, e.trace.lastTreeEntity = node, e.trace.entity
+
+	e.explainNode(node, condTreeParams, 1)
+	result := fmt.Sprintf("%v", evalResult)
+	if !isValid {
+		result = "false (not evaluable)"
+	}
+	e.trace.add(AudienceStage, 0, "audience conditions evaluate to %s", result)
+	return evalResult, isValid
+}
+
+func (e tracingTreeEvaluator) explainNode(node *entities.TreeNode, condTreeParams *entities.TreeParameters, depth int) {
+	if node.Operator != "" {
+		e.trace.add(ConditionStage, depth, "%s", node.Operator)
+		for _, child := range node.Nodes {
+			e.explainNode(child, condTreeParams, depth+1)
+		}
+		return
+	}
+
+	switch item := node.Item.(type) {
+	case entities.Condition:
+		result, err := evaluator.CustomAttributeConditionEvaluator{}.Evaluate(item, condTreeParams)
+		e.trace.add(ConditionStage, depth, "%s: %s", describeCondition(item, condTreeParams.User), describeResult(result, err))
+	case string:
+		result, err := evaluator.AudienceConditionEvaluator{}.Evaluate(item, condTreeParams)
+		audience, ok := condTreeParams.AudienceMap[item]
+		if !ok {
+			e.trace.add(ConditionStage, depth, `audience ID "%s": %s`, item, describeResult(result, err))
+			return
+		}
+		e.trace.add(ConditionStage, depth, `audience "%s" (%s): %s`, audience.Name, item, describeResult(result, err))
+		if audience.ConditionTree != nil {
+			e.explainNode(audience.ConditionTree, condTreeParams, depth+1)
+		}
+	default:
+		e.trace.add(ConditionStage, depth, "unknown condition %v: not evaluable", item)
+	}
+}
+
+func describeCondition(condition entities.Condition, user *entities.UserContext) string {
+	match := condition.Match
+	if match == "" {
+		match = "exact"
+	}
+	value, ok := user.Attributes[condition.Name]
+	attribute := "missing"
+	if ok {
+		attribute = fmt.Sprintf("%#v", value)
+	}
+	if match == "exists" {
+		return fmt.Sprintf(`%s exists (user: %s)`, condition.Name, attribute)
+	}
+	return fmt.Sprintf(`%s %s %#v (user: %s)`, condition.Name, match, condition.Value, attribute)
+}
+
+func describeResult(result bool, err error) string {
+	if err != nil {
+		return fmt.Sprintf("not evaluable, %v", err)
+	}
+	return fmt.Sprintf("%v", result)
+}
+
+// tracingUserProfileService records the saved decisions of the user, and never saves the explained ones
+type tracingUserProfileService struct {
+	userProfileService decision.UserProfileServiceV2
+	trace              *trace
+}
+
+func (s tracingUserProfileService) Lookup(ctx context.Context, userID string) (decision.UserProfile, error) {
+	userProfile, err := s.userProfileService.Lookup(ctx, userID)
+	if err != nil {
+		s.trace.add(UserProfileStage, 0, "lookup failed, the decision is computed but not saved: %v", err)
+		return userProfile, err
+	}
+	if len(userProfile.ExperimentBucketMap) == 0 {
+		s.trace.add(UserProfileStage, 0, "no saved decisions")
+		return userProfile, nil
+	}
+
+	saved := make([]string, 0, len(userProfile.ExperimentBucketMap))
+	for decisionKey, variationID := range userProfile.ExperimentBucketMap {
+		saved = append(saved, fmt.Sprintf("%s=%s", decisionKey.ExperimentID, variationID))
+	}
+	sort.Strings(saved)
+	s.trace.add(UserProfileStage, 0, "saved variation IDs by experiment ID: %s", strings.Join(saved, ", "))
+	return userProfile, nil
+}
+
+func (s tracingUserProfileService) Save(ctx context.Context, userProfile decision.UserProfile) error {
+	s.trace.add(UserProfileStage, 0, "decision would be saved")
+	return nil
+}
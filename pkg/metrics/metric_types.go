@@ -24,3 +24,48 @@ const (
 	DispatcherRetryFlush   = "dispatcher.retryFlush"
 	DispatcherQueueSize    = "dispatcher.queueSize"
 )
+
+// NotificationDropped and NotificationPanicked are the prefixes of the counters for notifications discarded by an async
+// notification manager and for the panics of its handlers
+const (
+	NotificationDropped  = "notification.dropped"
+	NotificationPanicked = "notification.panicked"
+)
+
+// EventImpressionDeduplicated, EventImpressionSampledOut and EventImpressionRateLimited count the impressions dropped
+// by an event processor as duplicates, by sampling and by its rate limit
+const (
+	EventImpressionDeduplicated = "event.impressionDeduplicated"
+	EventImpressionSampledOut   = "event.impressionSampledOut"
+	EventImpressionRateLimited  = "event.impressionRateLimited"
+)
+
+// EventDropped is the prefix of the counters kept per reason for the events dropped by an event processor on overflow
+const EventDropped = "event.dropped"
+
+// SinkDropped and SinkFailed are the prefixes of the counters kept per event sink
+const (
+	SinkDropped = "sink.dropped"
+	SinkFailed  = "sink.failed"
+)
+
+// UserProfileLookupError and UserProfileSaveError count the failures of the user profile service store
+const (
+	UserProfileLookupError = "ups.lookupError"
+	UserProfileSaveError   = "ups.saveError"
+)
+
+// UserProfileCacheHit and UserProfileCacheMiss count the lookups of a caching user profile service
+const (
+	UserProfileCacheHit  = "ups.cacheHit"
+	UserProfileCacheMiss = "ups.cacheMiss"
+)
+
+// ClientCacheSize gauges the clients of a client cache, ClientCacheCreated, ClientCacheCreateError and
+// ClientCacheEvicted count their creations, failed creations and evictions
+const (
+	ClientCacheSize        = "clientCache.size"
+	ClientCacheCreated     = "clientCache.created"
+	ClientCacheCreateError = "clientCache.createError"
+	ClientCacheEvicted     = "clientCache.evicted"
+)
@@ -0,0 +1,178 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package notification //
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/metrics"
+)
+
+// DefaultAsyncBufferSize holds the default number of notifications buffered per notification type
+const DefaultAsyncBufferSize = 100
+
+// DefaultBlockTimeout holds the default time a sender waits for buffer space with the BlockOnOverflow policy
+const DefaultBlockTimeout = 100 * time.Millisecond
+
+// OverflowPolicy determines what happens to a notification that is sent while the buffer is full
+type OverflowPolicy int
+
+const (
+	// DropOnOverflow discards the notification right away
+	DropOnOverflow OverflowPolicy = iota
+	// BlockOnOverflow makes the sender wait for buffer space up to the block timeout, then discards the notification
+	BlockOnOverflow
+)
+
+// AsyncManager delivers notifications to its handlers on a separate goroutine, so that slow handlers do not stall
+// the caller. Notifications are buffered until Start is called.
+type AsyncManager struct {
+	*AtomicManager
+	queue          chan interface{}
+	policy         OverflowPolicy
+	blockTimeout   time.Duration
+	metricsName    string
+	droppedCounter metrics.Counter
+	panicCounter   metrics.Counter
+}
+
+// AsyncOptionFunc is used to provide custom configuration to the AsyncManager.
+type AsyncOptionFunc func(*asyncConfig)
+
+type asyncConfig struct {
+	bufferSize      int
+	policy          OverflowPolicy
+	blockTimeout    time.Duration
+	metricsRegistry metrics.Registry
+}
+
+// WithBufferSize sets the number of notifications that can be pending delivery
+func WithBufferSize(bufferSize int) AsyncOptionFunc {
+	return func(c *asyncConfig) {
+		c.bufferSize = bufferSize
+	}
+}
+
+// WithOverflowPolicy sets the policy applied when the buffer is full
+func WithOverflowPolicy(policy OverflowPolicy) AsyncOptionFunc {
+	return func(c *asyncConfig) {
+		c.policy = policy
+	}
+}
+
+// WithBlockTimeout sets how long a sender waits for buffer space with the BlockOnOverflow policy
+func WithBlockTimeout(blockTimeout time.Duration) AsyncOptionFunc {
+	return func(c *asyncConfig) {
+		c.blockTimeout = blockTimeout
+	}
+}
+
+// WithNotificationMetrics sets the registry used to count dropped notifications
+func WithNotificationMetrics(metricsRegistry metrics.Registry) AsyncOptionFunc {
+	return func(c *asyncConfig) {
+		c.metricsRegistry = metricsRegistry
+	}
+}
+
+// NewAsyncManager creates a new instance of the async manager for the given notification type
+func NewAsyncManager(notificationType Type, options ...AsyncOptionFunc) *AsyncManager {
+	c := &asyncConfig{
+		bufferSize:   DefaultAsyncBufferSize,
+		policy:       DropOnOverflow,
+		blockTimeout: DefaultBlockTimeout,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if c.bufferSize <= 0 {
+		c.bufferSize = DefaultAsyncBufferSize
+	}
+	if c.metricsRegistry == nil {
+		c.metricsRegistry = metrics.NewNoopRegistry()
+	}
+
+	metricsName := fmt.Sprintf("%s.%s", metrics.NotificationDropped, notificationType)
+	return &AsyncManager{
+		AtomicManager:  NewAtomicManager(),
+		queue:          make(chan interface{}, c.bufferSize),
+		policy:         c.policy,
+		blockTimeout:   c.blockTimeout,
+		metricsName:    metricsName,
+		droppedCounter: c.metricsRegistry.GetCounter(metricsName),
+		panicCounter:   c.metricsRegistry.GetCounter(fmt.Sprintf("%s.%s", metrics.NotificationPanicked, notificationType)),
+	}
+}
+
+// Send queues the notification for delivery to the registered handlers
+func (am *AsyncManager) Send(notification interface{}) {
+	select {
+	case am.queue <- notification:
+		return
+	default:
+	}
+
+	if am.policy == BlockOnOverflow && am.blockTimeout > 0 {
+		timer := time.NewTimer(am.blockTimeout)
+		defer timer.Stop()
+		select {
+		case am.queue <- notification:
+			return
+		case <-timer.C:
+		}
+	}
+
+	managerLogger.Warning(fmt.Sprintf("Notification buffer is full, discarding notification (%s)", am.metricsName))
+	am.droppedCounter.Add(1)
+}
+
+// Start delivers queued notifications until the context is cancelled. Notifications still buffered at that time
+// are delivered before Start returns.
+func (am *AsyncManager) Start(ctx context.Context) {
+	for {
+		select {
+		case notification := <-am.queue:
+			am.deliver(notification)
+		case <-ctx.Done():
+			am.drain()
+			return
+		}
+	}
+}
+
+func (am *AsyncManager) drain() {
+	for {
+		select {
+		case notification := <-am.queue:
+			am.deliver(notification)
+		default:
+			return
+		}
+	}
+}
+
+// deliver sends the notification to the registered handlers, counting the ones that panic
+func (am *AsyncManager) deliver(notification interface{}) {
+	for _, handler := range am.copyHandlers() {
+		if safeHandle(handler, notification) {
+			am.panicCounter.Add(1)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package notification
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/optimizely/go-sdk/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCounter struct {
+	sync.Mutex
+	value float64
+}
+
+func (c *testCounter) Add(value float64) {
+	c.Lock()
+	c.value += value
+	c.Unlock()
+}
+
+func (c *testCounter) Get() float64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.value
+}
+
+type testRegistry struct {
+	counters map[string]*testCounter
+}
+
+func (r *testRegistry) GetCounter(key string) metrics.Counter {
+	if counter, ok := r.counters[key]; ok {
+		return counter
+	}
+	counter := &testCounter{}
+	r.counters[key] = counter
+	return counter
+}
+
+func (r *testRegistry) GetGauge(key string) metrics.Gauge {
+	return &metrics.NoopGauge{}
+}
+
+func TestAsyncManagerDeliversOffCallerGoroutine(t *testing.T) {
+	asyncManager := NewAsyncManager(Decision)
+	block := make(chan struct{})
+	received := make(chan interface{}, 1)
+	asyncManager.Add(func(payload interface{}) {
+		<-block
+		received <- payload
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		asyncManager.Start(ctx)
+		close(done)
+	}()
+
+	// Send does not wait on the blocked handler
+	asyncManager.Send("payload")
+	close(block)
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, "payload", payload)
+	case <-time.After(time.Second):
+		assert.Fail(t, "notification was not delivered")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestAsyncManagerDropsWhenFull(t *testing.T) {
+	registry := &testRegistry{counters: map[string]*testCounter{}}
+	asyncManager := NewAsyncManager(Track, WithBufferSize(2), WithNotificationMetrics(registry))
+
+	received := 0
+	asyncManager.Add(func(interface{}) {
+		received++
+	})
+
+	// not started, so the buffer fills up
+	asyncManager.Send(1)
+	asyncManager.Send(2)
+	asyncManager.Send(3)
+	assert.Equal(t, float64(1), registry.counters["notification.dropped.track"].Get())
+
+	// pending notifications are delivered on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	asyncManager.Start(ctx)
+	assert.Equal(t, 2, received)
+}
+
+func TestAsyncManagerBlocksUntilTimeout(t *testing.T) {
+	registry := &testRegistry{counters: map[string]*testCounter{}}
+	asyncManager := NewAsyncManager(LogEvent, WithBufferSize(1), WithOverflowPolicy(BlockOnOverflow),
+		WithBlockTimeout(20*time.Millisecond), WithNotificationMetrics(registry))
+
+	asyncManager.Send(1)
+	start := time.Now()
+	asyncManager.Send(2)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	assert.Equal(t, float64(1), registry.counters["notification.dropped.log_event_notification"].Get())
+}
+
+func TestAsyncManagerBlockSucceedsWhenSpaceFrees(t *testing.T) {
+	asyncManager := NewAsyncManager(Decision, WithBufferSize(1), WithOverflowPolicy(BlockOnOverflow),
+		WithBlockTimeout(time.Second))
+
+	var mutex sync.Mutex
+	var received []interface{}
+	asyncManager.Add(func(payload interface{}) {
+		mutex.Lock()
+		received = append(received, payload)
+		mutex.Unlock()
+	})
+
+	asyncManager.Send(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		asyncManager.Start(ctx)
+		close(done)
+	}()
+	asyncManager.Send(2)
+
+	cancel()
+	<-done
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []interface{}{1, 2}, received)
+}
+
+func TestAsyncManagerRecoversFromHandlerPanic(t *testing.T) {
+	registry := &testRegistry{counters: map[string]*testCounter{}}
+	asyncManager := NewAsyncManager(Decision, WithNotificationMetrics(registry))
+	received := 0
+	asyncManager.Add(func(interface{}) {
+		panic("listener failure")
+	})
+	asyncManager.Add(func(interface{}) {
+		received++
+	})
+
+	asyncManager.Send(1)
+	asyncManager.Send(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotPanics(t, func() { asyncManager.Start(ctx) })
+	assert.Equal(t, 2, received)
+	assert.Equal(t, float64(2), registry.counters["notification.panicked.decision"].Get())
+}
@@ -17,7 +17,11 @@
 // Package notification //
 package notification
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"sync"
+)
 
 // Center handles all notification listeners. It keeps track of the Manager for each type of notification.
 type Center interface {
@@ -37,16 +41,47 @@ func NewNotificationCenter() *DefaultCenter {
 	projectConfigUpdateNotificationManager := NewAtomicManager()
 	processLogEventNotificationManager := NewAtomicManager()
 	trackNotificationManager := NewAtomicManager()
+	eventDropNotificationManager := NewAtomicManager()
 	managerMap := make(map[Type]Manager)
 	managerMap[Decision] = decisionNotificationManager
 	managerMap[ProjectConfigUpdate] = projectConfigUpdateNotificationManager
 	managerMap[LogEvent] = processLogEventNotificationManager
 	managerMap[Track] = trackNotificationManager
+	managerMap[EventDrop] = eventDropNotificationManager
 	return &DefaultCenter{
 		managerMap: managerMap,
 	}
 }
 
+// NewAsyncNotificationCenter returns a new notification center that delivers notifications asynchronously, with a
+// separate buffer for each notification type. Start must be called for notifications to be delivered.
+func NewAsyncNotificationCenter(options ...AsyncOptionFunc) *DefaultCenter {
+	managerMap := make(map[Type]Manager)
+	for _, notificationType := range []Type{Decision, ProjectConfigUpdate, LogEvent, Track, EventDrop} {
+		managerMap[notificationType] = NewAsyncManager(notificationType, options...)
+	}
+
+	return &DefaultCenter{
+		managerMap: managerMap,
+	}
+}
+
+// Start starts the delivery of notifications for the managers that deliver asynchronously and blocks until the
+// context is cancelled and the pending notifications are delivered
+func (c *DefaultCenter) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, manager := range c.managerMap {
+		if asyncManager, ok := manager.(*AsyncManager); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				asyncManager.Start(ctx)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
 // AddHandler adds a handler for the given notification type
 func (c *DefaultCenter) AddHandler(notificationType Type, handler func(interface{})) (int, error) {
 	if manager, ok := c.managerMap[notificationType]; ok {
@@ -1,6 +1,7 @@
 package notification
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,3 +56,24 @@ func TestNotificationCenter(t *testing.T) {
 	mockReceiver.AssertNumberOfCalls(t, "handleNotification", 1)
 	mockReceiver2.AssertNumberOfCalls(t, "handleNotification", 2)
 }
+
+func TestAsyncNotificationCenter(t *testing.T) {
+	notificationCenter := NewAsyncNotificationCenter(WithBufferSize(10))
+
+	received := make(chan interface{}, 10)
+	for _, notificationType := range []Type{Decision, ProjectConfigUpdate, LogEvent, Track, EventDrop} {
+		_, err := notificationCenter.AddHandler(notificationType, func(payload interface{}) {
+			received <- payload
+		})
+		assert.NoError(t, err)
+	}
+
+	notificationCenter.Send(Decision, "decision")
+	notificationCenter.Send(Track, "track")
+	assert.Equal(t, 0, len(received))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	notificationCenter.Start(ctx)
+	assert.Equal(t, 2, len(received))
+}
@@ -45,13 +45,55 @@ const (
 	FeatureVariable DecisionNotificationType = "feature-variable"
 	// LogEvent notification type
 	LogEvent Type = "log_event_notification"
+	// EventDrop notification type
+	EventDrop Type = "event_drop"
 )
 
-// DecisionNotification is a notification triggered when a decision is made for either a feature or an experiment
+// DecisionNotification is a notification triggered when a decision is made for either a feature or an experiment.
+// Only the typed info field matching the notification Type is set.
 type DecisionNotification struct {
 	Type         DecisionNotificationType
 	UserContext  entities.UserContext
 	DecisionInfo map[string]interface{}
+
+	FeatureInfo         *FeatureDecisionInfo
+	FeatureVariableInfo *FeatureVariableDecisionInfo
+	ABTestInfo          *ABTestDecisionInfo
+	FeatureTestInfo     *FeatureTestDecisionInfo
+}
+
+// SourceInfo identifies the feature test that a feature decision came from
+type SourceInfo struct {
+	ExperimentKey string
+	VariationKey  string
+}
+
+// FeatureDecisionInfo contains the details of a decision for a feature
+type FeatureDecisionInfo struct {
+	FeatureKey     string
+	FeatureEnabled bool
+	Source         string
+	SourceInfo     SourceInfo
+}
+
+// FeatureVariableDecisionInfo contains the details of a decision for a feature variable
+type FeatureVariableDecisionInfo struct {
+	FeatureDecisionInfo
+	VariableKey   string
+	VariableType  entities.VariableType
+	VariableValue interface{}
+}
+
+// ABTestDecisionInfo contains the details of a decision for an ab test
+type ABTestDecisionInfo struct {
+	ExperimentKey string
+	VariationKey  string
+}
+
+// FeatureTestDecisionInfo contains the details of a decision for a feature test
+type FeatureTestDecisionInfo struct {
+	ExperimentKey string
+	VariationKey  string
 }
 
 // TrackNotification is a notification triggered when track is called
@@ -66,6 +108,56 @@ type TrackNotification struct {
 type ProjectConfigUpdateNotification struct {
 	Type     Type
 	Revision string
+	Diff     *ProjectConfigDiff // changes from the previous project config, everything is added for the first one
+}
+
+// ChangeType tells how an entity of the project config changed
+type ChangeType string
+
+const (
+	// Added is used for entities missing from the previous project config
+	Added ChangeType = "added"
+	// Removed is used for entities missing from the new project config
+	Removed ChangeType = "removed"
+	// Changed is used for a field of an entity of both project configs
+	Changed ChangeType = "changed"
+)
+
+// ConfigEntity is the kind of a changed entity of the project config
+type ConfigEntity string
+
+const (
+	// FeatureEntity is a feature flag, keyed by feature key
+	FeatureEntity ConfigEntity = "feature"
+	// VariableEntity is a feature variable, keyed by feature key and variable key
+	VariableEntity ConfigEntity = "variable"
+	// ExperimentEntity is an experiment, keyed by experiment key
+	ExperimentEntity ConfigEntity = "experiment"
+	// RolloutRuleEntity is a rule of a feature rollout, keyed by feature key and rule key
+	RolloutRuleEntity ConfigEntity = "rolloutRule"
+	// VariationEntity is a variation, keyed by the key of its experiment or rollout rule and the variation key
+	VariationEntity ConfigEntity = "variation"
+	// GroupEntity is a mutually exclusive group, keyed by group ID
+	GroupEntity ConfigEntity = "group"
+	// AudienceEntity is an audience, keyed by audience ID
+	AudienceEntity ConfigEntity = "audience"
+)
+
+// ProjectConfigChange is a change of an entity of the project config. Nested keys are joined with dots.
+type ProjectConfigChange struct {
+	Type     ChangeType
+	Entity   ConfigEntity
+	Key      string
+	Field    string // changed field, only set for the Changed type
+	OldValue string
+	NewValue string
+}
+
+// ProjectConfigDiff holds the changes between two project configs
+type ProjectConfigDiff struct {
+	OldRevision string
+	NewRevision string
+	Changes     []ProjectConfigChange
 }
 
 // LogEventNotification is the notification triggered before log event is dispatched.
@@ -73,3 +165,23 @@ type LogEventNotification struct {
 	Type     Type
 	LogEvent interface{}
 }
+
+// EventDropReason tells why an event processor dropped an event
+type EventDropReason string
+
+const (
+	// QueueFull is used for new events dropped because the event queue was full
+	QueueFull EventDropReason = "queue_full"
+	// QueueEvicted is used for the oldest queued events evicted to make room for new ones
+	QueueEvicted EventDropReason = "queue_evicted"
+	// BlockTimeout is used for new events dropped because the event queue stayed full for the blocking timeout
+	BlockTimeout EventDropReason = "block_timeout"
+	// SpillFull is used for new events dropped because both the event queue and the spill queue were full
+	SpillFull EventDropReason = "spill_full"
+)
+
+// EventDropNotification is the notification triggered when an event processor drops a user event
+type EventDropNotification struct {
+	Reason EventDropReason
+	Event  interface{}
+}
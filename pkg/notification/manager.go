@@ -77,10 +77,23 @@ func (am *AtomicManager) Send(notification interface{}) {
 	// copying handler to avoid race condition
 	handlers := am.copyHandlers()
 	for _, handler := range handlers {
-		handler(notification)
+		safeHandle(handler, notification)
 	}
 }
 
+// Calls the handler, recovering from any panic so a misbehaving listener cannot take down the sender.
+// Returns whether the handler panicked.
+func safeHandle(handler func(interface{}), notification interface{}) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			managerLogger.Error("Notification handler panicked", r)
+			panicked = true
+		}
+	}()
+	handler(notification)
+	return false
+}
+
 // Return a copy of the given handlers
 func (am *AtomicManager) copyHandlers() (handlers []func(interface{})) {
 	am.lock.RLock()
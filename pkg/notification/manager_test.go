@@ -103,3 +103,17 @@ func TestAddRaceCondition(t *testing.T) {
 	<-sync
 	assert.Equal(t, len(atomicManager.handlers), 0)
 }
+
+func TestSendRecoversFromHandlerPanic(t *testing.T) {
+	atomicManager := NewAtomicManager()
+	called := false
+	atomicManager.Add(func(interface{}) {
+		panic("listener failure")
+	})
+	atomicManager.Add(func(interface{}) {
+		called = true
+	})
+
+	assert.NotPanics(t, func() { atomicManager.Send("payload") })
+	assert.True(t, called)
+}
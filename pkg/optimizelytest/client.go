@@ -0,0 +1,487 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package optimizelytest provides test doubles for the code using the client: a fake client.Client, which needs no
+// datafile as tests declare the features and the variations of the users, and a builder of datafiles for the tests
+// using an OptimizelyClient.
+package optimizelytest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/event"
+	"github.com/optimizely/go-sdk/pkg/notification"
+)
+
+// Revision is the revision of the OptimizelyConfig of the fake client
+const Revision = "optimizelytest"
+
+// UserMatcher tells whether a declaration applies to the user
+type UserMatcher func(userContext entities.UserContext) bool
+
+// AnyUser matches all the users
+func AnyUser() UserMatcher {
+	return func(userContext entities.UserContext) bool {
+		return true
+	}
+}
+
+// UserID matches the users with one of the IDs
+func UserID(userIDs ...string) UserMatcher {
+	return func(userContext entities.UserContext) bool {
+		for _, userID := range userIDs {
+			if userContext.ID == userID {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Attribute matches the users with the attribute value. Numbers match whatever their type.
+func Attribute(key string, value interface{}) UserMatcher {
+	return func(userContext entities.UserContext) bool {
+		attribute, ok := userContext.Attributes[key]
+		if !ok {
+			return false
+		}
+		if number, ok := toFloat(value); ok {
+			attributeNumber, ok := toFloat(attribute)
+			return ok && number == attributeNumber
+		}
+		return reflect.DeepEqual(attribute, value)
+	}
+}
+
+// Variables are the values of feature variables by variable key. The values are booleans, numbers or strings: a float
+// is a double variable and an integer an integer one.
+type Variables map[string]interface{}
+
+// Activation is a recorded call to Activate which bucketed the user into a variation
+type Activation struct {
+	ExperimentKey string
+	VariationKey  string
+	UserContext   entities.UserContext
+}
+
+// TrackedEvent is a recorded call to Track
+type TrackedEvent struct {
+	EventKey    string
+	UserContext entities.UserContext
+	EventTags   map[string]interface{}
+}
+
+type featureRule struct {
+	enabled   bool
+	variables Variables
+	matchers  []UserMatcher
+}
+
+type variationRule struct {
+	variationKey string
+	matchers     []UserMatcher
+}
+
+// Client is a fake client.Client deciding with the declared features and variations. The latest declaration matching
+// the user applies, so that the declarations for all the users come before the more specific ones.
+type Client struct {
+	mutex       sync.RWMutex
+	features    map[string][]featureRule
+	experiments map[string][]variationRule
+	activations []Activation
+	tracks      []TrackedEvent
+
+	notificationCenter notification.Center
+}
+
+var _ client.Client = &Client{}
+
+// NewClient returns a fake client with no features nor experiments
+func NewClient() *Client {
+	return &Client{
+		features:           map[string][]featureRule{},
+		experiments:        map[string][]variationRule{},
+		notificationCenter: notification.NewNotificationCenter(),
+	}
+}
+
+// EnableFeature declares the feature enabled with the variables for the users matching all the matchers, or all the
+// users if there are none
+func (c *Client) EnableFeature(featureKey string, variables Variables, matchers ...UserMatcher) {
+	c.addFeatureRule(featureKey, featureRule{enabled: true, variables: variables, matchers: matchers})
+}
+
+// DisableFeature declares the feature disabled for the users matching all the matchers, or all the users if there are
+// none. The variables are the default values returned for the disabled feature.
+func (c *Client) DisableFeature(featureKey string, variables Variables, matchers ...UserMatcher) {
+	c.addFeatureRule(featureKey, featureRule{enabled: false, variables: variables, matchers: matchers})
+}
+
+// SetVariation declares the variation of the experiment for the users matching all the matchers, or all the users if
+// there are none. An empty variation key declares the users not bucketed into the experiment.
+func (c *Client) SetVariation(experimentKey, variationKey string, matchers ...UserMatcher) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.experiments[experimentKey] = append(c.experiments[experimentKey], variationRule{variationKey: variationKey, matchers: matchers})
+}
+
+// Activations returns the recorded activations
+func (c *Client) Activations() []Activation {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]Activation{}, c.activations...)
+}
+
+// Tracks returns the recorded tracked events
+func (c *Client) Tracks() []TrackedEvent {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]TrackedEvent{}, c.tracks...)
+}
+
+// Reset forgets the recorded activations and tracked events, keeping the declarations
+func (c *Client) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.activations = nil
+	c.tracks = nil
+}
+
+// Activate returns the declared variation of the user and records the activation if there is one
+func (c *Client) Activate(experimentKey string, userContext entities.UserContext) (string, error) {
+	variationKey := c.variation(experimentKey, userContext)
+	if variationKey != "" {
+		c.mutex.Lock()
+		c.activations = append(c.activations, Activation{ExperimentKey: experimentKey, VariationKey: variationKey, UserContext: userContext})
+		c.mutex.Unlock()
+	}
+	return variationKey, nil
+}
+
+// GetVariation returns the declared variation of the user
+func (c *Client) GetVariation(experimentKey string, userContext entities.UserContext) (string, error) {
+	return c.variation(experimentKey, userContext), nil
+}
+
+// IsFeatureEnabled returns whether the feature is declared enabled for the user
+func (c *Client) IsFeatureEnabled(featureKey string, userContext entities.UserContext) (bool, error) {
+	rule := c.feature(featureKey, userContext)
+	return rule.enabled, nil
+}
+
+// GetEnabledFeatures returns the sorted keys of the features declared enabled for the user
+func (c *Client) GetEnabledFeatures(userContext entities.UserContext) ([]string, error) {
+	c.mutex.RLock()
+	featureKeys := make([]string, 0, len(c.features))
+	for featureKey := range c.features {
+		featureKeys = append(featureKeys, featureKey)
+	}
+	c.mutex.RUnlock()
+
+	enabledFeatures := []string{}
+	for _, featureKey := range featureKeys {
+		if rule := c.feature(featureKey, userContext); rule.enabled {
+			enabledFeatures = append(enabledFeatures, featureKey)
+		}
+	}
+	sort.Strings(enabledFeatures)
+	return enabledFeatures, nil
+}
+
+// GetFeatureVariable returns the declared value of the variable for the user formatted as in a datafile, along with
+// its type. Unknown variables have an empty value and type, like with the OptimizelyClient.
+func (c *Client) GetFeatureVariable(featureKey, variableKey string, userContext entities.UserContext) (string, entities.VariableType, error) {
+	rule := c.feature(featureKey, userContext)
+	value, ok := rule.variables[variableKey]
+	if !ok {
+		return "", "", nil
+	}
+	return formatVariable(value)
+}
+
+// GetFeatureVariableBoolean returns the declared boolean value of the variable for the user
+func (c *Client) GetFeatureVariableBoolean(featureKey, variableKey string, userContext entities.UserContext) (bool, error) {
+	val, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return false, err
+	}
+	convertedValue, err := strconv.ParseBool(val)
+	if err != nil || valueType != entities.Boolean {
+		return false, fmt.Errorf("variable value for key %s is invalid or wrong type", variableKey)
+	}
+	return convertedValue, nil
+}
+
+// GetFeatureVariableDouble returns the declared double value of the variable for the user
+func (c *Client) GetFeatureVariableDouble(featureKey, variableKey string, userContext entities.UserContext) (float64, error) {
+	val, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return 0, err
+	}
+	convertedValue, err := strconv.ParseFloat(val, 64)
+	if err != nil || valueType != entities.Double {
+		return 0, fmt.Errorf("variable value for key %s is invalid or wrong type", variableKey)
+	}
+	return convertedValue, nil
+}
+
+// GetFeatureVariableInteger returns the declared integer value of the variable for the user
+func (c *Client) GetFeatureVariableInteger(featureKey, variableKey string, userContext entities.UserContext) (int, error) {
+	val, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return 0, err
+	}
+	convertedValue, err := strconv.Atoi(val)
+	if err != nil || valueType != entities.Integer {
+		return 0, fmt.Errorf("variable value for key %s is invalid or wrong type", variableKey)
+	}
+	return convertedValue, nil
+}
+
+// GetFeatureVariableString returns the declared string value of the variable for the user
+func (c *Client) GetFeatureVariableString(featureKey, variableKey string, userContext entities.UserContext) (string, error) {
+	value, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return "", err
+	}
+	if valueType != entities.String {
+		return "", fmt.Errorf("variable value for key %s is wrong type", variableKey)
+	}
+	return value, nil
+}
+
+// GetAllFeatureVariables returns whether the feature is declared enabled for the user, along with the values of its
+// declared variables, typed like with the OptimizelyClient
+func (c *Client) GetAllFeatureVariables(featureKey string, userContext entities.UserContext) (bool, map[string]interface{}, error) {
+	rule := c.feature(featureKey, userContext)
+	variableMap := make(map[string]interface{}, len(rule.variables))
+	for variableKey, value := range rule.variables {
+		formattedValue, variableType, err := formatVariable(value)
+		if err != nil {
+			return rule.enabled, variableMap, err
+		}
+		switch variableType {
+		case entities.Boolean:
+			variableMap[variableKey], err = strconv.ParseBool(formattedValue)
+		case entities.Double:
+			variableMap[variableKey], err = strconv.ParseFloat(formattedValue, 64)
+		case entities.Integer:
+			variableMap[variableKey], err = strconv.Atoi(formattedValue)
+		default:
+			variableMap[variableKey] = formattedValue
+		}
+		if err != nil {
+			return rule.enabled, variableMap, err
+		}
+	}
+	return rule.enabled, variableMap, nil
+}
+
+// Track records the event and sends a Track notification, with an empty conversion event
+func (c *Client) Track(eventKey string, userContext entities.UserContext, eventTags map[string]interface{}) error {
+	c.mutex.Lock()
+	c.tracks = append(c.tracks, TrackedEvent{EventKey: eventKey, UserContext: userContext, EventTags: eventTags})
+	c.mutex.Unlock()
+
+	trackNotification := notification.TrackNotification{
+		EventKey:        eventKey,
+		UserContext:     userContext,
+		EventTags:       eventTags,
+		ConversionEvent: event.ConversionEvent{},
+	}
+	return c.notificationCenter.Send(notification.Track, trackNotification)
+}
+
+// GetOptimizelyConfig returns the declared features and experiments. The variables of a feature are the ones of all
+// its declarations, with the values of the latest one declaring them.
+func (c *Client) GetOptimizelyConfig() *config.OptimizelyConfig {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	optimizelyConfig := &config.OptimizelyConfig{
+		Revision:       Revision,
+		ExperimentsMap: map[string]config.OptimizelyExperiment{},
+		FeaturesMap:    map[string]config.OptimizelyFeature{},
+	}
+	for experimentKey, rules := range c.experiments {
+		experiment := config.OptimizelyExperiment{ID: experimentKey, Key: experimentKey, VariationsMap: map[string]config.OptimizelyVariation{}}
+		for _, rule := range rules {
+			if rule.variationKey != "" {
+				experiment.VariationsMap[rule.variationKey] = config.OptimizelyVariation{ID: rule.variationKey, Key: rule.variationKey}
+			}
+		}
+		optimizelyConfig.ExperimentsMap[experimentKey] = experiment
+	}
+	for featureKey, rules := range c.features {
+		feature := config.OptimizelyFeature{
+			ID:             featureKey,
+			Key:            featureKey,
+			ExperimentsMap: map[string]config.OptimizelyExperiment{},
+			VariablesMap:   map[string]config.OptimizelyVariable{},
+		}
+		for _, rule := range rules {
+			for variableKey, value := range rule.variables {
+				formattedValue, variableType, _ := formatVariable(value)
+				feature.VariablesMap[variableKey] = config.OptimizelyVariable{ID: variableKey, Key: variableKey, Type: string(variableType), Value: formattedValue}
+			}
+		}
+		optimizelyConfig.FeaturesMap[featureKey] = feature
+	}
+	return optimizelyConfig
+}
+
+// OnTrack registers a handler for the Track notifications of the tracked events
+func (c *Client) OnTrack(callback func(eventKey string, userContext entities.UserContext, eventTags map[string]interface{}, conversionEvent event.ConversionEvent)) (int, error) {
+	handler := func(payload interface{}) {
+		if trackNotification, ok := payload.(notification.TrackNotification); ok {
+			callback(trackNotification.EventKey, trackNotification.UserContext, trackNotification.EventTags, event.ConversionEvent{})
+		}
+	}
+	return c.notificationCenter.AddHandler(notification.Track, handler)
+}
+
+// RemoveOnTrack removes handler for Track notification with given id
+func (c *Client) RemoveOnTrack(id int) error {
+	return c.notificationCenter.RemoveHandler(id, notification.Track)
+}
+
+// OnDecision registers a handler for Decision notifications, which the fake client never sends
+func (c *Client) OnDecision(callback func(notification.DecisionNotification)) (int, error) {
+	return c.addSilentHandler(notification.Decision)
+}
+
+// RemoveOnDecision removes handler for Decision notification with given id
+func (c *Client) RemoveOnDecision(id int) error {
+	return c.notificationCenter.RemoveHandler(id, notification.Decision)
+}
+
+// OnConfigUpdate registers a handler for ProjectConfigUpdate notifications, which the fake client never sends
+func (c *Client) OnConfigUpdate(callback func(notification.ProjectConfigUpdateNotification)) (int, error) {
+	return c.addSilentHandler(notification.ProjectConfigUpdate)
+}
+
+// RemoveOnConfigUpdate removes handler for ProjectConfigUpdate notification with given id
+func (c *Client) RemoveOnConfigUpdate(id int) error {
+	return c.notificationCenter.RemoveHandler(id, notification.ProjectConfigUpdate)
+}
+
+// OnLogEvent registers a handler for LogEvent notifications, which the fake client never sends
+func (c *Client) OnLogEvent(callback func(logEvent event.LogEvent)) (int, error) {
+	return c.addSilentHandler(notification.LogEvent)
+}
+
+// RemoveOnLogEvent removes handler for LogEvent notification with given id
+func (c *Client) RemoveOnLogEvent(id int) error {
+	return c.notificationCenter.RemoveHandler(id, notification.LogEvent)
+}
+
+// OnEventDrop registers a handler for EventDrop notifications, which the fake client never sends
+func (c *Client) OnEventDrop(callback func(notification.EventDropNotification)) (int, error) {
+	return c.addSilentHandler(notification.EventDrop)
+}
+
+// RemoveOnEventDrop removes handler for EventDrop notification with given id
+func (c *Client) RemoveOnEventDrop(id int) error {
+	return c.notificationCenter.RemoveHandler(id, notification.EventDrop)
+}
+
+// Close does nothing, the fake client can be used afterwards
+func (c *Client) Close() {
+}
+
+func (c *Client) addFeatureRule(featureKey string, rule featureRule) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.features[featureKey] = append(c.features[featureKey], rule)
+}
+
+// feature returns the latest rule of the feature matching the user, or a disabled rule with no variables
+func (c *Client) feature(featureKey string, userContext entities.UserContext) featureRule {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	rules := c.features[featureKey]
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matches(rules[i].matchers, userContext) {
+			return rules[i]
+		}
+	}
+	return featureRule{}
+}
+
+// variation returns the variation of the latest rule of the experiment matching the user, or an empty one
+func (c *Client) variation(experimentKey string, userContext entities.UserContext) string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	rules := c.experiments[experimentKey]
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matches(rules[i].matchers, userContext) {
+			return rules[i].variationKey
+		}
+	}
+	return ""
+}
+
+// addSilentHandler registers a handler for a notification type the fake client doesn't send, so that it can be removed
+func (c *Client) addSilentHandler(notificationType notification.Type) (int, error) {
+	return c.notificationCenter.AddHandler(notificationType, func(interface{}) {})
+}
+
+func matches(matchers []UserMatcher, userContext entities.UserContext) bool {
+	for _, matcher := range matchers {
+		if !matcher(userContext) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatVariable formats a declared variable value as in a datafile
+func formatVariable(value interface{}) (string, entities.VariableType, error) {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v), entities.Boolean, nil
+	case string:
+		return v, entities.String, nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), entities.Double, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), entities.Double, nil
+	}
+	if _, ok := toFloat(value); ok {
+		return fmt.Sprint(value), entities.Integer, nil
+	}
+	return "", "", fmt.Errorf("unsupported variable value type %T", value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,201 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package optimizelytest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/event"
+)
+
+var (
+	freeUser    = entities.UserContext{ID: "user_1", Attributes: map[string]interface{}{"plan": "free", "age": 30.0}}
+	premiumUser = entities.UserContext{ID: "user_2", Attributes: map[string]interface{}{"plan": "premium", "age": 42}}
+)
+
+func TestFeatureDeclarations(t *testing.T) {
+	fake := NewClient()
+	fake.DisableFeature("checkout", Variables{"title": "Checkout"})
+	fake.EnableFeature("checkout", Variables{"title": "Pay now", "count": 3, "ratio": 0.5, "beta": true}, Attribute("plan", "premium"))
+
+	enabled, err := fake.IsFeatureEnabled("checkout", freeUser)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	enabled, err = fake.IsFeatureEnabled("checkout", premiumUser)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	title, err := fake.GetFeatureVariableString("checkout", "title", freeUser)
+	assert.NoError(t, err)
+	assert.Equal(t, "Checkout", title)
+	title, err = fake.GetFeatureVariableString("checkout", "title", premiumUser)
+	assert.NoError(t, err)
+	assert.Equal(t, "Pay now", title)
+
+	count, err := fake.GetFeatureVariableInteger("checkout", "count", premiumUser)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	ratio, err := fake.GetFeatureVariableDouble("checkout", "ratio", premiumUser)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, ratio)
+	beta, err := fake.GetFeatureVariableBoolean("checkout", "beta", premiumUser)
+	assert.NoError(t, err)
+	assert.True(t, beta)
+
+	value, valueType, err := fake.GetFeatureVariable("checkout", "count", premiumUser)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", value)
+	assert.Equal(t, entities.Integer, valueType)
+
+	_, err = fake.GetFeatureVariableBoolean("checkout", "count", premiumUser)
+	assert.EqualError(t, err, "variable value for key count is invalid or wrong type")
+	_, err = fake.GetFeatureVariableInteger("checkout", "count", freeUser)
+	assert.EqualError(t, err, "variable value for key count is invalid or wrong type")
+
+	enabled, variables, err := fake.GetAllFeatureVariables("checkout", premiumUser)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, map[string]interface{}{"title": "Pay now", "count": 3, "ratio": 0.5, "beta": true}, variables)
+}
+
+func TestUnknownFeature(t *testing.T) {
+	fake := NewClient()
+
+	enabled, err := fake.IsFeatureEnabled("unknown", freeUser)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	enabled, variables, err := fake.GetAllFeatureVariables("unknown", freeUser)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Empty(t, variables)
+}
+
+func TestLatestMatchingDeclarationApplies(t *testing.T) {
+	fake := NewClient()
+	fake.EnableFeature("search", nil)
+	fake.DisableFeature("search", nil, UserID("user_1", "user_3"))
+	fake.EnableFeature("search", nil, UserID("user_1"), Attribute("age", 30))
+
+	enabled, _ := fake.IsFeatureEnabled("search", freeUser)
+	assert.True(t, enabled)
+	enabled, _ = fake.IsFeatureEnabled("search", premiumUser)
+	assert.True(t, enabled)
+	enabled, _ = fake.IsFeatureEnabled("search", entities.UserContext{ID: "user_3"})
+	assert.False(t, enabled)
+	enabled, _ = fake.IsFeatureEnabled("search", entities.UserContext{ID: "user_1", Attributes: map[string]interface{}{"age": 31}})
+	assert.False(t, enabled)
+}
+
+func TestGetEnabledFeatures(t *testing.T) {
+	fake := NewClient()
+	fake.EnableFeature("search", nil)
+	fake.EnableFeature("checkout", nil, Attribute("plan", "premium"))
+	fake.DisableFeature("beta", nil)
+
+	features, err := fake.GetEnabledFeatures(freeUser)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"search"}, features)
+	features, err = fake.GetEnabledFeatures(premiumUser)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"checkout", "search"}, features)
+}
+
+func TestVariationsAndActivations(t *testing.T) {
+	fake := NewClient()
+	fake.SetVariation("pricing", "control")
+	fake.SetVariation("pricing", "discount", Attribute("plan", "premium"))
+	fake.SetVariation("pricing", "", UserID("excluded"))
+
+	variation, err := fake.GetVariation("pricing", freeUser)
+	assert.NoError(t, err)
+	assert.Equal(t, "control", variation)
+	assert.Empty(t, fake.Activations())
+
+	variation, err = fake.Activate("pricing", premiumUser)
+	assert.NoError(t, err)
+	assert.Equal(t, "discount", variation)
+	variation, err = fake.Activate("pricing", entities.UserContext{ID: "excluded"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", variation)
+	variation, err = fake.Activate("unknown", premiumUser)
+	assert.NoError(t, err)
+	assert.Equal(t, "", variation)
+
+	assert.Equal(t, []Activation{{ExperimentKey: "pricing", VariationKey: "discount", UserContext: premiumUser}}, fake.Activations())
+	fake.Reset()
+	assert.Empty(t, fake.Activations())
+}
+
+func TestTracks(t *testing.T) {
+	fake := NewClient()
+	var notified []string
+	id, err := fake.OnTrack(func(eventKey string, userContext entities.UserContext, eventTags map[string]interface{}, conversionEvent event.ConversionEvent) {
+		notified = append(notified, eventKey)
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, fake.Track("purchase", freeUser, map[string]interface{}{"revenue": 100}))
+	assert.NoError(t, fake.RemoveOnTrack(id))
+	assert.NoError(t, fake.Track("signup", premiumUser, nil))
+
+	assert.Equal(t, []TrackedEvent{
+		{EventKey: "purchase", UserContext: freeUser, EventTags: map[string]interface{}{"revenue": 100}},
+		{EventKey: "signup", UserContext: premiumUser},
+	}, fake.Tracks())
+	assert.Equal(t, []string{"purchase"}, notified)
+}
+
+func TestGetOptimizelyConfig(t *testing.T) {
+	fake := NewClient()
+	fake.DisableFeature("checkout", Variables{"title": "Checkout"})
+	fake.EnableFeature("checkout", Variables{"count": 3}, UserID("user_1"))
+	fake.SetVariation("pricing", "control")
+	fake.SetVariation("pricing", "discount", UserID("user_1"))
+
+	optimizelyConfig := fake.GetOptimizelyConfig()
+	assert.Equal(t, Revision, optimizelyConfig.Revision)
+	assert.Equal(t, "integer", optimizelyConfig.FeaturesMap["checkout"].VariablesMap["count"].Type)
+	assert.Equal(t, "Checkout", optimizelyConfig.FeaturesMap["checkout"].VariablesMap["title"].Value)
+	assert.Len(t, optimizelyConfig.ExperimentsMap["pricing"].VariationsMap, 2)
+}
+
+func TestUnsupportedVariableValue(t *testing.T) {
+	fake := NewClient()
+	fake.EnableFeature("checkout", Variables{"items": []string{"a"}})
+
+	_, _, err := fake.GetFeatureVariable("checkout", "items", freeUser)
+	assert.EqualError(t, err, "unsupported variable value type []string")
+}
+
+// the code under test depends on the client.Client interface
+func isCheckoutEnabled(optimizelyClient client.Client, userContext entities.UserContext) bool {
+	enabled, err := optimizelyClient.IsFeatureEnabled("checkout", userContext)
+	return err == nil && enabled
+}
+
+func TestFakeReplacesOptimizelyClient(t *testing.T) {
+	fake := NewClient()
+	fake.EnableFeature("checkout", nil, UserID("user_2"))
+
+	assert.False(t, isCheckoutEnabled(fake, freeUser))
+	assert.True(t, isCheckoutEnabled(fake, premiumUser))
+}
@@ -0,0 +1,652 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package optimizelytest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
+	datafileEntities "github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig/entities"
+	"github.com/optimizely/go-sdk/pkg/entities"
+)
+
+// DatafileVersion is the version of the datafiles built by the DatafileBuilder
+const DatafileVersion = "4"
+
+// maxEndOfRange is the end of range of the traffic allocations for 100% of the traffic
+const maxEndOfRange = 10000
+
+// Condition returns an audience condition on a custom attribute, such as Condition("country", "exact", "us")
+func Condition(attribute, match string, value interface{}) map[string]interface{} {
+	condition := map[string]interface{}{"type": "custom_attribute", "name": attribute, "match": match}
+	if value != nil {
+		condition["value"] = value
+	}
+	return condition
+}
+
+// And returns the audience conditions matching when all the conditions do
+func And(conditions ...interface{}) []interface{} {
+	return append([]interface{}{"and"}, conditions...)
+}
+
+// Or returns the audience conditions matching when any of the conditions does
+func Or(conditions ...interface{}) []interface{} {
+	return append([]interface{}{"or"}, conditions...)
+}
+
+// Not returns the audience conditions matching when the condition doesn't
+func Not(condition interface{}) []interface{} {
+	return []interface{}{"not", condition}
+}
+
+// VariationSpec declares a variation of an experiment with its share of the traffic
+type VariationSpec struct {
+	key        string
+	percentage float64
+	enabled    bool
+	values     map[string]string
+}
+
+// Variation declares a variation receiving the percentage of the traffic of the experiment
+func Variation(key string, percentage float64) VariationSpec {
+	return VariationSpec{key: key, percentage: percentage}
+}
+
+// Enabled enables the feature for the variation of a feature experiment
+func (v VariationSpec) Enabled() VariationSpec {
+	v.enabled = true
+	return v
+}
+
+// Value sets the value of a feature variable for the variation, formatted as in a datafile
+func (v VariationSpec) Value(variableKey, value string) VariationSpec {
+	v.values = withValue(v.values, variableKey, value)
+	return v
+}
+
+// RuleSpec declares a rule of the rollout of a feature
+type RuleSpec struct {
+	percentage float64
+	audiences  []string
+	disabled   bool
+	values     map[string]string
+}
+
+// Rule declares a rollout rule enabling the feature for the percentage of the users it targets
+func Rule(percentage float64) RuleSpec {
+	return RuleSpec{percentage: percentage}
+}
+
+// Audiences targets the rule to the users of any of the audiences
+func (r RuleSpec) Audiences(audienceKeys ...string) RuleSpec {
+	r.audiences = append(append([]string{}, r.audiences...), audienceKeys...)
+	return r
+}
+
+// Disabled makes the rule disable the feature for the users it targets
+func (r RuleSpec) Disabled() RuleSpec {
+	r.disabled = true
+	return r
+}
+
+// Value sets the value of a feature variable for the users of the rule, formatted as in a datafile
+func (r RuleSpec) Value(variableKey, value string) RuleSpec {
+	r.values = withValue(r.values, variableKey, value)
+	return r
+}
+
+func withValue(values map[string]string, variableKey, value string) map[string]string {
+	copied := map[string]string{variableKey: value}
+	for key, v := range values {
+		if key != variableKey {
+			copied[key] = v
+		}
+	}
+	return copied
+}
+
+type audienceSpec struct {
+	key        string
+	conditions interface{}
+}
+
+type experimentSpec struct {
+	key              string
+	variations       []VariationSpec
+	audiences        []string
+	forcedVariations map[string]string
+}
+
+type variableSpec struct {
+	key          string
+	variableType entities.VariableType
+	defaultValue string
+}
+
+type featureSpec struct {
+	key         string
+	variables   []variableSpec
+	experiments []*experimentSpec
+	rollout     []RuleSpec
+}
+
+// DatafileBuilder builds valid v4 datafiles for tests. The IDs of the entities are generated in the order they are
+// declared, and the traffic allocations are computed from the percentages of the variations and of the rules.
+type DatafileBuilder struct {
+	revision     string
+	anonymizeIP  bool
+	botFiltering bool
+	attributes   []string
+	audiences    []audienceSpec
+	events       []string
+	experiments  []*experimentSpec
+	features     []*featureSpec
+}
+
+// NewDatafile returns a builder of a datafile with no entities
+func NewDatafile() *DatafileBuilder {
+	return &DatafileBuilder{revision: "1"}
+}
+
+// Revision sets the revision of the datafile
+func (b *DatafileBuilder) Revision(revision string) *DatafileBuilder {
+	b.revision = revision
+	return b
+}
+
+// AnonymizeIP sets whether the IP of the users is anonymized in the events
+func (b *DatafileBuilder) AnonymizeIP(anonymizeIP bool) *DatafileBuilder {
+	b.anonymizeIP = anonymizeIP
+	return b
+}
+
+// BotFiltering sets whether the events of bots are filtered
+func (b *DatafileBuilder) BotFiltering(botFiltering bool) *DatafileBuilder {
+	b.botFiltering = botFiltering
+	return b
+}
+
+// Attribute declares attributes. The attributes of the audience conditions are declared with the audiences.
+func (b *DatafileBuilder) Attribute(attributeKeys ...string) *DatafileBuilder {
+	b.attributes = append(b.attributes, attributeKeys...)
+	return b
+}
+
+// Audience declares an audience of the users matching the conditions, built with Condition, And, Or and Not
+func (b *DatafileBuilder) Audience(key string, conditions interface{}) *DatafileBuilder {
+	b.audiences = append(b.audiences, audienceSpec{key: key, conditions: conditions})
+	return b
+}
+
+// Event declares events, which are tracked for all the experiments
+func (b *DatafileBuilder) Event(eventKeys ...string) *DatafileBuilder {
+	b.events = append(b.events, eventKeys...)
+	return b
+}
+
+// Experiment declares an A/B test with the variations
+func (b *DatafileBuilder) Experiment(key string, variations ...VariationSpec) *ExperimentBuilder {
+	experiment := &experimentSpec{key: key, variations: variations}
+	b.experiments = append(b.experiments, experiment)
+	return &ExperimentBuilder{DatafileBuilder: b, experiment: experiment}
+}
+
+// Feature declares a feature, with no variables, experiments nor rollout
+func (b *DatafileBuilder) Feature(key string) *FeatureBuilder {
+	feature := &featureSpec{key: key}
+	b.features = append(b.features, feature)
+	return &FeatureBuilder{DatafileBuilder: b, feature: feature}
+}
+
+// ExperimentBuilder declares the targeting of an A/B test
+type ExperimentBuilder struct {
+	*DatafileBuilder
+	experiment *experimentSpec
+}
+
+// Audiences targets the experiment to the users of any of the audiences
+func (b *ExperimentBuilder) Audiences(audienceKeys ...string) *ExperimentBuilder {
+	b.experiment.audiences = append(b.experiment.audiences, audienceKeys...)
+	return b
+}
+
+// ForcedVariation forces the variation of the user
+func (b *ExperimentBuilder) ForcedVariation(userID, variationKey string) *ExperimentBuilder {
+	b.experiment.forceVariation(userID, variationKey)
+	return b
+}
+
+// FeatureBuilder declares the variables, the experiments and the rollout of a feature
+type FeatureBuilder struct {
+	*DatafileBuilder
+	feature *featureSpec
+}
+
+// Variable declares a variable of the feature, with its default value formatted as in a datafile
+func (b *FeatureBuilder) Variable(key string, variableType entities.VariableType, defaultValue string) *FeatureBuilder {
+	b.feature.variables = append(b.feature.variables, variableSpec{key: key, variableType: variableType, defaultValue: defaultValue})
+	return b
+}
+
+// Experiment declares a feature test with the variations
+func (b *FeatureBuilder) Experiment(key string, variations ...VariationSpec) *FeatureExperimentBuilder {
+	experiment := &experimentSpec{key: key, variations: variations}
+	b.feature.experiments = append(b.feature.experiments, experiment)
+	return &FeatureExperimentBuilder{FeatureBuilder: b, experiment: experiment}
+}
+
+// Rollout sets the rules of the rollout of the feature, evaluated in order
+func (b *FeatureBuilder) Rollout(rules ...RuleSpec) *FeatureBuilder {
+	b.feature.rollout = rules
+	return b
+}
+
+// FeatureExperimentBuilder declares the targeting of a feature test
+type FeatureExperimentBuilder struct {
+	*FeatureBuilder
+	experiment *experimentSpec
+}
+
+// Audiences targets the feature test to the users of any of the audiences
+func (b *FeatureExperimentBuilder) Audiences(audienceKeys ...string) *FeatureExperimentBuilder {
+	b.experiment.audiences = append(b.experiment.audiences, audienceKeys...)
+	return b
+}
+
+// ForcedVariation forces the variation of the user
+func (b *FeatureExperimentBuilder) ForcedVariation(userID, variationKey string) *FeatureExperimentBuilder {
+	b.experiment.forceVariation(userID, variationKey)
+	return b
+}
+
+func (e *experimentSpec) forceVariation(userID, variationKey string) {
+	if e.forcedVariations == nil {
+		e.forcedVariations = map[string]string{}
+	}
+	e.forcedVariations[userID] = variationKey
+}
+
+// Build returns the JSON of the datafile, once validated by parsing it into a project config
+func (b *DatafileBuilder) Build() ([]byte, error) {
+	datafile, err := b.datafile()
+	if err != nil {
+		return nil, err
+	}
+	jsonDatafile, err := json.MarshalIndent(datafile, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := datafileprojectconfig.NewDatafileProjectConfig(jsonDatafile); err != nil {
+		return nil, fmt.Errorf("invalid datafile: %v", err)
+	}
+	return jsonDatafile, nil
+}
+
+// ProjectConfig returns the project config of the datafile
+func (b *DatafileBuilder) ProjectConfig() (*datafileprojectconfig.DatafileProjectConfig, error) {
+	jsonDatafile, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return datafileprojectconfig.NewDatafileProjectConfig(jsonDatafile)
+}
+
+// datafileAssembler generates the IDs of the entities of a datafile, and collects the errors of the declarations
+type datafileAssembler struct {
+	lastID      int
+	audienceIDs map[string]string
+	errs        []string
+}
+
+func (a *datafileAssembler) newID() string {
+	a.lastID++
+	return strconv.Itoa(a.lastID)
+}
+
+func (a *datafileAssembler) errorf(format string, args ...interface{}) {
+	a.errs = append(a.errs, fmt.Sprintf(format, args...))
+}
+
+func (b *DatafileBuilder) datafile() (datafileEntities.Datafile, error) {
+	a := &datafileAssembler{lastID: 10000, audienceIDs: map[string]string{}}
+	datafile := datafileEntities.Datafile{
+		Attributes:     []datafileEntities.Attribute{},
+		Audiences:      []datafileEntities.Audience{},
+		Experiments:    []datafileEntities.Experiment{},
+		Groups:         []datafileEntities.Group{},
+		FeatureFlags:   []datafileEntities.FeatureFlag{},
+		Events:         []datafileEntities.Event{},
+		Rollouts:       []datafileEntities.Rollout{},
+		TypedAudiences: []datafileEntities.Audience{},
+		Variables:      []string{},
+		AccountID:      "10000",
+		ProjectID:      "20000",
+		Revision:       b.revision,
+		Version:        DatafileVersion,
+		AnonymizeIP:    b.anonymizeIP,
+		BotFiltering:   b.botFiltering,
+	}
+
+	attributes := map[string]bool{}
+	addAttribute := func(attributeKey string) {
+		if !attributes[attributeKey] {
+			attributes[attributeKey] = true
+			datafile.Attributes = append(datafile.Attributes, datafileEntities.Attribute{ID: a.newID(), Key: attributeKey})
+		}
+	}
+	for _, attributeKey := range b.attributes {
+		addAttribute(attributeKey)
+	}
+
+	for _, audience := range b.audiences {
+		if _, ok := a.audienceIDs[audience.key]; ok {
+			a.errorf(`audience "%s" is declared twice`, audience.key)
+			continue
+		}
+		attributeKeys, err := conditionAttributes(audience.conditions)
+		if err != nil {
+			a.errorf(`audience "%s": %v`, audience.key, err)
+			continue
+		}
+		for _, attributeKey := range attributeKeys {
+			addAttribute(attributeKey)
+		}
+		id := a.newID()
+		a.audienceIDs[audience.key] = id
+		datafile.TypedAudiences = append(datafile.TypedAudiences, datafileEntities.Audience{ID: id, Name: audience.key, Conditions: audience.conditions})
+	}
+
+	experimentKeys := map[string]bool{}
+	var experimentIDs []string
+	addExperiment := func(experiment *experimentSpec, feature *featureSpec) string {
+		if experimentKeys[experiment.key] {
+			a.errorf(`experiment "%s" is declared twice`, experiment.key)
+		}
+		experimentKeys[experiment.key] = true
+		layerID := a.newID()
+		datafileExperiment := a.experiment(experiment.key, layerID, experiment.variations, experiment.audiences, feature)
+		for userID, variationKey := range experiment.forcedVariations {
+			if !hasVariation(experiment.variations, variationKey) {
+				a.errorf(`experiment "%s": forced variation "%s" of user "%s" is not declared`, experiment.key, variationKey, userID)
+			}
+			datafileExperiment.ForcedVariations[userID] = variationKey
+		}
+		datafile.Experiments = append(datafile.Experiments, datafileExperiment)
+		experimentIDs = append(experimentIDs, datafileExperiment.ID)
+		return datafileExperiment.ID
+	}
+	for _, experiment := range b.experiments {
+		addExperiment(experiment, nil)
+	}
+
+	featureKeys := map[string]bool{}
+	for _, feature := range b.features {
+		if featureKeys[feature.key] {
+			a.errorf(`feature "%s" is declared twice`, feature.key)
+		}
+		featureKeys[feature.key] = true
+
+		featureFlag := datafileEntities.FeatureFlag{
+			ID:            a.newID(),
+			Key:           feature.key,
+			ExperimentIDs: []string{},
+			Variables:     []datafileEntities.Variable{},
+		}
+		variableKeys := map[string]bool{}
+		for _, variable := range feature.variables {
+			if variableKeys[variable.key] {
+				a.errorf(`feature "%s": variable "%s" is declared twice`, feature.key, variable.key)
+			}
+			variableKeys[variable.key] = true
+			if err := checkVariableValue(variable.variableType, variable.defaultValue); err != nil {
+				a.errorf(`feature "%s": default value of variable "%s": %v`, feature.key, variable.key, err)
+			}
+			featureFlag.Variables = append(featureFlag.Variables, datafileEntities.Variable{
+				ID:           a.newID(),
+				Key:          variable.key,
+				Type:         variable.variableType,
+				DefaultValue: variable.defaultValue,
+			})
+		}
+
+		for _, experiment := range feature.experiments {
+			featureFlag.ExperimentIDs = append(featureFlag.ExperimentIDs, addExperiment(experiment, feature))
+		}
+
+		if len(feature.rollout) > 0 {
+			rollout := datafileEntities.Rollout{ID: a.newID()}
+			for i, rule := range feature.rollout {
+				variation := Variation("on", rule.percentage).Enabled()
+				if rule.disabled {
+					variation = Variation("off", rule.percentage)
+				}
+				variation.values = rule.values
+				key := fmt.Sprintf("%s_rule_%d", feature.key, i+1)
+				rollout.Experiments = append(rollout.Experiments, a.experiment(key, rollout.ID, []VariationSpec{variation}, rule.audiences, feature))
+			}
+			featureFlag.RolloutID = rollout.ID
+			datafile.Rollouts = append(datafile.Rollouts, rollout)
+		}
+		datafile.FeatureFlags = append(datafile.FeatureFlags, featureFlag)
+	}
+
+	// the variable IDs are only known once the feature is built
+	for i := range datafile.FeatureFlags {
+		a.resolveVariableIDs(&datafile, datafile.FeatureFlags[i])
+	}
+
+	for _, eventKey := range b.events {
+		datafile.Events = append(datafile.Events, datafileEntities.Event{
+			ID:            a.newID(),
+			Key:           eventKey,
+			ExperimentIds: append([]string{}, experimentIDs...),
+		})
+	}
+
+	if len(a.errs) > 0 {
+		return datafile, errors.New(strings.Join(a.errs, "; "))
+	}
+	return datafile, nil
+}
+
+// experiment returns the experiment with the traffic allocated to the variations by their percentages. The values of
+// the variables of the variations are keyed by variable key until the variable IDs are resolved.
+func (a *datafileAssembler) experiment(key, layerID string, variations []VariationSpec, audienceKeys []string, feature *featureSpec) datafileEntities.Experiment {
+	experiment := datafileEntities.Experiment{
+		ID:                a.newID(),
+		Key:               key,
+		LayerID:           layerID,
+		Status:            "Running",
+		Variations:        []datafileEntities.Variation{},
+		TrafficAllocation: []datafileEntities.TrafficAllocation{},
+		AudienceIds:       []string{},
+		ForcedVariations:  map[string]string{},
+	}
+	if len(variations) == 0 {
+		a.errorf(`experiment "%s" has no variations`, key)
+	}
+
+	for _, audienceKey := range audienceKeys {
+		audienceID, ok := a.audienceIDs[audienceKey]
+		if !ok {
+			a.errorf(`experiment "%s": audience "%s" is not declared`, key, audienceKey)
+			continue
+		}
+		experiment.AudienceIds = append(experiment.AudienceIds, audienceID)
+	}
+
+	total := 0.0
+	variationKeys := map[string]bool{}
+	for _, variation := range variations {
+		if variationKeys[variation.key] {
+			a.errorf(`experiment "%s": variation "%s" is declared twice`, key, variation.key)
+		}
+		variationKeys[variation.key] = true
+		if variation.percentage < 0 {
+			a.errorf(`experiment "%s": variation "%s" has a negative percentage`, key, variation.key)
+		}
+		if feature == nil && (variation.enabled || len(variation.values) > 0) {
+			a.errorf(`experiment "%s": variation "%s" sets a feature but the experiment is not a feature test`, key, variation.key)
+		}
+
+		datafileVariation := datafileEntities.Variation{
+			ID:             a.newID(),
+			Key:            variation.key,
+			FeatureEnabled: variation.enabled,
+			Variables:      []datafileEntities.VariationVariable{},
+		}
+		for _, variableKey := range sortedKeys(variation.values) {
+			datafileVariation.Variables = append(datafileVariation.Variables, datafileEntities.VariationVariable{ID: variableKey, Value: variation.values[variableKey]})
+		}
+		experiment.Variations = append(experiment.Variations, datafileVariation)
+
+		if variation.percentage > 0 {
+			total += variation.percentage
+			experiment.TrafficAllocation = append(experiment.TrafficAllocation, datafileEntities.TrafficAllocation{
+				EntityID:   datafileVariation.ID,
+				EndOfRange: int(math.Round(total * maxEndOfRange / 100)),
+			})
+		}
+	}
+	if total > 100 {
+		a.errorf(`experiment "%s": the percentages of the variations add up to %v%%`, key, total)
+	}
+	return experiment
+}
+
+// resolveVariableIDs replaces the variable keys of the variations of the feature with the variable IDs
+func (a *datafileAssembler) resolveVariableIDs(datafile *datafileEntities.Datafile, featureFlag datafileEntities.FeatureFlag) {
+	variables := map[string]datafileEntities.Variable{}
+	for _, variable := range featureFlag.Variables {
+		variables[variable.Key] = variable
+	}
+	resolve := func(experiment *datafileEntities.Experiment) {
+		for _, variation := range experiment.Variations {
+			// the variables of the copied variation are the ones of the experiment
+			for i, variationVariable := range variation.Variables {
+				variable, ok := variables[variationVariable.ID]
+				if !ok {
+					a.errorf(`feature "%s": variable "%s" of variation "%s" is not declared`, featureFlag.Key, variationVariable.ID, variation.Key)
+					continue
+				}
+				if err := checkVariableValue(variable.Type, variationVariable.Value); err != nil {
+					a.errorf(`feature "%s": value of variable "%s" of variation "%s": %v`, featureFlag.Key, variable.Key, variation.Key, err)
+				}
+				variation.Variables[i].ID = variable.ID
+			}
+		}
+	}
+
+	experimentIDs := map[string]bool{}
+	for _, experimentID := range featureFlag.ExperimentIDs {
+		experimentIDs[experimentID] = true
+	}
+	for i := range datafile.Experiments {
+		if experimentIDs[datafile.Experiments[i].ID] {
+			resolve(&datafile.Experiments[i])
+		}
+	}
+	for i := range datafile.Rollouts {
+		if datafile.Rollouts[i].ID == featureFlag.RolloutID {
+			for j := range datafile.Rollouts[i].Experiments {
+				resolve(&datafile.Rollouts[i].Experiments[j])
+			}
+		}
+	}
+}
+
+func hasVariation(variations []VariationSpec, variationKey string) bool {
+	for _, variation := range variations {
+		if variation.key == variationKey {
+			return true
+		}
+	}
+	return false
+}
+
+func checkVariableValue(variableType entities.VariableType, value string) (err error) {
+	switch variableType {
+	case entities.Boolean:
+		_, err = strconv.ParseBool(value)
+	case entities.Double:
+		_, err = strconv.ParseFloat(value, 64)
+	case entities.Integer:
+		_, err = strconv.Atoi(value)
+	case entities.String:
+	default:
+		err = fmt.Errorf(`unknown type "%s"`, variableType)
+	}
+	return err
+}
+
+// supportedMatches are the match types of the conditions the SDK evaluates
+var supportedMatches = map[string]bool{"exact": true, "exists": true, "gt": true, "lt": true, "substring": true}
+
+// conditionAttributes returns the attributes of the conditions, checking that they are made of supported conditions and
+// of the and, or and not operators
+func conditionAttributes(conditions interface{}) ([]string, error) {
+	var attributeKeys []string
+	var walk func(conditions interface{}) error
+	walk = func(conditions interface{}) error {
+		switch c := conditions.(type) {
+		case map[string]interface{}:
+			name, ok := c["name"].(string)
+			if !ok || name == "" {
+				return fmt.Errorf("condition %v has no attribute name", c)
+			}
+			if match, ok := c["match"].(string); ok && !supportedMatches[match] {
+				return fmt.Errorf(`condition on "%s" has an unsupported match "%s"`, name, match)
+			}
+			attributeKeys = append(attributeKeys, name)
+		case []interface{}:
+			for i, condition := range c {
+				if operator, ok := condition.(string); ok && i == 0 {
+					if operator != "and" && operator != "or" && operator != "not" {
+						return fmt.Errorf(`unknown operator "%s"`, operator)
+					}
+					continue
+				}
+				if err := walk(condition); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("invalid conditions %v", conditions)
+		}
+		return nil
+	}
+	if err := walk(conditions); err != nil {
+		return nil, err
+	}
+	return attributeKeys, nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
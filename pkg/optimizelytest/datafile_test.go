@@ -0,0 +1,248 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package optimizelytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/config"
+	datafileEntities "github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig/entities"
+	"github.com/optimizely/go-sdk/pkg/entities"
+)
+
+func TestDatafileBuilderBuildsDatafile(t *testing.T) {
+	jsonDatafile, err := NewDatafile().
+		Revision("42").
+		Attribute("plan").
+		Audience("us", Condition("country", "exact", "us")).
+		Event("purchase").
+		Experiment("ab_test", Variation("control", 33.33), Variation("treatment", 33.33), Variation("excluded", 0)).
+		Audiences("us").
+		ForcedVariation("qa", "treatment").
+		Feature("checkout").
+		Variable("count", entities.Integer, "1").
+		Experiment("checkout_test", Variation("off", 50), Variation("on", 50).Enabled().Value("count", "2")).
+		Rollout(Rule(100).Audiences("us").Value("count", "3"), Rule(20)).
+		Build()
+	require.NoError(t, err)
+
+	var datafile datafileEntities.Datafile
+	require.NoError(t, json.Unmarshal(jsonDatafile, &datafile))
+	assert.Equal(t, "4", datafile.Version)
+	assert.Equal(t, "42", datafile.Revision)
+	assert.Equal(t, []datafileEntities.Attribute{{ID: "10001", Key: "plan"}, {ID: "10002", Key: "country"}}, datafile.Attributes)
+	require.Len(t, datafile.TypedAudiences, 1)
+	assert.Equal(t, "us", datafile.TypedAudiences[0].Name)
+
+	require.Len(t, datafile.Experiments, 2)
+	abTest := datafile.Experiments[0]
+	assert.Equal(t, "ab_test", abTest.Key)
+	assert.Equal(t, []string{datafile.TypedAudiences[0].ID}, abTest.AudienceIds)
+	assert.Equal(t, map[string]string{"qa": "treatment"}, abTest.ForcedVariations)
+	assert.Len(t, abTest.Variations, 3)
+	assert.Equal(t, []datafileEntities.TrafficAllocation{
+		{EntityID: abTest.Variations[0].ID, EndOfRange: 3333},
+		{EntityID: abTest.Variations[1].ID, EndOfRange: 6666},
+	}, abTest.TrafficAllocation)
+
+	require.Len(t, datafile.FeatureFlags, 1)
+	feature := datafile.FeatureFlags[0]
+	checkoutTest := datafile.Experiments[1]
+	assert.Equal(t, []string{checkoutTest.ID}, feature.ExperimentIDs)
+	assert.Equal(t, []datafileEntities.VariationVariable{{ID: feature.Variables[0].ID, Value: "2"}}, checkoutTest.Variations[1].Variables)
+	assert.True(t, checkoutTest.Variations[1].FeatureEnabled)
+	assert.False(t, checkoutTest.Variations[0].FeatureEnabled)
+
+	require.Len(t, datafile.Rollouts, 1)
+	rollout := datafile.Rollouts[0]
+	assert.Equal(t, rollout.ID, feature.RolloutID)
+	require.Len(t, rollout.Experiments, 2)
+	assert.Equal(t, rollout.ID, rollout.Experiments[0].LayerID)
+	assert.Equal(t, 10000, rollout.Experiments[0].TrafficAllocation[0].EndOfRange)
+	assert.Equal(t, 2000, rollout.Experiments[1].TrafficAllocation[0].EndOfRange)
+	assert.Empty(t, rollout.Experiments[1].AudienceIds)
+
+	require.Len(t, datafile.Events, 1)
+	assert.Equal(t, []string{abTest.ID, checkoutTest.ID}, datafile.Events[0].ExperimentIds)
+}
+
+func TestDatafileBuilderDecisions(t *testing.T) {
+	projectConfig, err := NewDatafile().
+		Audience("us", Condition("country", "exact", "us")).
+		Audience("adults", And(Condition("age", "gt", 17), Not(Condition("banned", "exists", nil)))).
+		Experiment("ab_test", Variation("control", 0), Variation("treatment", 100)).
+		Audiences("adults").
+		ForcedVariation("qa", "control").
+		Feature("checkout").
+		Variable("count", entities.Integer, "1").
+		Variable("title", entities.String, "Checkout").
+		Rollout(Rule(100).Audiences("us").Value("count", "3"), Rule(100).Disabled().Value("title", "Unused")).
+		Feature("search").
+		Rollout(Rule(100)).
+		ProjectConfig()
+	require.NoError(t, err)
+
+	optimizelyClient, err := (&client.OptimizelyFactory{}).Client(client.WithConfigManager(config.NewStaticProjectConfigManager(projectConfig)))
+	require.NoError(t, err)
+	defer optimizelyClient.Close()
+
+	us := entities.UserContext{ID: "user_1", Attributes: map[string]interface{}{"country": "us", "age": 30}}
+	other := entities.UserContext{ID: "user_2", Attributes: map[string]interface{}{"country": "fr", "age": 30, "banned": true}}
+
+	enabled, variables, err := optimizelyClient.GetAllFeatureVariables("checkout", us)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, map[string]interface{}{"count": 3, "title": "Checkout"}, variables)
+
+	enabled, variables, err = optimizelyClient.GetAllFeatureVariables("checkout", other)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Equal(t, map[string]interface{}{"count": 1, "title": "Checkout"}, variables)
+
+	features, err := optimizelyClient.GetEnabledFeatures(other)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"search"}, features)
+
+	variation, err := optimizelyClient.GetVariation("ab_test", us)
+	assert.NoError(t, err)
+	assert.Equal(t, "treatment", variation)
+	variation, err = optimizelyClient.GetVariation("ab_test", other)
+	assert.NoError(t, err)
+	assert.Equal(t, "", variation)
+	variation, err = optimizelyClient.GetVariation("ab_test", entities.UserContext{ID: "qa"})
+	assert.NoError(t, err)
+	assert.Equal(t, "control", variation)
+}
+
+func TestDatafileBuilderAllocatesTraffic(t *testing.T) {
+	projectConfig, err := NewDatafile().
+		Experiment("split", Variation("a", 25), Variation("b", 25)).
+		ProjectConfig()
+	require.NoError(t, err)
+
+	optimizelyClient, err := (&client.OptimizelyFactory{}).Client(client.WithConfigManager(config.NewStaticProjectConfigManager(projectConfig)))
+	require.NoError(t, err)
+	defer optimizelyClient.Close()
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		variation, err := optimizelyClient.GetVariation("split", entities.UserContext{ID: fmt.Sprintf("user_%d", i)})
+		require.NoError(t, err)
+		counts[variation]++
+	}
+	assert.InDelta(t, 500, counts["a"], 75)
+	assert.InDelta(t, 500, counts["b"], 75)
+	assert.InDelta(t, 1000, counts[""], 100)
+}
+
+func TestDatafileBuilderErrors(t *testing.T) {
+	scenarios := []struct {
+		builder *DatafileBuilder
+		err     string
+	}{
+		{
+			NewDatafile().Experiment("split", Variation("a", 60), Variation("b", 50)).DatafileBuilder,
+			`experiment "split": the percentages of the variations add up to 110%`,
+		},
+		{
+			NewDatafile().Experiment("split", Variation("a", -1)).DatafileBuilder,
+			`experiment "split": variation "a" has a negative percentage`,
+		},
+		{
+			NewDatafile().Experiment("split").DatafileBuilder,
+			`experiment "split" has no variations`,
+		},
+		{
+			NewDatafile().Experiment("split", Variation("a", 50)).Audiences("unknown").DatafileBuilder,
+			`experiment "split": audience "unknown" is not declared`,
+		},
+		{
+			NewDatafile().Experiment("split", Variation("a", 50)).ForcedVariation("qa", "b").DatafileBuilder,
+			`experiment "split": forced variation "b" of user "qa" is not declared`,
+		},
+		{
+			NewDatafile().Experiment("split", Variation("a", 50).Enabled()).DatafileBuilder,
+			`experiment "split": variation "a" sets a feature but the experiment is not a feature test`,
+		},
+		{
+			NewDatafile().Experiment("split", Variation("a", 50), Variation("a", 50)).DatafileBuilder,
+			`experiment "split": variation "a" is declared twice`,
+		},
+		{
+			NewDatafile().Feature("f").Variable("x", entities.Integer, "one").DatafileBuilder,
+			`feature "f": default value of variable "x": strconv.Atoi: parsing "one": invalid syntax`,
+		},
+		{
+			NewDatafile().Feature("f").Variable("x", "list", "[]").DatafileBuilder,
+			`feature "f": default value of variable "x": unknown type "list"`,
+		},
+		{
+			NewDatafile().Feature("f").Rollout(Rule(100).Value("x", "2")).DatafileBuilder,
+			`feature "f": variable "x" of variation "on" is not declared`,
+		},
+		{
+			NewDatafile().Feature("f").Variable("x", entities.Boolean, "true").Rollout(Rule(100).Value("x", "2")).DatafileBuilder,
+			`feature "f": value of variable "x" of variation "on": strconv.ParseBool: parsing "2": invalid syntax`,
+		},
+		{
+			NewDatafile().Feature("f").Feature("f").DatafileBuilder,
+			`feature "f" is declared twice`,
+		},
+		{
+			NewDatafile().Audience("a", Condition("x", "exact", 1)).Audience("a", Condition("x", "exact", 2)),
+			`audience "a" is declared twice`,
+		},
+		{
+			NewDatafile().Audience("a", []interface{}{"xor", Condition("x", "exact", 1)}),
+			`audience "a": unknown operator "xor"`,
+		},
+		{
+			NewDatafile().Audience("a", Condition("x", "ge", 1)),
+			`audience "a": condition on "x" has an unsupported match "ge"`,
+		},
+		{
+			NewDatafile().Audience("a", "x == 1"),
+			`audience "a": invalid conditions x == 1`,
+		},
+		{
+			NewDatafile().Experiment("split", Variation("a", 50)).Feature("f").Experiment("split", Variation("on", 50)).DatafileBuilder,
+			`experiment "split" is declared twice`,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		_, err := scenario.builder.Build()
+		assert.EqualError(t, err, scenario.err)
+		_, err = scenario.builder.ProjectConfig()
+		assert.EqualError(t, err, scenario.err)
+	}
+}
+
+func TestDatafileBuilderReportsAllErrors(t *testing.T) {
+	_, err := NewDatafile().
+		Experiment("split", Variation("a", 60), Variation("b", 50)).
+		Audiences("unknown").
+		Build()
+	assert.EqualError(t, err, `experiment "split": audience "unknown" is not declared; `+
+		`experiment "split": the percentages of the variations add up to 110%`)
+}
@@ -18,15 +18,25 @@
 package registry
 
 import (
+	"sync"
+
 	"github.com/optimizely/go-sdk/pkg/notification"
 )
 
 var notificationCenterCache = make(map[string]notification.Center)
+var notificationCenterLock sync.RWMutex
 
 // GetNotificationCenter returns the notification center instance associated with the given SDK Key or creates a new one if not found
 func GetNotificationCenter(sdkKey string) notification.Center {
-	var notificationCenter notification.Center
-	var ok bool
+	notificationCenterLock.RLock()
+	notificationCenter, ok := notificationCenterCache[sdkKey]
+	notificationCenterLock.RUnlock()
+	if ok {
+		return notificationCenter
+	}
+
+	notificationCenterLock.Lock()
+	defer notificationCenterLock.Unlock()
 	if notificationCenter, ok = notificationCenterCache[sdkKey]; !ok {
 		notificationCenter = notification.NewNotificationCenter()
 		notificationCenterCache[sdkKey] = notificationCenter
@@ -34,3 +44,10 @@ func GetNotificationCenter(sdkKey string) notification.Center {
 
 	return notificationCenter
 }
+
+// SetNotificationCenter sets the notification center instance associated with the given SDK Key
+func SetNotificationCenter(sdkKey string, notificationCenter notification.Center) {
+	notificationCenterLock.Lock()
+	notificationCenterCache[sdkKey] = notificationCenter
+	notificationCenterLock.Unlock()
+}
@@ -17,9 +17,12 @@
 package registry
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
+
+	"github.com/optimizely/go-sdk/pkg/notification"
 )
 
 type ServiceRegistryTestSuite struct {
@@ -36,6 +39,25 @@ func (s *ServiceRegistryTestSuite) TestGetNotificationCenter() {
 	s.Equal(notificationCenter, notificationCenter2)
 }
 
+func (s *ServiceRegistryTestSuite) TestConcurrentGetNotificationCenter() {
+	sdkKey := "sdk_key_concurrent"
+	notificationCenters := make(chan notification.Center, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			notificationCenters <- GetNotificationCenter(sdkKey)
+		}()
+	}
+	wg.Wait()
+	close(notificationCenters)
+
+	for notificationCenter := range notificationCenters {
+		s.Equal(GetNotificationCenter(sdkKey), notificationCenter)
+	}
+}
+
 func TestServiceRegistryTestSuite(t *testing.T) {
 	suite.Run(t, new(ServiceRegistryTestSuite))
 }
@@ -0,0 +1,414 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/event"
+	"github.com/optimizely/go-sdk/pkg/notification"
+	"github.com/optimizely/go-sdk/pkg/remote/decisionpb"
+)
+
+// DefaultTimeout is the default timeout of the calls of the Client
+const DefaultTimeout = 5 * time.Second
+
+// DefaultStreamRetryInterval is the default time the Client waits before subscribing again to the config stream
+const DefaultStreamRetryInterval = 5 * time.Second
+
+// ErrNotSupported is returned for the notifications the Client can't receive, as they happen on the server
+var ErrNotSupported = errors.New("not supported by the remote client")
+
+// ClientOptionFunc is used to configure the Client
+type ClientOptionFunc func(c *Client)
+
+// WithTimeout sets the timeout of the calls
+func WithTimeout(timeout time.Duration) ClientOptionFunc {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithStreamRetryInterval sets the time to wait before subscribing again to the config stream once it fails
+func WithStreamRetryInterval(retryInterval time.Duration) ClientOptionFunc {
+	return func(c *Client) {
+		c.streamRetryInterval = retryInterval
+	}
+}
+
+// Client has the methods of the OptimizelyClient, deciding with a DecisionService server. Errors of the server are
+// returned as they are by the OptimizelyClient, while the ones of the transport are gRPC status errors.
+type Client struct {
+	conn                *grpc.ClientConn
+	service             decisionpb.DecisionServiceClient
+	timeout             time.Duration
+	streamRetryInterval time.Duration
+
+	notificationCenter notification.Center
+	streamOnce         sync.Once
+	ctx                context.Context
+	cancel             context.CancelFunc
+	wg                 sync.WaitGroup
+}
+
+var _ client.Client = &Client{}
+
+// NewClient returns a client calling the server of the connection. The client closes the connection on Close.
+func NewClient(conn *grpc.ClientConn, options ...ClientOptionFunc) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		conn:                conn,
+		service:             decisionpb.NewDecisionServiceClient(conn),
+		timeout:             DefaultTimeout,
+		streamRetryInterval: DefaultStreamRetryInterval,
+		notificationCenter:  notification.NewNotificationCenter(),
+		ctx:                 ctx,
+		cancel:              cancel,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// Activate returns the key of the variation the user is bucketed into and sends an impression event
+func (c *Client) Activate(experimentKey string, userContext entities.UserContext) (result string, err error) {
+	user, err := toPBUser(userContext)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	response, err := c.service.Activate(ctx, &decisionpb.ExperimentRequest{User: user, ExperimentKey: experimentKey})
+	if err != nil {
+		return "", fromStatusError(err)
+	}
+	return response.GetVariationKey(), nil
+}
+
+// IsFeatureEnabled returns true if the feature is enabled for the given user
+func (c *Client) IsFeatureEnabled(featureKey string, userContext entities.UserContext) (result bool, err error) {
+	user, err := toPBUser(userContext)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	response, err := c.service.IsFeatureEnabled(ctx, &decisionpb.FeatureRequest{User: user, FeatureKey: featureKey})
+	if err != nil {
+		return false, fromStatusError(err)
+	}
+	return response.GetEnabled(), nil
+}
+
+// GetEnabledFeatures returns an array containing the keys of all features in the project that are enabled for the given user
+func (c *Client) GetEnabledFeatures(userContext entities.UserContext) (enabledFeatures []string, err error) {
+	user, err := toPBUser(userContext)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	response, err := c.service.GetEnabledFeatures(ctx, &decisionpb.UserRequest{User: user})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	return response.GetFeatureKeys(), nil
+}
+
+// GetFeatureVariableBoolean returns the feature variable value of type bool associated with the given feature and variable keys.
+func (c *Client) GetFeatureVariableBoolean(featureKey, variableKey string, userContext entities.UserContext) (value bool, err error) {
+	val, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return false, err
+	}
+	convertedValue, err := strconv.ParseBool(val)
+	if err != nil || valueType != entities.Boolean {
+		return false, fmt.Errorf("variable value for key %s is invalid or wrong type", variableKey)
+	}
+	return convertedValue, err
+}
+
+// GetFeatureVariableDouble returns the feature variable value of type double associated with the given feature and variable keys.
+func (c *Client) GetFeatureVariableDouble(featureKey, variableKey string, userContext entities.UserContext) (value float64, err error) {
+	val, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return 0, err
+	}
+	convertedValue, err := strconv.ParseFloat(val, 64)
+	if err != nil || valueType != entities.Double {
+		return 0, fmt.Errorf("variable value for key %s is invalid or wrong type", variableKey)
+	}
+	return convertedValue, err
+}
+
+// GetFeatureVariableInteger returns the feature variable value of type int associated with the given feature and variable keys.
+func (c *Client) GetFeatureVariableInteger(featureKey, variableKey string, userContext entities.UserContext) (value int, err error) {
+	val, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return 0, err
+	}
+	convertedValue, err := strconv.Atoi(val)
+	if err != nil || valueType != entities.Integer {
+		return 0, fmt.Errorf("variable value for key %s is invalid or wrong type", variableKey)
+	}
+	return convertedValue, err
+}
+
+// GetFeatureVariableString returns the feature variable value of type string associated with the given feature and variable keys.
+func (c *Client) GetFeatureVariableString(featureKey, variableKey string, userContext entities.UserContext) (value string, err error) {
+	value, valueType, err := c.GetFeatureVariable(featureKey, variableKey, userContext)
+	if err != nil {
+		return "", err
+	}
+	if valueType != entities.String {
+		return "", fmt.Errorf("variable value for key %s is wrong type", variableKey)
+	}
+	return value, err
+}
+
+// GetFeatureVariable returns feature variable as a string along with it's associated type.
+func (c *Client) GetFeatureVariable(featureKey, variableKey string, userContext entities.UserContext) (value string, valueType entities.VariableType, err error) {
+	user, err := toPBUser(userContext)
+	if err != nil {
+		return "", "", err
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	response, err := c.service.GetFeatureVariable(ctx, &decisionpb.VariableRequest{User: user, FeatureKey: featureKey, VariableKey: variableKey})
+	if err != nil {
+		return "", "", fromStatusError(err)
+	}
+	return response.GetValue(), entities.VariableType(response.GetType()), nil
+}
+
+// GetAllFeatureVariables returns all the variables for a given feature along with the enabled state.
+func (c *Client) GetAllFeatureVariables(featureKey string, userContext entities.UserContext) (enabled bool, variableMap map[string]interface{}, err error) {
+	variableMap = make(map[string]interface{})
+	user, err := toPBUser(userContext)
+	if err != nil {
+		return false, variableMap, err
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	response, err := c.service.GetAllFeatureVariables(ctx, &decisionpb.FeatureRequest{User: user, FeatureKey: featureKey})
+	if err != nil {
+		return false, variableMap, fromStatusError(err)
+	}
+	for key, variable := range response.GetVariables() {
+		value, parseErr := fromPBVariable(variable)
+		if parseErr != nil {
+			err = parseErr
+		}
+		variableMap[key] = value
+	}
+	return response.GetEnabled(), variableMap, err
+}
+
+// GetVariation returns the key of the variation the user is bucketed into. Does not generate impression events.
+func (c *Client) GetVariation(experimentKey string, userContext entities.UserContext) (result string, err error) {
+	user, err := toPBUser(userContext)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	response, err := c.service.GetVariation(ctx, &decisionpb.ExperimentRequest{User: user, ExperimentKey: experimentKey})
+	if err != nil {
+		return "", fromStatusError(err)
+	}
+	return response.GetVariationKey(), nil
+}
+
+// Track generates a conversion event with the given event key if it exists and queues it up to be sent to the Optimizely
+// log endpoint for results processing.
+func (c *Client) Track(eventKey string, userContext entities.UserContext, eventTags map[string]interface{}) (err error) {
+	user, err := toPBUser(userContext)
+	if err != nil {
+		return err
+	}
+	pbEventTags, err := toPBStruct(eventTags)
+	if err != nil {
+		return fmt.Errorf("event tag %v", err)
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	if _, err := c.service.Track(ctx, &decisionpb.TrackRequest{User: user, EventKey: eventKey, EventTags: pbEventTags}); err != nil {
+		return fromStatusError(err)
+	}
+	return nil
+}
+
+// GetOptimizelyConfig returns OptimizelyConfig object, or nil if the server can't return it
+func (c *Client) GetOptimizelyConfig() (optimizelyConfig *config.OptimizelyConfig) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	response, err := c.service.GetOptimizelyConfig(ctx, &decisionpb.ConfigRequest{})
+	if err != nil {
+		logger.Error("Unable to get the OptimizelyConfig from the server.", err)
+		return nil
+	}
+	return fromPBConfig(response)
+}
+
+// OnConfigUpdate registers a handler for ProjectConfigUpdate notifications, sent for the project configs streamed by
+// the server. The notifications have no diff.
+func (c *Client) OnConfigUpdate(callback func(notification.ProjectConfigUpdateNotification)) (int, error) {
+	handler := func(payload interface{}) {
+		if projectConfigUpdateNotification, ok := payload.(notification.ProjectConfigUpdateNotification); ok {
+			callback(projectConfigUpdateNotification)
+		}
+	}
+	id, err := c.notificationCenter.AddHandler(notification.ProjectConfigUpdate, handler)
+	if err != nil {
+		return 0, err
+	}
+
+	c.streamOnce.Do(func() {
+		c.wg.Add(1)
+		go c.streamConfig()
+	})
+	return id, nil
+}
+
+// RemoveOnConfigUpdate removes handler for ProjectConfigUpdate notification with given id
+func (c *Client) RemoveOnConfigUpdate(id int) error {
+	return c.notificationCenter.RemoveHandler(id, notification.ProjectConfigUpdate)
+}
+
+// OnTrack returns ErrNotSupported, track notifications are sent on the server
+func (c *Client) OnTrack(callback func(eventKey string, userContext entities.UserContext, eventTags map[string]interface{}, conversionEvent event.ConversionEvent)) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// RemoveOnTrack returns ErrNotSupported, track notifications are sent on the server
+func (c *Client) RemoveOnTrack(id int) error {
+	return ErrNotSupported
+}
+
+// OnDecision returns ErrNotSupported, decision notifications are sent on the server
+func (c *Client) OnDecision(callback func(notification.DecisionNotification)) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// RemoveOnDecision returns ErrNotSupported, decision notifications are sent on the server
+func (c *Client) RemoveOnDecision(id int) error {
+	return ErrNotSupported
+}
+
+// OnLogEvent returns ErrNotSupported, events are dispatched by the server
+func (c *Client) OnLogEvent(callback func(logEvent event.LogEvent)) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// RemoveOnLogEvent returns ErrNotSupported, events are dispatched by the server
+func (c *Client) RemoveOnLogEvent(id int) error {
+	return ErrNotSupported
+}
+
+// OnEventDrop returns ErrNotSupported, events are processed by the server
+func (c *Client) OnEventDrop(callback func(notification.EventDropNotification)) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// RemoveOnEventDrop returns ErrNotSupported, events are processed by the server
+func (c *Client) RemoveOnEventDrop(id int) error {
+	return ErrNotSupported
+}
+
+// Close stops the config stream and closes the connection
+func (c *Client) Close() {
+	c.cancel()
+	c.wg.Wait()
+	if err := c.conn.Close(); err != nil {
+		logger.Warning(fmt.Sprintf("Unable to close the connection: %v", err))
+	}
+}
+
+// streamConfig sends a ProjectConfigUpdate notification for each project config streamed by the server, except the
+// first one, which is the project config loaded when the stream starts. The stream is subscribed to again on failures.
+func (c *Client) streamConfig() {
+	defer c.wg.Done()
+	revision := ""
+	for {
+		err := c.receiveConfigs(&revision)
+		if c.ctx.Err() != nil {
+			return
+		}
+		logger.Warning(fmt.Sprintf("Config stream failed, subscribing again in %v: %v", c.streamRetryInterval, err))
+
+		select {
+		case <-time.After(c.streamRetryInterval):
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Client) receiveConfigs(revision *string) error {
+	stream, err := c.service.StreamConfig(c.ctx, &decisionpb.ConfigRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		optimizelyConfig, err := stream.Recv()
+		if err == io.EOF {
+			return errors.New("stream closed by the server")
+		}
+		if err != nil {
+			return err
+		}
+
+		previous := *revision
+		*revision = optimizelyConfig.GetRevision()
+		if previous == "" || previous == *revision {
+			continue
+		}
+		projectConfigUpdateNotification := notification.ProjectConfigUpdateNotification{
+			Type:     notification.ProjectConfigUpdate,
+			Revision: *revision,
+		}
+		if err := c.notificationCenter.Send(notification.ProjectConfigUpdate, projectConfigUpdateNotification); err != nil {
+			logger.Warning("Problem with sending notification")
+		}
+	}
+}
+
+func (c *Client) callContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.ctx, c.timeout)
+}
+
+// fromStatusError returns the errors of the OptimizelyClient of the server as they were returned by it
+func fromStatusError(err error) error {
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unknown {
+		return errors.New(s.Message())
+	}
+	return err
+}
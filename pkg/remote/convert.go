@@ -0,0 +1,238 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package remote
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/remote/decisionpb"
+)
+
+func toPBUser(userContext entities.UserContext) (*decisionpb.User, error) {
+	user := &decisionpb.User{Id: userContext.ID}
+	if len(userContext.Attributes) > 0 {
+		user.Attributes = make(map[string]*structpb.Value, len(userContext.Attributes))
+		for key, value := range userContext.Attributes {
+			pbValue, err := toPBValue(value)
+			if err != nil {
+				return nil, fmt.Errorf(`attribute "%s": %v`, key, err)
+			}
+			user.Attributes[key] = pbValue
+		}
+	}
+	return user, nil
+}
+
+func fromPBUser(user *decisionpb.User) entities.UserContext {
+	userContext := entities.UserContext{ID: user.GetId(), Attributes: map[string]interface{}{}}
+	for key, value := range user.GetAttributes() {
+		userContext.Attributes[key] = fromPBValue(value)
+	}
+	return userContext
+}
+
+func toPBStruct(values map[string]interface{}) (*structpb.Struct, error) {
+	if values == nil {
+		return nil, nil
+	}
+	pbStruct := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(values))}
+	for key, value := range values {
+		pbValue, err := toPBValue(value)
+		if err != nil {
+			return nil, fmt.Errorf(`"%s": %v`, key, err)
+		}
+		pbStruct.Fields[key] = pbValue
+	}
+	return pbStruct, nil
+}
+
+func fromPBStruct(pbStruct *structpb.Struct) map[string]interface{} {
+	if pbStruct == nil {
+		return nil
+	}
+	values := make(map[string]interface{}, len(pbStruct.Fields))
+	for key, value := range pbStruct.Fields {
+		values[key] = fromPBValue(value)
+	}
+	return values
+}
+
+// toPBValue converts the value of an attribute or an event tag, which is a string, a number, a boolean or nil
+func toPBValue(value interface{}) (*structpb.Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return &structpb.Value{Kind: &structpb.Value_NullValue{}}, nil
+	case string:
+		return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: v}}, nil
+	case bool:
+		return &structpb.Value{Kind: &structpb.Value_BoolValue{BoolValue: v}}, nil
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(v.Int())}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(v.Uint())}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: v.Float()}}, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", value)
+}
+
+func fromPBValue(value *structpb.Value) interface{} {
+	switch kind := value.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_BoolValue:
+		return kind.BoolValue
+	case *structpb.Value_NumberValue:
+		return kind.NumberValue
+	}
+	return nil
+}
+
+// toPBVariable formats a typed variable value of GetAllFeatureVariables as in the datafile
+func toPBVariable(value interface{}) *decisionpb.Variable {
+	switch v := value.(type) {
+	case bool:
+		return &decisionpb.Variable{Type: string(entities.Boolean), Value: strconv.FormatBool(v)}
+	case float64:
+		return &decisionpb.Variable{Type: string(entities.Double), Value: strconv.FormatFloat(v, 'f', -1, 64)}
+	case int:
+		return &decisionpb.Variable{Type: string(entities.Integer), Value: strconv.Itoa(v)}
+	}
+	return &decisionpb.Variable{Type: string(entities.String), Value: fmt.Sprint(value)}
+}
+
+// fromPBVariable parses a variable value the way OptimizelyClient.GetAllFeatureVariables does
+func fromPBVariable(variable *decisionpb.Variable) (value interface{}, err error) {
+	switch entities.VariableType(variable.GetType()) {
+	case entities.Boolean:
+		return strconv.ParseBool(variable.GetValue())
+	case entities.Double:
+		return strconv.ParseFloat(variable.GetValue(), 64)
+	case entities.Integer:
+		return strconv.Atoi(variable.GetValue())
+	}
+	return variable.GetValue(), nil
+}
+
+func toPBConfig(optimizelyConfig *config.OptimizelyConfig) *decisionpb.OptimizelyConfig {
+	if optimizelyConfig == nil {
+		return nil
+	}
+	pbConfig := &decisionpb.OptimizelyConfig{
+		Revision:       optimizelyConfig.Revision,
+		ExperimentsMap: toPBExperiments(optimizelyConfig.ExperimentsMap),
+		FeaturesMap:    make(map[string]*decisionpb.OptimizelyFeature, len(optimizelyConfig.FeaturesMap)),
+	}
+	for key, feature := range optimizelyConfig.FeaturesMap {
+		pbConfig.FeaturesMap[key] = &decisionpb.OptimizelyFeature{
+			Id:             feature.ID,
+			Key:            feature.Key,
+			ExperimentsMap: toPBExperiments(feature.ExperimentsMap),
+			VariablesMap:   toPBVariables(feature.VariablesMap),
+		}
+	}
+	return pbConfig
+}
+
+func toPBExperiments(experiments map[string]config.OptimizelyExperiment) map[string]*decisionpb.OptimizelyExperiment {
+	pbExperiments := make(map[string]*decisionpb.OptimizelyExperiment, len(experiments))
+	for key, experiment := range experiments {
+		pbExperiment := &decisionpb.OptimizelyExperiment{
+			Id:            experiment.ID,
+			Key:           experiment.Key,
+			VariationsMap: make(map[string]*decisionpb.OptimizelyVariation, len(experiment.VariationsMap)),
+		}
+		for variationKey, variation := range experiment.VariationsMap {
+			pbExperiment.VariationsMap[variationKey] = &decisionpb.OptimizelyVariation{
+				Id:             variation.ID,
+				Key:            variation.Key,
+				FeatureEnabled: variation.FeatureEnabled,
+				VariablesMap:   toPBVariables(variation.VariablesMap),
+			}
+		}
+		pbExperiments[key] = pbExperiment
+	}
+	return pbExperiments
+}
+
+func toPBVariables(variables map[string]config.OptimizelyVariable) map[string]*decisionpb.OptimizelyVariable {
+	pbVariables := make(map[string]*decisionpb.OptimizelyVariable, len(variables))
+	for key, variable := range variables {
+		pbVariables[key] = &decisionpb.OptimizelyVariable{Id: variable.ID, Key: variable.Key, Type: variable.Type, Value: variable.Value}
+	}
+	return pbVariables
+}
+
+func fromPBConfig(pbConfig *decisionpb.OptimizelyConfig) *config.OptimizelyConfig {
+	optimizelyConfig := &config.OptimizelyConfig{
+		Revision:       pbConfig.GetRevision(),
+		ExperimentsMap: fromPBExperiments(pbConfig.GetExperimentsMap()),
+		FeaturesMap:    make(map[string]config.OptimizelyFeature, len(pbConfig.GetFeaturesMap())),
+	}
+	for key, feature := range pbConfig.GetFeaturesMap() {
+		optimizelyConfig.FeaturesMap[key] = config.OptimizelyFeature{
+			ID:             feature.GetId(),
+			Key:            feature.GetKey(),
+			ExperimentsMap: fromPBExperiments(feature.GetExperimentsMap()),
+			VariablesMap:   fromPBVariables(feature.GetVariablesMap()),
+		}
+	}
+	return optimizelyConfig
+}
+
+func fromPBExperiments(pbExperiments map[string]*decisionpb.OptimizelyExperiment) map[string]config.OptimizelyExperiment {
+	experiments := make(map[string]config.OptimizelyExperiment, len(pbExperiments))
+	for key, pbExperiment := range pbExperiments {
+		experiment := config.OptimizelyExperiment{
+			ID:            pbExperiment.GetId(),
+			Key:           pbExperiment.GetKey(),
+			VariationsMap: make(map[string]config.OptimizelyVariation, len(pbExperiment.GetVariationsMap())),
+		}
+		for variationKey, pbVariation := range pbExperiment.GetVariationsMap() {
+			experiment.VariationsMap[variationKey] = config.OptimizelyVariation{
+				ID:             pbVariation.GetId(),
+				Key:            pbVariation.GetKey(),
+				FeatureEnabled: pbVariation.GetFeatureEnabled(),
+				VariablesMap:   fromPBVariables(pbVariation.GetVariablesMap()),
+			}
+		}
+		experiments[key] = experiment
+	}
+	return experiments
+}
+
+func fromPBVariables(pbVariables map[string]*decisionpb.OptimizelyVariable) map[string]config.OptimizelyVariable {
+	variables := make(map[string]config.OptimizelyVariable, len(pbVariables))
+	for key, pbVariable := range pbVariables {
+		variables[key] = config.OptimizelyVariable{
+			ID:    pbVariable.GetId(),
+			Key:   pbVariable.GetKey(),
+			Type:  pbVariable.GetType(),
+			Value: pbVariable.GetValue(),
+		}
+	}
+	return variables
+}
@@ -0,0 +1,1410 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/remote/decisionpb/decision.proto
+
+package decisionpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// User is the user decisions are made for
+type User struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// attributes are strings, numbers or booleans
+	Attributes           map[string]*_struct.Value `protobuf:"bytes,2,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *User) Reset()         { *m = User{} }
+func (m *User) String() string { return proto.CompactTextString(m) }
+func (*User) ProtoMessage()    {}
+func (*User) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{0}
+}
+
+func (m *User) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_User.Unmarshal(m, b)
+}
+func (m *User) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_User.Marshal(b, m, deterministic)
+}
+func (m *User) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_User.Merge(m, src)
+}
+func (m *User) XXX_Size() int {
+	return xxx_messageInfo_User.Size(m)
+}
+func (m *User) XXX_DiscardUnknown() {
+	xxx_messageInfo_User.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_User proto.InternalMessageInfo
+
+func (m *User) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *User) GetAttributes() map[string]*_struct.Value {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+type UserRequest struct {
+	User                 *User    `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UserRequest) Reset()         { *m = UserRequest{} }
+func (m *UserRequest) String() string { return proto.CompactTextString(m) }
+func (*UserRequest) ProtoMessage()    {}
+func (*UserRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{1}
+}
+
+func (m *UserRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UserRequest.Unmarshal(m, b)
+}
+func (m *UserRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UserRequest.Marshal(b, m, deterministic)
+}
+func (m *UserRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UserRequest.Merge(m, src)
+}
+func (m *UserRequest) XXX_Size() int {
+	return xxx_messageInfo_UserRequest.Size(m)
+}
+func (m *UserRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UserRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UserRequest proto.InternalMessageInfo
+
+func (m *UserRequest) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+type ExperimentRequest struct {
+	User                 *User    `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	ExperimentKey        string   `protobuf:"bytes,2,opt,name=experiment_key,json=experimentKey,proto3" json:"experiment_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExperimentRequest) Reset()         { *m = ExperimentRequest{} }
+func (m *ExperimentRequest) String() string { return proto.CompactTextString(m) }
+func (*ExperimentRequest) ProtoMessage()    {}
+func (*ExperimentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{2}
+}
+
+func (m *ExperimentRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExperimentRequest.Unmarshal(m, b)
+}
+func (m *ExperimentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExperimentRequest.Marshal(b, m, deterministic)
+}
+func (m *ExperimentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExperimentRequest.Merge(m, src)
+}
+func (m *ExperimentRequest) XXX_Size() int {
+	return xxx_messageInfo_ExperimentRequest.Size(m)
+}
+func (m *ExperimentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExperimentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExperimentRequest proto.InternalMessageInfo
+
+func (m *ExperimentRequest) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *ExperimentRequest) GetExperimentKey() string {
+	if m != nil {
+		return m.ExperimentKey
+	}
+	return ""
+}
+
+type VariationResponse struct {
+	// variation_key is empty when the user is not bucketed into the experiment
+	VariationKey         string   `protobuf:"bytes,1,opt,name=variation_key,json=variationKey,proto3" json:"variation_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VariationResponse) Reset()         { *m = VariationResponse{} }
+func (m *VariationResponse) String() string { return proto.CompactTextString(m) }
+func (*VariationResponse) ProtoMessage()    {}
+func (*VariationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{3}
+}
+
+func (m *VariationResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VariationResponse.Unmarshal(m, b)
+}
+func (m *VariationResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VariationResponse.Marshal(b, m, deterministic)
+}
+func (m *VariationResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VariationResponse.Merge(m, src)
+}
+func (m *VariationResponse) XXX_Size() int {
+	return xxx_messageInfo_VariationResponse.Size(m)
+}
+func (m *VariationResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_VariationResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VariationResponse proto.InternalMessageInfo
+
+func (m *VariationResponse) GetVariationKey() string {
+	if m != nil {
+		return m.VariationKey
+	}
+	return ""
+}
+
+type FeatureRequest struct {
+	User                 *User    `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	FeatureKey           string   `protobuf:"bytes,2,opt,name=feature_key,json=featureKey,proto3" json:"feature_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FeatureRequest) Reset()         { *m = FeatureRequest{} }
+func (m *FeatureRequest) String() string { return proto.CompactTextString(m) }
+func (*FeatureRequest) ProtoMessage()    {}
+func (*FeatureRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{4}
+}
+
+func (m *FeatureRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FeatureRequest.Unmarshal(m, b)
+}
+func (m *FeatureRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FeatureRequest.Marshal(b, m, deterministic)
+}
+func (m *FeatureRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FeatureRequest.Merge(m, src)
+}
+func (m *FeatureRequest) XXX_Size() int {
+	return xxx_messageInfo_FeatureRequest.Size(m)
+}
+func (m *FeatureRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FeatureRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FeatureRequest proto.InternalMessageInfo
+
+func (m *FeatureRequest) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *FeatureRequest) GetFeatureKey() string {
+	if m != nil {
+		return m.FeatureKey
+	}
+	return ""
+}
+
+type FeatureEnabledResponse struct {
+	Enabled              bool     `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FeatureEnabledResponse) Reset()         { *m = FeatureEnabledResponse{} }
+func (m *FeatureEnabledResponse) String() string { return proto.CompactTextString(m) }
+func (*FeatureEnabledResponse) ProtoMessage()    {}
+func (*FeatureEnabledResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{5}
+}
+
+func (m *FeatureEnabledResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FeatureEnabledResponse.Unmarshal(m, b)
+}
+func (m *FeatureEnabledResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FeatureEnabledResponse.Marshal(b, m, deterministic)
+}
+func (m *FeatureEnabledResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FeatureEnabledResponse.Merge(m, src)
+}
+func (m *FeatureEnabledResponse) XXX_Size() int {
+	return xxx_messageInfo_FeatureEnabledResponse.Size(m)
+}
+func (m *FeatureEnabledResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FeatureEnabledResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FeatureEnabledResponse proto.InternalMessageInfo
+
+func (m *FeatureEnabledResponse) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+type EnabledFeaturesResponse struct {
+	FeatureKeys          []string `protobuf:"bytes,1,rep,name=feature_keys,json=featureKeys,proto3" json:"feature_keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EnabledFeaturesResponse) Reset()         { *m = EnabledFeaturesResponse{} }
+func (m *EnabledFeaturesResponse) String() string { return proto.CompactTextString(m) }
+func (*EnabledFeaturesResponse) ProtoMessage()    {}
+func (*EnabledFeaturesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{6}
+}
+
+func (m *EnabledFeaturesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EnabledFeaturesResponse.Unmarshal(m, b)
+}
+func (m *EnabledFeaturesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EnabledFeaturesResponse.Marshal(b, m, deterministic)
+}
+func (m *EnabledFeaturesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EnabledFeaturesResponse.Merge(m, src)
+}
+func (m *EnabledFeaturesResponse) XXX_Size() int {
+	return xxx_messageInfo_EnabledFeaturesResponse.Size(m)
+}
+func (m *EnabledFeaturesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_EnabledFeaturesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EnabledFeaturesResponse proto.InternalMessageInfo
+
+func (m *EnabledFeaturesResponse) GetFeatureKeys() []string {
+	if m != nil {
+		return m.FeatureKeys
+	}
+	return nil
+}
+
+type VariableRequest struct {
+	User                 *User    `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	FeatureKey           string   `protobuf:"bytes,2,opt,name=feature_key,json=featureKey,proto3" json:"feature_key,omitempty"`
+	VariableKey          string   `protobuf:"bytes,3,opt,name=variable_key,json=variableKey,proto3" json:"variable_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VariableRequest) Reset()         { *m = VariableRequest{} }
+func (m *VariableRequest) String() string { return proto.CompactTextString(m) }
+func (*VariableRequest) ProtoMessage()    {}
+func (*VariableRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{7}
+}
+
+func (m *VariableRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VariableRequest.Unmarshal(m, b)
+}
+func (m *VariableRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VariableRequest.Marshal(b, m, deterministic)
+}
+func (m *VariableRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VariableRequest.Merge(m, src)
+}
+func (m *VariableRequest) XXX_Size() int {
+	return xxx_messageInfo_VariableRequest.Size(m)
+}
+func (m *VariableRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VariableRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VariableRequest proto.InternalMessageInfo
+
+func (m *VariableRequest) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *VariableRequest) GetFeatureKey() string {
+	if m != nil {
+		return m.FeatureKey
+	}
+	return ""
+}
+
+func (m *VariableRequest) GetVariableKey() string {
+	if m != nil {
+		return m.VariableKey
+	}
+	return ""
+}
+
+// Variable is the value of a feature variable, formatted as in the datafile
+type Variable struct {
+	// type is one of boolean, double, integer or string
+	Type                 string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Variable) Reset()         { *m = Variable{} }
+func (m *Variable) String() string { return proto.CompactTextString(m) }
+func (*Variable) ProtoMessage()    {}
+func (*Variable) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{8}
+}
+
+func (m *Variable) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Variable.Unmarshal(m, b)
+}
+func (m *Variable) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Variable.Marshal(b, m, deterministic)
+}
+func (m *Variable) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Variable.Merge(m, src)
+}
+func (m *Variable) XXX_Size() int {
+	return xxx_messageInfo_Variable.Size(m)
+}
+func (m *Variable) XXX_DiscardUnknown() {
+	xxx_messageInfo_Variable.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Variable proto.InternalMessageInfo
+
+func (m *Variable) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Variable) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type AllFeatureVariablesResponse struct {
+	Enabled bool `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// variables are keyed by variable key
+	Variables            map[string]*Variable `protobuf:"bytes,2,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AllFeatureVariablesResponse) Reset()         { *m = AllFeatureVariablesResponse{} }
+func (m *AllFeatureVariablesResponse) String() string { return proto.CompactTextString(m) }
+func (*AllFeatureVariablesResponse) ProtoMessage()    {}
+func (*AllFeatureVariablesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{9}
+}
+
+func (m *AllFeatureVariablesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AllFeatureVariablesResponse.Unmarshal(m, b)
+}
+func (m *AllFeatureVariablesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AllFeatureVariablesResponse.Marshal(b, m, deterministic)
+}
+func (m *AllFeatureVariablesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AllFeatureVariablesResponse.Merge(m, src)
+}
+func (m *AllFeatureVariablesResponse) XXX_Size() int {
+	return xxx_messageInfo_AllFeatureVariablesResponse.Size(m)
+}
+func (m *AllFeatureVariablesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AllFeatureVariablesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AllFeatureVariablesResponse proto.InternalMessageInfo
+
+func (m *AllFeatureVariablesResponse) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+func (m *AllFeatureVariablesResponse) GetVariables() map[string]*Variable {
+	if m != nil {
+		return m.Variables
+	}
+	return nil
+}
+
+type TrackRequest struct {
+	User                 *User           `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	EventKey             string          `protobuf:"bytes,2,opt,name=event_key,json=eventKey,proto3" json:"event_key,omitempty"`
+	EventTags            *_struct.Struct `protobuf:"bytes,3,opt,name=event_tags,json=eventTags,proto3" json:"event_tags,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *TrackRequest) Reset()         { *m = TrackRequest{} }
+func (m *TrackRequest) String() string { return proto.CompactTextString(m) }
+func (*TrackRequest) ProtoMessage()    {}
+func (*TrackRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{10}
+}
+
+func (m *TrackRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrackRequest.Unmarshal(m, b)
+}
+func (m *TrackRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrackRequest.Marshal(b, m, deterministic)
+}
+func (m *TrackRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrackRequest.Merge(m, src)
+}
+func (m *TrackRequest) XXX_Size() int {
+	return xxx_messageInfo_TrackRequest.Size(m)
+}
+func (m *TrackRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrackRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrackRequest proto.InternalMessageInfo
+
+func (m *TrackRequest) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *TrackRequest) GetEventKey() string {
+	if m != nil {
+		return m.EventKey
+	}
+	return ""
+}
+
+func (m *TrackRequest) GetEventTags() *_struct.Struct {
+	if m != nil {
+		return m.EventTags
+	}
+	return nil
+}
+
+type TrackResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TrackResponse) Reset()         { *m = TrackResponse{} }
+func (m *TrackResponse) String() string { return proto.CompactTextString(m) }
+func (*TrackResponse) ProtoMessage()    {}
+func (*TrackResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{11}
+}
+
+func (m *TrackResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrackResponse.Unmarshal(m, b)
+}
+func (m *TrackResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrackResponse.Marshal(b, m, deterministic)
+}
+func (m *TrackResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrackResponse.Merge(m, src)
+}
+func (m *TrackResponse) XXX_Size() int {
+	return xxx_messageInfo_TrackResponse.Size(m)
+}
+func (m *TrackResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrackResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrackResponse proto.InternalMessageInfo
+
+type ConfigRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConfigRequest) Reset()         { *m = ConfigRequest{} }
+func (m *ConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*ConfigRequest) ProtoMessage()    {}
+func (*ConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{12}
+}
+
+func (m *ConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigRequest.Unmarshal(m, b)
+}
+func (m *ConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *ConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigRequest.Merge(m, src)
+}
+func (m *ConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_ConfigRequest.Size(m)
+}
+func (m *ConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigRequest proto.InternalMessageInfo
+
+// OptimizelyConfig mirrors the OptimizelyConfig of the SDK
+type OptimizelyConfig struct {
+	Revision             string                           `protobuf:"bytes,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	ExperimentsMap       map[string]*OptimizelyExperiment `protobuf:"bytes,2,rep,name=experiments_map,json=experimentsMap,proto3" json:"experiments_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	FeaturesMap          map[string]*OptimizelyFeature    `protobuf:"bytes,3,rep,name=features_map,json=featuresMap,proto3" json:"features_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
+	XXX_unrecognized     []byte                           `json:"-"`
+	XXX_sizecache        int32                            `json:"-"`
+}
+
+func (m *OptimizelyConfig) Reset()         { *m = OptimizelyConfig{} }
+func (m *OptimizelyConfig) String() string { return proto.CompactTextString(m) }
+func (*OptimizelyConfig) ProtoMessage()    {}
+func (*OptimizelyConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{13}
+}
+
+func (m *OptimizelyConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OptimizelyConfig.Unmarshal(m, b)
+}
+func (m *OptimizelyConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OptimizelyConfig.Marshal(b, m, deterministic)
+}
+func (m *OptimizelyConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OptimizelyConfig.Merge(m, src)
+}
+func (m *OptimizelyConfig) XXX_Size() int {
+	return xxx_messageInfo_OptimizelyConfig.Size(m)
+}
+func (m *OptimizelyConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_OptimizelyConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OptimizelyConfig proto.InternalMessageInfo
+
+func (m *OptimizelyConfig) GetRevision() string {
+	if m != nil {
+		return m.Revision
+	}
+	return ""
+}
+
+func (m *OptimizelyConfig) GetExperimentsMap() map[string]*OptimizelyExperiment {
+	if m != nil {
+		return m.ExperimentsMap
+	}
+	return nil
+}
+
+func (m *OptimizelyConfig) GetFeaturesMap() map[string]*OptimizelyFeature {
+	if m != nil {
+		return m.FeaturesMap
+	}
+	return nil
+}
+
+type OptimizelyExperiment struct {
+	Id                   string                          `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key                  string                          `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	VariationsMap        map[string]*OptimizelyVariation `protobuf:"bytes,3,rep,name=variations_map,json=variationsMap,proto3" json:"variations_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                        `json:"-"`
+	XXX_unrecognized     []byte                          `json:"-"`
+	XXX_sizecache        int32                           `json:"-"`
+}
+
+func (m *OptimizelyExperiment) Reset()         { *m = OptimizelyExperiment{} }
+func (m *OptimizelyExperiment) String() string { return proto.CompactTextString(m) }
+func (*OptimizelyExperiment) ProtoMessage()    {}
+func (*OptimizelyExperiment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{14}
+}
+
+func (m *OptimizelyExperiment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OptimizelyExperiment.Unmarshal(m, b)
+}
+func (m *OptimizelyExperiment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OptimizelyExperiment.Marshal(b, m, deterministic)
+}
+func (m *OptimizelyExperiment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OptimizelyExperiment.Merge(m, src)
+}
+func (m *OptimizelyExperiment) XXX_Size() int {
+	return xxx_messageInfo_OptimizelyExperiment.Size(m)
+}
+func (m *OptimizelyExperiment) XXX_DiscardUnknown() {
+	xxx_messageInfo_OptimizelyExperiment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OptimizelyExperiment proto.InternalMessageInfo
+
+func (m *OptimizelyExperiment) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *OptimizelyExperiment) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *OptimizelyExperiment) GetVariationsMap() map[string]*OptimizelyVariation {
+	if m != nil {
+		return m.VariationsMap
+	}
+	return nil
+}
+
+type OptimizelyFeature struct {
+	Id                   string                           `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key                  string                           `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	ExperimentsMap       map[string]*OptimizelyExperiment `protobuf:"bytes,3,rep,name=experiments_map,json=experimentsMap,proto3" json:"experiments_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	VariablesMap         map[string]*OptimizelyVariable   `protobuf:"bytes,4,rep,name=variables_map,json=variablesMap,proto3" json:"variables_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
+	XXX_unrecognized     []byte                           `json:"-"`
+	XXX_sizecache        int32                            `json:"-"`
+}
+
+func (m *OptimizelyFeature) Reset()         { *m = OptimizelyFeature{} }
+func (m *OptimizelyFeature) String() string { return proto.CompactTextString(m) }
+func (*OptimizelyFeature) ProtoMessage()    {}
+func (*OptimizelyFeature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{15}
+}
+
+func (m *OptimizelyFeature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OptimizelyFeature.Unmarshal(m, b)
+}
+func (m *OptimizelyFeature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OptimizelyFeature.Marshal(b, m, deterministic)
+}
+func (m *OptimizelyFeature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OptimizelyFeature.Merge(m, src)
+}
+func (m *OptimizelyFeature) XXX_Size() int {
+	return xxx_messageInfo_OptimizelyFeature.Size(m)
+}
+func (m *OptimizelyFeature) XXX_DiscardUnknown() {
+	xxx_messageInfo_OptimizelyFeature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OptimizelyFeature proto.InternalMessageInfo
+
+func (m *OptimizelyFeature) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *OptimizelyFeature) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *OptimizelyFeature) GetExperimentsMap() map[string]*OptimizelyExperiment {
+	if m != nil {
+		return m.ExperimentsMap
+	}
+	return nil
+}
+
+func (m *OptimizelyFeature) GetVariablesMap() map[string]*OptimizelyVariable {
+	if m != nil {
+		return m.VariablesMap
+	}
+	return nil
+}
+
+type OptimizelyVariation struct {
+	Id                   string                         `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key                  string                         `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	FeatureEnabled       bool                           `protobuf:"varint,3,opt,name=feature_enabled,json=featureEnabled,proto3" json:"feature_enabled,omitempty"`
+	VariablesMap         map[string]*OptimizelyVariable `protobuf:"bytes,4,rep,name=variables_map,json=variablesMap,proto3" json:"variables_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                       `json:"-"`
+	XXX_unrecognized     []byte                         `json:"-"`
+	XXX_sizecache        int32                          `json:"-"`
+}
+
+func (m *OptimizelyVariation) Reset()         { *m = OptimizelyVariation{} }
+func (m *OptimizelyVariation) String() string { return proto.CompactTextString(m) }
+func (*OptimizelyVariation) ProtoMessage()    {}
+func (*OptimizelyVariation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{16}
+}
+
+func (m *OptimizelyVariation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OptimizelyVariation.Unmarshal(m, b)
+}
+func (m *OptimizelyVariation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OptimizelyVariation.Marshal(b, m, deterministic)
+}
+func (m *OptimizelyVariation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OptimizelyVariation.Merge(m, src)
+}
+func (m *OptimizelyVariation) XXX_Size() int {
+	return xxx_messageInfo_OptimizelyVariation.Size(m)
+}
+func (m *OptimizelyVariation) XXX_DiscardUnknown() {
+	xxx_messageInfo_OptimizelyVariation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OptimizelyVariation proto.InternalMessageInfo
+
+func (m *OptimizelyVariation) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *OptimizelyVariation) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *OptimizelyVariation) GetFeatureEnabled() bool {
+	if m != nil {
+		return m.FeatureEnabled
+	}
+	return false
+}
+
+func (m *OptimizelyVariation) GetVariablesMap() map[string]*OptimizelyVariable {
+	if m != nil {
+		return m.VariablesMap
+	}
+	return nil
+}
+
+type OptimizelyVariable struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Type                 string   `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Value                string   `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OptimizelyVariable) Reset()         { *m = OptimizelyVariable{} }
+func (m *OptimizelyVariable) String() string { return proto.CompactTextString(m) }
+func (*OptimizelyVariable) ProtoMessage()    {}
+func (*OptimizelyVariable) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a7622e9dff1646b7, []int{17}
+}
+
+func (m *OptimizelyVariable) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OptimizelyVariable.Unmarshal(m, b)
+}
+func (m *OptimizelyVariable) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OptimizelyVariable.Marshal(b, m, deterministic)
+}
+func (m *OptimizelyVariable) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OptimizelyVariable.Merge(m, src)
+}
+func (m *OptimizelyVariable) XXX_Size() int {
+	return xxx_messageInfo_OptimizelyVariable.Size(m)
+}
+func (m *OptimizelyVariable) XXX_DiscardUnknown() {
+	xxx_messageInfo_OptimizelyVariable.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OptimizelyVariable proto.InternalMessageInfo
+
+func (m *OptimizelyVariable) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *OptimizelyVariable) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *OptimizelyVariable) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *OptimizelyVariable) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*User)(nil), "optimizely.remote.User")
+	proto.RegisterMapType((map[string]*_struct.Value)(nil), "optimizely.remote.User.AttributesEntry")
+	proto.RegisterType((*UserRequest)(nil), "optimizely.remote.UserRequest")
+	proto.RegisterType((*ExperimentRequest)(nil), "optimizely.remote.ExperimentRequest")
+	proto.RegisterType((*VariationResponse)(nil), "optimizely.remote.VariationResponse")
+	proto.RegisterType((*FeatureRequest)(nil), "optimizely.remote.FeatureRequest")
+	proto.RegisterType((*FeatureEnabledResponse)(nil), "optimizely.remote.FeatureEnabledResponse")
+	proto.RegisterType((*EnabledFeaturesResponse)(nil), "optimizely.remote.EnabledFeaturesResponse")
+	proto.RegisterType((*VariableRequest)(nil), "optimizely.remote.VariableRequest")
+	proto.RegisterType((*Variable)(nil), "optimizely.remote.Variable")
+	proto.RegisterType((*AllFeatureVariablesResponse)(nil), "optimizely.remote.AllFeatureVariablesResponse")
+	proto.RegisterMapType((map[string]*Variable)(nil), "optimizely.remote.AllFeatureVariablesResponse.VariablesEntry")
+	proto.RegisterType((*TrackRequest)(nil), "optimizely.remote.TrackRequest")
+	proto.RegisterType((*TrackResponse)(nil), "optimizely.remote.TrackResponse")
+	proto.RegisterType((*ConfigRequest)(nil), "optimizely.remote.ConfigRequest")
+	proto.RegisterType((*OptimizelyConfig)(nil), "optimizely.remote.OptimizelyConfig")
+	proto.RegisterMapType((map[string]*OptimizelyExperiment)(nil), "optimizely.remote.OptimizelyConfig.ExperimentsMapEntry")
+	proto.RegisterMapType((map[string]*OptimizelyFeature)(nil), "optimizely.remote.OptimizelyConfig.FeaturesMapEntry")
+	proto.RegisterType((*OptimizelyExperiment)(nil), "optimizely.remote.OptimizelyExperiment")
+	proto.RegisterMapType((map[string]*OptimizelyVariation)(nil), "optimizely.remote.OptimizelyExperiment.VariationsMapEntry")
+	proto.RegisterType((*OptimizelyFeature)(nil), "optimizely.remote.OptimizelyFeature")
+	proto.RegisterMapType((map[string]*OptimizelyExperiment)(nil), "optimizely.remote.OptimizelyFeature.ExperimentsMapEntry")
+	proto.RegisterMapType((map[string]*OptimizelyVariable)(nil), "optimizely.remote.OptimizelyFeature.VariablesMapEntry")
+	proto.RegisterType((*OptimizelyVariation)(nil), "optimizely.remote.OptimizelyVariation")
+	proto.RegisterMapType((map[string]*OptimizelyVariable)(nil), "optimizely.remote.OptimizelyVariation.VariablesMapEntry")
+	proto.RegisterType((*OptimizelyVariable)(nil), "optimizely.remote.OptimizelyVariable")
+}
+
+func init() {
+	proto.RegisterFile("pkg/remote/decisionpb/decision.proto", fileDescriptor_a7622e9dff1646b7)
+}
+
+var fileDescriptor_a7622e9dff1646b7 = []byte{
+	// 1019 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0x5f, 0x6f, 0xe3, 0x44,
+	0x10, 0x97, 0xed, 0xdc, 0x91, 0x4c, 0xd2, 0xa4, 0xdd, 0x9e, 0x7a, 0x91, 0x8b, 0xb8, 0x9c, 0xaf,
+	0x47, 0xcb, 0x3f, 0x07, 0x42, 0x75, 0x54, 0xe5, 0xee, 0xa1, 0x40, 0x89, 0x00, 0x21, 0x24, 0xf7,
+	0x5a, 0xc4, 0x1d, 0xd0, 0x38, 0xc9, 0xc6, 0x67, 0xf2, 0xc7, 0xc6, 0xbb, 0xb1, 0x08, 0x8f, 0x48,
+	0xbc, 0xf3, 0x2d, 0xf8, 0x06, 0xbc, 0xf0, 0x09, 0x78, 0xe3, 0x9b, 0xf0, 0x15, 0x90, 0xd7, 0xeb,
+	0x7f, 0xf1, 0x26, 0x35, 0xd5, 0xd1, 0x37, 0x67, 0x76, 0x66, 0x7e, 0xb3, 0x33, 0x3b, 0x33, 0xbf,
+	0xc0, 0x9e, 0x3b, 0xb6, 0xda, 0x1e, 0x9e, 0x3a, 0x14, 0xb7, 0x87, 0x78, 0x60, 0x13, 0xdb, 0x99,
+	0xb9, 0xfd, 0xf8, 0x53, 0x77, 0x3d, 0x87, 0x3a, 0x68, 0xcb, 0x71, 0xa9, 0x3d, 0xb5, 0x7f, 0xc6,
+	0x93, 0x85, 0x1e, 0x2a, 0xab, 0xaf, 0x5a, 0x8e, 0x63, 0x4d, 0x70, 0x9b, 0x29, 0xf4, 0xe7, 0xa3,
+	0x36, 0xa1, 0xde, 0x7c, 0x40, 0x43, 0x03, 0xed, 0x0f, 0x09, 0x4a, 0xe7, 0x04, 0x7b, 0xa8, 0x0e,
+	0xb2, 0x3d, 0x6c, 0x4a, 0x2d, 0xe9, 0xa0, 0x62, 0xc8, 0xf6, 0x10, 0x75, 0x01, 0x4c, 0x4a, 0x3d,
+	0xbb, 0x3f, 0xa7, 0x98, 0x34, 0xe5, 0x96, 0x72, 0x50, 0xed, 0xec, 0xeb, 0x39, 0xf7, 0x7a, 0x60,
+	0xac, 0x9f, 0xc4, 0x9a, 0xa7, 0x33, 0xea, 0x2d, 0x8c, 0x94, 0xa9, 0x7a, 0x0e, 0x8d, 0xa5, 0x63,
+	0xb4, 0x09, 0xca, 0x18, 0x2f, 0x38, 0x58, 0xf0, 0x89, 0xde, 0x86, 0x5b, 0xbe, 0x39, 0x99, 0xe3,
+	0xa6, 0xdc, 0x92, 0x0e, 0xaa, 0x9d, 0x1d, 0x3d, 0x0c, 0x5a, 0x8f, 0x82, 0xd6, 0x2f, 0x82, 0x53,
+	0x23, 0x54, 0x3a, 0x96, 0x8f, 0x24, 0xed, 0x18, 0xaa, 0x01, 0xb4, 0x81, 0x7f, 0x9c, 0x63, 0x42,
+	0xd1, 0x5b, 0x50, 0x9a, 0x13, 0xec, 0x31, 0x9f, 0xd5, 0xce, 0xdd, 0x15, 0x81, 0x1a, 0x4c, 0x49,
+	0xb3, 0x60, 0xeb, 0xf4, 0x27, 0x17, 0x7b, 0xf6, 0x14, 0xcf, 0xe8, 0x75, 0x3c, 0xa0, 0x87, 0x50,
+	0xc7, 0xb1, 0x87, 0xcb, 0xe0, 0x32, 0x32, 0xbb, 0xcc, 0x46, 0x22, 0xfd, 0x02, 0x2f, 0xb4, 0x23,
+	0xd8, 0xba, 0x30, 0x3d, 0xdb, 0xa4, 0xb6, 0x33, 0x33, 0x30, 0x71, 0x9d, 0x19, 0xc1, 0xe8, 0x01,
+	0x6c, 0xf8, 0x91, 0xf0, 0x32, 0xc9, 0x43, 0x2d, 0x16, 0x06, 0x96, 0xdf, 0x43, 0xfd, 0x53, 0x6c,
+	0xd2, 0xb9, 0x87, 0xaf, 0x15, 0xdf, 0x3d, 0xa8, 0x8e, 0x42, 0xf3, 0x54, 0x70, 0xc0, 0x45, 0x81,
+	0xff, 0x0e, 0xec, 0x70, 0xff, 0xa7, 0x33, 0xb3, 0x3f, 0xc1, 0xc3, 0x38, 0xbc, 0x26, 0xbc, 0x82,
+	0x43, 0x11, 0x83, 0x2a, 0x1b, 0xd1, 0x4f, 0xed, 0x31, 0xdc, 0xe5, 0xca, 0xdc, 0x94, 0xc4, 0x46,
+	0xf7, 0xa1, 0x96, 0xc2, 0x23, 0x4d, 0xa9, 0xa5, 0x1c, 0x54, 0x8c, 0x6a, 0x02, 0x48, 0xb4, 0x5f,
+	0x24, 0x68, 0xb0, 0x64, 0xf4, 0x27, 0xff, 0xcf, 0x9d, 0x82, 0x20, 0x7c, 0x0e, 0xc0, 0x34, 0x14,
+	0xa6, 0x51, 0x8d, 0x64, 0xc1, 0xb5, 0x0f, 0xa1, 0x1c, 0xc5, 0x80, 0x10, 0x94, 0xe8, 0xc2, 0xc5,
+	0x3c, 0xfd, 0xec, 0x1b, 0xdd, 0x49, 0xbf, 0xc3, 0x0a, 0x7f, 0x6f, 0xda, 0x3f, 0x12, 0xec, 0x9e,
+	0x4c, 0x26, 0xfc, 0xd6, 0x91, 0x03, 0x72, 0x75, 0xca, 0xd0, 0x73, 0xa8, 0x44, 0xf0, 0x51, 0x13,
+	0x3d, 0x11, 0xdc, 0x72, 0x8d, 0x73, 0x3d, 0x96, 0x84, 0xad, 0x95, 0xf8, 0x53, 0xbf, 0x81, 0x7a,
+	0xf6, 0x50, 0xd0, 0x58, 0xef, 0x65, 0x1b, 0x6b, 0x57, 0x00, 0x1e, 0x17, 0x25, 0xd5, 0x5d, 0xbf,
+	0x49, 0x50, 0x7b, 0xea, 0x99, 0x83, 0xf1, 0xb5, 0x2a, 0xb5, 0x0b, 0x15, 0xec, 0x67, 0x1b, 0xa3,
+	0xcc, 0x04, 0x41, 0x95, 0x1e, 0x01, 0x84, 0x87, 0xd4, 0xb4, 0x48, 0x53, 0xe1, 0xfe, 0x96, 0xfb,
+	0xfd, 0x8c, 0x0d, 0x29, 0x23, 0xf4, 0xf3, 0xd4, 0xb4, 0x88, 0xd6, 0x80, 0x0d, 0x1e, 0x51, 0x98,
+	0x98, 0x40, 0xf0, 0xb1, 0x33, 0x1b, 0xd9, 0x16, 0x8f, 0x51, 0xfb, 0x53, 0x81, 0xcd, 0xaf, 0xe2,
+	0xb8, 0xc2, 0x33, 0xa4, 0x42, 0xd9, 0xc3, 0x3e, 0x9b, 0x91, 0x3c, 0x2f, 0xf1, 0x6f, 0xd4, 0x83,
+	0x46, 0xd2, 0xaf, 0xe4, 0x72, 0x6a, 0xba, 0xbc, 0x46, 0x1f, 0x08, 0xee, 0xb7, 0xec, 0x59, 0x4f,
+	0x46, 0x08, 0xf9, 0xd2, 0x74, 0xc3, 0xea, 0xd4, 0x71, 0x46, 0x88, 0xbe, 0x8e, 0xfb, 0x22, 0x74,
+	0xaf, 0x30, 0xf7, 0x87, 0x45, 0xdc, 0x47, 0x3d, 0x16, 0xfb, 0xae, 0x8e, 0x12, 0x89, 0xfa, 0x03,
+	0x6c, 0x0b, 0xf0, 0x05, 0x0f, 0xe0, 0x49, 0xf6, 0x01, 0xec, 0xaf, 0x85, 0x4e, 0x4d, 0xc5, 0xe4,
+	0x31, 0xa8, 0x43, 0xd8, 0x5c, 0x0e, 0x46, 0x00, 0x74, 0x9c, 0x05, 0xda, 0x5b, 0x0b, 0x14, 0xcd,
+	0xb6, 0xd4, 0x93, 0xfb, 0x55, 0x86, 0x3b, 0xa2, 0x48, 0x72, 0x9b, 0x89, 0x43, 0xcb, 0x09, 0xb4,
+	0x09, 0xf5, 0x78, 0x78, 0xa6, 0xf3, 0x7c, 0x5c, 0xf0, 0xb2, 0x7a, 0x3c, 0xa4, 0x93, 0x6c, 0x6f,
+	0xf8, 0x69, 0x99, 0xfa, 0x02, 0x50, 0x5e, 0x49, 0x90, 0x85, 0xc7, 0xd9, 0x2c, 0xbc, 0xbe, 0x36,
+	0x82, 0x64, 0x37, 0xa4, 0xf2, 0xf0, 0x97, 0x02, 0x5b, 0xb9, 0x44, 0x15, 0x4a, 0x42, 0xee, 0x31,
+	0x87, 0x59, 0x38, 0x2a, 0x52, 0x89, 0x42, 0xaf, 0xf9, 0x39, 0xdf, 0x5c, 0xc1, 0xc0, 0x61, 0x00,
+	0x25, 0x06, 0xf0, 0xa8, 0x10, 0x40, 0x3c, 0xaa, 0x62, 0xf7, 0x35, 0x3f, 0x25, 0xba, 0xd1, 0x17,
+	0x3d, 0xe2, 0x7b, 0x39, 0x1d, 0x8e, 0x00, 0xe9, 0xc3, 0x2c, 0xd2, 0xc3, 0xab, 0x8b, 0xb9, 0x34,
+	0x46, 0x7f, 0x97, 0x61, 0x5b, 0x50, 0xee, 0x02, 0xd5, 0xdc, 0x87, 0x46, 0xb4, 0xec, 0xa2, 0xd5,
+	0xa2, 0xb0, 0xd5, 0x52, 0x1f, 0x65, 0xd6, 0x36, 0xfa, 0x4e, 0x5c, 0x93, 0xa3, 0x62, 0x0f, 0xef,
+	0xca, 0xaa, 0xdc, 0x54, 0xa6, 0x7a, 0x80, 0xf2, 0x0a, 0x05, 0xf2, 0x14, 0x2d, 0x71, 0x45, 0xb4,
+	0xc4, 0x4b, 0xa9, 0x25, 0xde, 0xf9, 0xfb, 0x36, 0x34, 0x3e, 0xe1, 0x6c, 0xf9, 0x0c, 0x7b, 0xbe,
+	0x3d, 0xc0, 0xe8, 0x02, 0xca, 0x27, 0x03, 0x6a, 0xfb, 0x26, 0xc5, 0x48, 0x34, 0xb0, 0x72, 0x2c,
+	0x51, 0xdd, 0x5b, 0xb5, 0x40, 0x33, 0x14, 0xef, 0x19, 0xd4, 0xba, 0x98, 0x26, 0xf5, 0x7e, 0x99,
+	0xbe, 0x7b, 0xb0, 0xf9, 0x19, 0xc9, 0x72, 0x37, 0x74, 0x5f, 0x60, 0x99, 0xa5, 0x8f, 0xea, 0x1b,
+	0xab, 0x55, 0x96, 0x19, 0x60, 0x0f, 0x50, 0x17, 0xd3, 0x25, 0xaa, 0x87, 0x5e, 0x5b, 0xb5, 0xf3,
+	0x39, 0xc0, 0x9b, 0xa2, 0x3b, 0xae, 0xa0, 0x8b, 0xe7, 0x0c, 0x61, 0x89, 0xf2, 0x20, 0x6d, 0x1d,
+	0x39, 0xe1, 0x28, 0xeb, 0x08, 0x0c, 0x1a, 0xc3, 0x4e, 0x17, 0x53, 0x01, 0x99, 0x2a, 0x92, 0x20,
+	0xfd, 0xbf, 0xf1, 0x32, 0xf4, 0x39, 0xdc, 0x62, 0x7c, 0x04, 0xdd, 0x13, 0x18, 0xa6, 0xb9, 0x93,
+	0xda, 0x5a, 0xad, 0xc0, 0x7d, 0x7d, 0x0b, 0xdb, 0x5d, 0x4c, 0x73, 0xdc, 0x45, 0x64, 0x98, 0xa1,
+	0x3c, 0xea, 0x83, 0x02, 0x4c, 0x22, 0x20, 0x21, 0x67, 0xd4, 0xc3, 0xe6, 0xf4, 0xa5, 0xba, 0x7d,
+	0x57, 0xfa, 0xe8, 0xf0, 0x59, 0xc7, 0xb2, 0xe9, 0x8b, 0x79, 0x5f, 0x1f, 0x38, 0xd3, 0x76, 0x62,
+	0xd2, 0xb6, 0x9c, 0x77, 0xc8, 0x70, 0xdc, 0x16, 0xfe, 0x65, 0xed, 0xdf, 0x66, 0x24, 0xef, 0xfd,
+	0x7f, 0x07, 0x00, 0x24, 0x27, 0xdd, 0x8d, 0xd2, 0x0e, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// DecisionServiceClient is the client API for DecisionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DecisionServiceClient interface {
+	// Activate returns the variation of the experiment the user is bucketed into and sends an impression event
+	Activate(ctx context.Context, in *ExperimentRequest, opts ...grpc.CallOption) (*VariationResponse, error)
+	// GetVariation returns the variation of the experiment the user is bucketed into without sending an impression event
+	GetVariation(ctx context.Context, in *ExperimentRequest, opts ...grpc.CallOption) (*VariationResponse, error)
+	// IsFeatureEnabled returns whether the feature is enabled for the user
+	IsFeatureEnabled(ctx context.Context, in *FeatureRequest, opts ...grpc.CallOption) (*FeatureEnabledResponse, error)
+	// GetEnabledFeatures returns the keys of the features enabled for the user
+	GetEnabledFeatures(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*EnabledFeaturesResponse, error)
+	// GetFeatureVariable returns the value of the feature variable for the user
+	GetFeatureVariable(ctx context.Context, in *VariableRequest, opts ...grpc.CallOption) (*Variable, error)
+	// GetAllFeatureVariables returns whether the feature is enabled and the values of all its variables for the user
+	GetAllFeatureVariables(ctx context.Context, in *FeatureRequest, opts ...grpc.CallOption) (*AllFeatureVariablesResponse, error)
+	// Track sends a conversion event
+	Track(ctx context.Context, in *TrackRequest, opts ...grpc.CallOption) (*TrackResponse, error)
+	// GetOptimizelyConfig returns the current project config
+	GetOptimizelyConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*OptimizelyConfig, error)
+	// StreamConfig sends the current project config, then each project config it is updated to
+	StreamConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (DecisionService_StreamConfigClient, error)
+}
+
+type decisionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDecisionServiceClient(cc grpc.ClientConnInterface) DecisionServiceClient {
+	return &decisionServiceClient{cc}
+}
+
+func (c *decisionServiceClient) Activate(ctx context.Context, in *ExperimentRequest, opts ...grpc.CallOption) (*VariationResponse, error) {
+	out := new(VariationResponse)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/Activate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) GetVariation(ctx context.Context, in *ExperimentRequest, opts ...grpc.CallOption) (*VariationResponse, error) {
+	out := new(VariationResponse)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/GetVariation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) IsFeatureEnabled(ctx context.Context, in *FeatureRequest, opts ...grpc.CallOption) (*FeatureEnabledResponse, error) {
+	out := new(FeatureEnabledResponse)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/IsFeatureEnabled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) GetEnabledFeatures(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*EnabledFeaturesResponse, error) {
+	out := new(EnabledFeaturesResponse)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/GetEnabledFeatures", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) GetFeatureVariable(ctx context.Context, in *VariableRequest, opts ...grpc.CallOption) (*Variable, error) {
+	out := new(Variable)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/GetFeatureVariable", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) GetAllFeatureVariables(ctx context.Context, in *FeatureRequest, opts ...grpc.CallOption) (*AllFeatureVariablesResponse, error) {
+	out := new(AllFeatureVariablesResponse)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/GetAllFeatureVariables", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) Track(ctx context.Context, in *TrackRequest, opts ...grpc.CallOption) (*TrackResponse, error) {
+	out := new(TrackResponse)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/Track", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) GetOptimizelyConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*OptimizelyConfig, error) {
+	out := new(OptimizelyConfig)
+	err := c.cc.Invoke(ctx, "/optimizely.remote.DecisionService/GetOptimizelyConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decisionServiceClient) StreamConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (DecisionService_StreamConfigClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DecisionService_serviceDesc.Streams[0], "/optimizely.remote.DecisionService/StreamConfig", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &decisionServiceStreamConfigClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DecisionService_StreamConfigClient interface {
+	Recv() (*OptimizelyConfig, error)
+	grpc.ClientStream
+}
+
+type decisionServiceStreamConfigClient struct {
+	grpc.ClientStream
+}
+
+func (x *decisionServiceStreamConfigClient) Recv() (*OptimizelyConfig, error) {
+	m := new(OptimizelyConfig)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DecisionServiceServer is the server API for DecisionService service.
+type DecisionServiceServer interface {
+	// Activate returns the variation of the experiment the user is bucketed into and sends an impression event
+	Activate(context.Context, *ExperimentRequest) (*VariationResponse, error)
+	// GetVariation returns the variation of the experiment the user is bucketed into without sending an impression event
+	GetVariation(context.Context, *ExperimentRequest) (*VariationResponse, error)
+	// IsFeatureEnabled returns whether the feature is enabled for the user
+	IsFeatureEnabled(context.Context, *FeatureRequest) (*FeatureEnabledResponse, error)
+	// GetEnabledFeatures returns the keys of the features enabled for the user
+	GetEnabledFeatures(context.Context, *UserRequest) (*EnabledFeaturesResponse, error)
+	// GetFeatureVariable returns the value of the feature variable for the user
+	GetFeatureVariable(context.Context, *VariableRequest) (*Variable, error)
+	// GetAllFeatureVariables returns whether the feature is enabled and the values of all its variables for the user
+	GetAllFeatureVariables(context.Context, *FeatureRequest) (*AllFeatureVariablesResponse, error)
+	// Track sends a conversion event
+	Track(context.Context, *TrackRequest) (*TrackResponse, error)
+	// GetOptimizelyConfig returns the current project config
+	GetOptimizelyConfig(context.Context, *ConfigRequest) (*OptimizelyConfig, error)
+	// StreamConfig sends the current project config, then each project config it is updated to
+	StreamConfig(*ConfigRequest, DecisionService_StreamConfigServer) error
+}
+
+// UnimplementedDecisionServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedDecisionServiceServer struct {
+}
+
+func (*UnimplementedDecisionServiceServer) Activate(ctx context.Context, req *ExperimentRequest) (*VariationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Activate not implemented")
+}
+func (*UnimplementedDecisionServiceServer) GetVariation(ctx context.Context, req *ExperimentRequest) (*VariationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVariation not implemented")
+}
+func (*UnimplementedDecisionServiceServer) IsFeatureEnabled(ctx context.Context, req *FeatureRequest) (*FeatureEnabledResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsFeatureEnabled not implemented")
+}
+func (*UnimplementedDecisionServiceServer) GetEnabledFeatures(ctx context.Context, req *UserRequest) (*EnabledFeaturesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEnabledFeatures not implemented")
+}
+func (*UnimplementedDecisionServiceServer) GetFeatureVariable(ctx context.Context, req *VariableRequest) (*Variable, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFeatureVariable not implemented")
+}
+func (*UnimplementedDecisionServiceServer) GetAllFeatureVariables(ctx context.Context, req *FeatureRequest) (*AllFeatureVariablesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllFeatureVariables not implemented")
+}
+func (*UnimplementedDecisionServiceServer) Track(ctx context.Context, req *TrackRequest) (*TrackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Track not implemented")
+}
+func (*UnimplementedDecisionServiceServer) GetOptimizelyConfig(ctx context.Context, req *ConfigRequest) (*OptimizelyConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOptimizelyConfig not implemented")
+}
+func (*UnimplementedDecisionServiceServer) StreamConfig(req *ConfigRequest, srv DecisionService_StreamConfigServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamConfig not implemented")
+}
+
+func RegisterDecisionServiceServer(s *grpc.Server, srv DecisionServiceServer) {
+	s.RegisterService(&_DecisionService_serviceDesc, srv)
+}
+
+func _DecisionService_Activate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExperimentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).Activate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/Activate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).Activate(ctx, req.(*ExperimentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_GetVariation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExperimentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).GetVariation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/GetVariation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).GetVariation(ctx, req.(*ExperimentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_IsFeatureEnabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FeatureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).IsFeatureEnabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/IsFeatureEnabled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).IsFeatureEnabled(ctx, req.(*FeatureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_GetEnabledFeatures_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).GetEnabledFeatures(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/GetEnabledFeatures",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).GetEnabledFeatures(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_GetFeatureVariable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VariableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).GetFeatureVariable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/GetFeatureVariable",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).GetFeatureVariable(ctx, req.(*VariableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_GetAllFeatureVariables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FeatureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).GetAllFeatureVariables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/GetAllFeatureVariables",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).GetAllFeatureVariables(ctx, req.(*FeatureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_Track_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).Track(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/Track",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).Track(ctx, req.(*TrackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_GetOptimizelyConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecisionServiceServer).GetOptimizelyConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/optimizely.remote.DecisionService/GetOptimizelyConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecisionServiceServer).GetOptimizelyConfig(ctx, req.(*ConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecisionService_StreamConfig_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ConfigRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DecisionServiceServer).StreamConfig(m, &decisionServiceStreamConfigServer{stream})
+}
+
+type DecisionService_StreamConfigServer interface {
+	Send(*OptimizelyConfig) error
+	grpc.ServerStream
+}
+
+type decisionServiceStreamConfigServer struct {
+	grpc.ServerStream
+}
+
+func (x *decisionServiceStreamConfigServer) Send(m *OptimizelyConfig) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _DecisionService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "optimizely.remote.DecisionService",
+	HandlerType: (*DecisionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Activate",
+			Handler:    _DecisionService_Activate_Handler,
+		},
+		{
+			MethodName: "GetVariation",
+			Handler:    _DecisionService_GetVariation_Handler,
+		},
+		{
+			MethodName: "IsFeatureEnabled",
+			Handler:    _DecisionService_IsFeatureEnabled_Handler,
+		},
+		{
+			MethodName: "GetEnabledFeatures",
+			Handler:    _DecisionService_GetEnabledFeatures_Handler,
+		},
+		{
+			MethodName: "GetFeatureVariable",
+			Handler:    _DecisionService_GetFeatureVariable_Handler,
+		},
+		{
+			MethodName: "GetAllFeatureVariables",
+			Handler:    _DecisionService_GetAllFeatureVariables_Handler,
+		},
+		{
+			MethodName: "Track",
+			Handler:    _DecisionService_Track_Handler,
+		},
+		{
+			MethodName: "GetOptimizelyConfig",
+			Handler:    _DecisionService_GetOptimizelyConfig_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamConfig",
+			Handler:       _DecisionService_StreamConfig_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/remote/decisionpb/decision.proto",
+}
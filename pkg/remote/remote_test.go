@@ -0,0 +1,349 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/event"
+	"github.com/optimizely/go-sdk/pkg/notification"
+	"github.com/optimizely/go-sdk/pkg/remote/decisionpb"
+)
+
+type recordingProcessor struct {
+	mutex  sync.Mutex
+	events []event.UserEvent
+}
+
+func (p *recordingProcessor) ProcessEvent(userEvent event.UserEvent) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.events = append(p.events, userEvent)
+	return true
+}
+
+func (p *recordingProcessor) OnEventDispatch(callback func(logEvent event.LogEvent)) (int, error) {
+	return 0, nil
+}
+
+func (p *recordingProcessor) RemoveOnEventDispatch(id int) error {
+	return nil
+}
+
+func (p *recordingProcessor) lastEvent() event.UserEvent {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.events[len(p.events)-1]
+}
+
+// updatableConfigManager notifies its project config updates like the PollingProjectConfigManager
+type updatableConfigManager struct {
+	mutex              sync.Mutex
+	projectConfig      config.ProjectConfig
+	notificationCenter notification.Center
+}
+
+func (cm *updatableConfigManager) GetConfig() (config.ProjectConfig, error) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	if cm.projectConfig == nil {
+		return nil, errors.New("datafile not fetched")
+	}
+	return cm.projectConfig, nil
+}
+
+func (cm *updatableConfigManager) GetOptimizelyConfig() *config.OptimizelyConfig {
+	projectConfig, err := cm.GetConfig()
+	if err != nil {
+		return nil
+	}
+	return config.NewOptimizelyConfig(projectConfig)
+}
+
+func (cm *updatableConfigManager) OnProjectConfigUpdate(callback func(notification.ProjectConfigUpdateNotification)) (int, error) {
+	return cm.notificationCenter.AddHandler(notification.ProjectConfigUpdate, func(payload interface{}) {
+		callback(payload.(notification.ProjectConfigUpdateNotification))
+	})
+}
+
+func (cm *updatableConfigManager) RemoveOnProjectConfigUpdate(id int) error {
+	return cm.notificationCenter.RemoveHandler(id, notification.ProjectConfigUpdate)
+}
+
+func (cm *updatableConfigManager) update(t *testing.T, revision string) {
+	datafile, err := ioutil.ReadFile("testdata/datafile.json")
+	require.NoError(t, err)
+	datafile = []byte(strings.Replace(string(datafile), `"revision": "7"`, fmt.Sprintf(`"revision": "%s"`, revision), 1))
+	projectConfig, err := datafileprojectconfig.NewDatafileProjectConfig(datafile)
+	require.NoError(t, err)
+
+	cm.mutex.Lock()
+	cm.projectConfig = projectConfig
+	cm.mutex.Unlock()
+	cm.notificationCenter.Send(notification.ProjectConfigUpdate, notification.ProjectConfigUpdateNotification{
+		Type:     notification.ProjectConfigUpdate,
+		Revision: revision,
+	})
+}
+
+type testRemote struct {
+	configManager *updatableConfigManager
+	processor     *recordingProcessor
+	local         *client.OptimizelyClient
+	remote        *Client
+	grpcServer    *grpc.Server
+}
+
+func newTestRemote(t *testing.T, options ...ClientOptionFunc) *testRemote {
+	r := &testRemote{
+		configManager: &updatableConfigManager{notificationCenter: notification.NewNotificationCenter()},
+		processor:     &recordingProcessor{},
+	}
+	r.configManager.update(t, "7")
+
+	factory := client.OptimizelyFactory{SDKKey: "remote_test"}
+	var err error
+	r.local, err = factory.Client(
+		client.WithConfigManager(r.configManager),
+		client.WithEventProcessor(r.processor),
+		client.WithNotificationCenter(notification.NewNotificationCenter()),
+	)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	r.grpcServer = grpc.NewServer()
+	decisionpb.RegisterDecisionServiceServer(r.grpcServer, NewServer(r.local))
+	go r.grpcServer.Serve(listener)
+
+	dialer := func(ctx context.Context, target string) (net.Conn, error) {
+		return listener.Dial()
+	}
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	require.NoError(t, err)
+	r.remote = NewClient(conn, options...)
+	return r
+}
+
+func (r *testRemote) close() {
+	r.remote.Close()
+	r.grpcServer.Stop()
+	r.local.Close()
+}
+
+func testUsers() []entities.UserContext {
+	users := []entities.UserContext{{ID: "no_attributes"}}
+	for i := 0; i < 20; i++ {
+		users = append(users, entities.UserContext{
+			ID:         fmt.Sprintf("user_%d", i),
+			Attributes: map[string]interface{}{"country": "us", "age": i, "premium": i%2 == 0},
+		})
+	}
+	return users
+}
+
+func TestRemoteDecisionsMatchLocalDecisions(t *testing.T) {
+	r := newTestRemote(t)
+	defer r.close()
+
+	for _, user := range testUsers() {
+		localVariation, localErr := r.local.GetVariation("split_test", user)
+		remoteVariation, remoteErr := r.remote.GetVariation("split_test", user)
+		assert.Equal(t, localVariation, remoteVariation)
+		assert.Equal(t, localErr, remoteErr)
+
+		remoteVariation, remoteErr = r.remote.Activate("split_test", user)
+		assert.Equal(t, localVariation, remoteVariation)
+		assert.NoError(t, remoteErr)
+		assert.Equal(t, user.ID, r.processor.lastEvent().VisitorID)
+
+		localEnabled, _ := r.local.IsFeatureEnabled("checkout", user)
+		remoteEnabled, err := r.remote.IsFeatureEnabled("checkout", user)
+		assert.NoError(t, err)
+		assert.Equal(t, localEnabled, remoteEnabled)
+
+		localFeatures, _ := r.local.GetEnabledFeatures(user)
+		remoteFeatures, err := r.remote.GetEnabledFeatures(user)
+		assert.NoError(t, err)
+		assert.Equal(t, localFeatures, remoteFeatures)
+
+		_, localVariables, _ := r.local.GetAllFeatureVariables("checkout", user)
+		_, remoteVariables, err := r.remote.GetAllFeatureVariables("checkout", user)
+		assert.NoError(t, err)
+		assert.Equal(t, localVariables, remoteVariables)
+	}
+}
+
+func TestRemoteFeatureVariables(t *testing.T) {
+	r := newTestRemote(t)
+	defer r.close()
+	user := entities.UserContext{ID: "user_1"}
+
+	count, err := r.remote.GetFeatureVariableInteger("checkout", "count", user)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	title, err := r.remote.GetFeatureVariableString("checkout", "title", user)
+	assert.NoError(t, err)
+	assert.Equal(t, "Checkout", title)
+
+	ratio, err := r.remote.GetFeatureVariableDouble("checkout", "ratio", user)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, ratio)
+
+	beta, err := r.remote.GetFeatureVariableBoolean("checkout", "beta", user)
+	assert.NoError(t, err)
+	assert.True(t, beta)
+
+	value, valueType, err := r.remote.GetFeatureVariable("checkout", "count", user)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", value)
+	assert.Equal(t, entities.Integer, valueType)
+
+	_, err = r.remote.GetFeatureVariableBoolean("checkout", "count", user)
+	assert.EqualError(t, err, "variable value for key count is invalid or wrong type")
+
+	enabled, variables, err := r.remote.GetAllFeatureVariables("checkout", user)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, map[string]interface{}{"count": 3, "title": "Checkout", "ratio": 0.5, "beta": true}, variables)
+}
+
+func TestRemoteErrors(t *testing.T) {
+	r := newTestRemote(t)
+	defer r.close()
+
+	_, err := r.remote.IsFeatureEnabled("checkout", entities.UserContext{ID: "user_1", Attributes: map[string]interface{}{"list": []string{}}})
+	assert.EqualError(t, err, `attribute "list": unsupported value type []string`)
+
+	err = r.remote.Track("purchase", entities.UserContext{ID: "user_1"}, map[string]interface{}{"items": map[string]int{}})
+	assert.EqualError(t, err, `event tag "items": unsupported value type map[string]int`)
+}
+
+func TestFromStatusError(t *testing.T) {
+	// the errors of the OptimizelyClient of the server are returned as they were returned by it
+	assert.Equal(t, errors.New("decision failed"), fromStatusError(status.Error(codes.Unknown, "decision failed")))
+
+	unavailable := status.Error(codes.Unavailable, "project config not loaded")
+	assert.Equal(t, unavailable, fromStatusError(unavailable))
+}
+
+func TestRemoteUnavailableWithoutConfig(t *testing.T) {
+	r := newTestRemote(t)
+	defer r.close()
+	r.configManager.mutex.Lock()
+	r.configManager.projectConfig = nil
+	r.configManager.mutex.Unlock()
+
+	_, err := r.remote.Activate("split_test", entities.UserContext{ID: "user_1"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Nil(t, r.remote.GetOptimizelyConfig())
+}
+
+func TestRemoteTrack(t *testing.T) {
+	r := newTestRemote(t)
+	defer r.close()
+
+	user := entities.UserContext{ID: "user_1", Attributes: map[string]interface{}{"country": "us"}}
+	err := r.remote.Track("purchase", user, map[string]interface{}{"revenue": 4200, "value": 3.5, "category": "books"})
+	assert.NoError(t, err)
+
+	conversion := r.processor.lastEvent().Conversion
+	require.NotNil(t, conversion)
+	assert.Equal(t, "purchase", conversion.Key)
+	assert.Equal(t, int64(4200), *conversion.Revenue)
+	assert.Equal(t, 3.5, *conversion.Value)
+	assert.Equal(t, "books", conversion.Tags["category"])
+}
+
+func TestRemoteOptimizelyConfig(t *testing.T) {
+	r := newTestRemote(t)
+	defer r.close()
+
+	assert.Equal(t, r.local.GetOptimizelyConfig(), r.remote.GetOptimizelyConfig())
+}
+
+func TestRemoteConfigUpdates(t *testing.T) {
+	r := newTestRemote(t, WithStreamRetryInterval(10*time.Millisecond))
+	defer r.close()
+
+	revisions := make(chan string, 10)
+	id, err := r.remote.OnConfigUpdate(func(n notification.ProjectConfigUpdateNotification) {
+		revisions <- n.Revision
+	})
+	require.NoError(t, err)
+
+	// the stream starts with the current revision, which isn't an update, so the revision is updated until the stream
+	// is subscribed
+	revision := ""
+	for i := 8; revision == "" && i < 100; i++ {
+		r.configManager.update(t, strconv.Itoa(i))
+		select {
+		case revision = <-revisions:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	require.NotEmpty(t, revision)
+
+	// the notifications of the revisions updated before the subscription may still be pending
+	r.configManager.update(t, "100")
+	for revision != "100" {
+		select {
+		case revision = <-revisions:
+		case <-time.After(time.Second):
+			require.Fail(t, "no project config update notification")
+		}
+	}
+
+	assert.NoError(t, r.remote.RemoveOnConfigUpdate(id))
+	r.configManager.update(t, "101")
+	select {
+	case revision := <-revisions:
+		assert.Fail(t, "unexpected notification", revision)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRemoteUnsupportedNotifications(t *testing.T) {
+	r := newTestRemote(t)
+	defer r.close()
+
+	_, err := r.remote.OnDecision(func(notification.DecisionNotification) {})
+	assert.Equal(t, ErrNotSupported, err)
+	_, err = r.remote.OnLogEvent(func(event.LogEvent) {})
+	assert.Equal(t, ErrNotSupported, err)
+	assert.Equal(t, ErrNotSupported, r.remote.RemoveOnTrack(1))
+}
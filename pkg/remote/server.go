@@ -0,0 +1,195 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package remote serves the decisions of an OptimizelyClient over gRPC, and provides a client deciding remotely with
+// the same methods as the OptimizelyClient
+package remote
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/logging"
+	"github.com/optimizely/go-sdk/pkg/notification"
+	"github.com/optimizely/go-sdk/pkg/remote/decisionpb"
+)
+
+var logger = logging.GetLogger("Remote")
+
+// Server implements the DecisionService with an OptimizelyClient. Register it with
+// decisionpb.RegisterDecisionServiceServer.
+type Server struct {
+	client *client.OptimizelyClient
+}
+
+// NewServer returns a server deciding with the client
+func NewServer(optimizelyClient *client.OptimizelyClient) *Server {
+	return &Server{client: optimizelyClient}
+}
+
+// Activate returns the variation of the experiment the user is bucketed into and sends an impression event
+func (s *Server) Activate(ctx context.Context, request *decisionpb.ExperimentRequest) (*decisionpb.VariationResponse, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	variationKey, err := s.client.Activate(request.GetExperimentKey(), fromPBUser(request.GetUser()))
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &decisionpb.VariationResponse{VariationKey: variationKey}, nil
+}
+
+// GetVariation returns the variation of the experiment the user is bucketed into without sending an impression event
+func (s *Server) GetVariation(ctx context.Context, request *decisionpb.ExperimentRequest) (*decisionpb.VariationResponse, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	variationKey, err := s.client.GetVariation(request.GetExperimentKey(), fromPBUser(request.GetUser()))
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &decisionpb.VariationResponse{VariationKey: variationKey}, nil
+}
+
+// IsFeatureEnabled returns whether the feature is enabled for the user
+func (s *Server) IsFeatureEnabled(ctx context.Context, request *decisionpb.FeatureRequest) (*decisionpb.FeatureEnabledResponse, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	enabled, err := s.client.IsFeatureEnabled(request.GetFeatureKey(), fromPBUser(request.GetUser()))
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &decisionpb.FeatureEnabledResponse{Enabled: enabled}, nil
+}
+
+// GetEnabledFeatures returns the keys of the features enabled for the user
+func (s *Server) GetEnabledFeatures(ctx context.Context, request *decisionpb.UserRequest) (*decisionpb.EnabledFeaturesResponse, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	featureKeys, err := s.client.GetEnabledFeatures(fromPBUser(request.GetUser()))
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &decisionpb.EnabledFeaturesResponse{FeatureKeys: featureKeys}, nil
+}
+
+// GetFeatureVariable returns the value of the feature variable for the user
+func (s *Server) GetFeatureVariable(ctx context.Context, request *decisionpb.VariableRequest) (*decisionpb.Variable, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	value, valueType, err := s.client.GetFeatureVariable(request.GetFeatureKey(), request.GetVariableKey(), fromPBUser(request.GetUser()))
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &decisionpb.Variable{Type: string(valueType), Value: value}, nil
+}
+
+// GetAllFeatureVariables returns whether the feature is enabled and the values of all its variables for the user
+func (s *Server) GetAllFeatureVariables(ctx context.Context, request *decisionpb.FeatureRequest) (*decisionpb.AllFeatureVariablesResponse, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	enabled, variableMap, err := s.client.GetAllFeatureVariables(request.GetFeatureKey(), fromPBUser(request.GetUser()))
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	response := &decisionpb.AllFeatureVariablesResponse{Enabled: enabled, Variables: make(map[string]*decisionpb.Variable, len(variableMap))}
+	for key, value := range variableMap {
+		response.Variables[key] = toPBVariable(value)
+	}
+	return response, nil
+}
+
+// Track sends a conversion event
+func (s *Server) Track(ctx context.Context, request *decisionpb.TrackRequest) (*decisionpb.TrackResponse, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	if err := s.client.Track(request.GetEventKey(), fromPBUser(request.GetUser()), fromPBStruct(request.GetEventTags())); err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &decisionpb.TrackResponse{}, nil
+}
+
+// GetOptimizelyConfig returns the current project config
+func (s *Server) GetOptimizelyConfig(ctx context.Context, request *decisionpb.ConfigRequest) (*decisionpb.OptimizelyConfig, error) {
+	if err := s.checkConfig(); err != nil {
+		return nil, err
+	}
+	return toPBConfig(s.client.GetOptimizelyConfig()), nil
+}
+
+// StreamConfig sends the current project config once it is loaded, then each project config it is updated to, until
+// the client cancels the stream
+func (s *Server) StreamConfig(request *decisionpb.ConfigRequest, stream decisionpb.DecisionService_StreamConfigServer) error {
+	// a pending update is enough, as the latest project config is sent for it
+	updates := make(chan struct{}, 1)
+	id, err := s.client.OnConfigUpdate(func(notification.ProjectConfigUpdateNotification) {
+		select {
+		case updates <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer func() {
+		if err := s.client.RemoveOnConfigUpdate(id); err != nil {
+			logger.Warning("Unable to remove the project config update handler of a config stream.")
+		}
+	}()
+
+	var revision string
+	send := func() error {
+		if s.checkConfig() != nil {
+			return nil
+		}
+		optimizelyConfig := s.client.GetOptimizelyConfig()
+		if optimizelyConfig == nil || optimizelyConfig.Revision == revision {
+			return nil
+		}
+		if err := stream.Send(toPBConfig(optimizelyConfig)); err != nil {
+			return err
+		}
+		revision = optimizelyConfig.Revision
+		return nil
+	}
+
+	for {
+		if err := send(); err != nil {
+			return err
+		}
+		select {
+		case <-updates:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// checkConfig returns an Unavailable error while the project config of the client is not loaded
+func (s *Server) checkConfig() error {
+	if _, err := s.client.ConfigManager.GetConfig(); err != nil {
+		return status.Errorf(codes.Unavailable, "project config not loaded: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package sidecar
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/entities"
+)
+
+// UserRequest holds the user of a decision
+type UserRequest struct {
+	UserID         string                 `json:"userId"`
+	UserAttributes map[string]interface{} `json:"userAttributes,omitempty"`
+}
+
+func (u UserRequest) userContext() entities.UserContext {
+	return entities.UserContext{ID: u.UserID, Attributes: u.UserAttributes}
+}
+
+// ActivateRequest is the body of an activate request
+type ActivateRequest struct {
+	UserRequest
+	ExperimentKey string `json:"experimentKey"`
+}
+
+// ActivateResponse is the body of an activate response. The variation key is empty if the user is not bucketed.
+type ActivateResponse struct {
+	ExperimentKey string `json:"experimentKey"`
+	VariationKey  string `json:"variationKey"`
+}
+
+// FeatureRequest is the body of a feature enabled or variables request. All the variables of the feature are
+// returned if the variable key is empty.
+type FeatureRequest struct {
+	UserRequest
+	FeatureKey  string `json:"featureKey"`
+	VariableKey string `json:"variableKey,omitempty"`
+}
+
+// FeatureEnabledResponse is the body of a feature enabled response
+type FeatureEnabledResponse struct {
+	FeatureKey string `json:"featureKey"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// VariableResponse is the body of a variables response for a variable key
+type VariableResponse struct {
+	FeatureKey  string      `json:"featureKey"`
+	VariableKey string      `json:"variableKey"`
+	Type        string      `json:"type"`
+	Value       interface{} `json:"value"`
+}
+
+// VariablesResponse is the body of a variables response for all the variables of a feature
+type VariablesResponse struct {
+	FeatureKey string                 `json:"featureKey"`
+	Enabled    bool                   `json:"enabled"`
+	Variables  map[string]interface{} `json:"variables"`
+}
+
+// TrackRequest is the body of a track request
+type TrackRequest struct {
+	UserRequest
+	EventKey  string                 `json:"eventKey"`
+	EventTags map[string]interface{} `json:"eventTags,omitempty"`
+}
+
+// ForcedVariation is the body of the forced variations requests. The variation key is ignored when removing the
+// forced variation.
+type ForcedVariation struct {
+	UserID        string `json:"userId"`
+	ExperimentKey string `json:"experimentKey"`
+	VariationKey  string `json:"variationKey,omitempty"`
+}
+
+// ErrorResponse is the body of the responses of failed requests
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type clientHandler func(w http.ResponseWriter, r *http.Request, cached *cachedClient)
+
+// withClient checks the method of the request and passes the client of its SDK key to the handler
+func (s *Server) withClient(method string, handler clientHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if method != "" && r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		sdkKey := r.Header.Get(SDKKeyHeader)
+		if sdkKey == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("missing %s header", SDKKeyHeader))
+			return
+		}
+		cached, err := s.getClient(sdkKey)
+		if err == ErrUnknownSDKKey {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		defer cached.release()
+		if _, err := cached.client.ConfigManager.GetConfig(); err != nil {
+			writeError(w, http.StatusServiceUnavailable, fmt.Errorf("project config not loaded: %v", err))
+			return
+		}
+		handler(w, r, cached)
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady reports whether the project configs are loaded: the one of the SDK key of the request if it has one,
+// otherwise the ones of the SDK keys set with WithSDKKeys
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	sdkKeys := s.sdkKeys
+	if sdkKey := r.Header.Get(SDKKeyHeader); sdkKey != "" {
+		sdkKeys = []string{sdkKey}
+	}
+
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+	if closed {
+		writeError(w, http.StatusServiceUnavailable, ErrClosed)
+		return
+	}
+
+	for _, sdkKey := range sdkKeys {
+		cached, err := s.getClient(sdkKey)
+		if err == ErrUnknownSDKKey {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		if err == nil {
+			_, err = cached.client.ConfigManager.GetConfig()
+			cached.release()
+		}
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, fmt.Errorf(`project config of SDK key "%s" not loaded: %v`, sdkKey, err))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+func (s *Server) handleActivate(w http.ResponseWriter, r *http.Request, cached *cachedClient) {
+	var request ActivateRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+	variationKey, err := cached.client.Activate(request.ExperimentKey, request.userContext())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ActivateResponse{ExperimentKey: request.ExperimentKey, VariationKey: variationKey})
+}
+
+func (s *Server) handleFeatureEnabled(w http.ResponseWriter, r *http.Request, cached *cachedClient) {
+	var request FeatureRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+	enabled, err := cached.client.IsFeatureEnabled(request.FeatureKey, request.userContext())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, FeatureEnabledResponse{FeatureKey: request.FeatureKey, Enabled: enabled})
+}
+
+func (s *Server) handleVariables(w http.ResponseWriter, r *http.Request, cached *cachedClient) {
+	var request FeatureRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	if request.VariableKey == "" {
+		enabled, variables, err := cached.client.GetAllFeatureVariables(request.FeatureKey, request.userContext())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, VariablesResponse{FeatureKey: request.FeatureKey, Enabled: enabled, Variables: variables})
+		return
+	}
+
+	var value interface{}
+	projectConfig, _ := cached.client.ConfigManager.GetConfig()
+	variable, err := projectConfig.GetVariableByKey(request.FeatureKey, request.VariableKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	switch variable.Type {
+	case entities.Boolean:
+		value, err = cached.client.GetFeatureVariableBoolean(request.FeatureKey, request.VariableKey, request.userContext())
+	case entities.Double:
+		value, err = cached.client.GetFeatureVariableDouble(request.FeatureKey, request.VariableKey, request.userContext())
+	case entities.Integer:
+		value, err = cached.client.GetFeatureVariableInteger(request.FeatureKey, request.VariableKey, request.userContext())
+	default:
+		value, err = cached.client.GetFeatureVariableString(request.FeatureKey, request.VariableKey, request.userContext())
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, VariableResponse{
+		FeatureKey:  request.FeatureKey,
+		VariableKey: request.VariableKey,
+		Type:        string(variable.Type),
+		Value:       value,
+	})
+}
+
+func (s *Server) handleTrack(w http.ResponseWriter, r *http.Request, cached *cachedClient) {
+	var request TrackRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if err := cached.client.Track(request.EventKey, request.userContext(), request.EventTags); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request, cached *cachedClient) {
+	writeJSON(w, http.StatusOK, cached.client.GetOptimizelyConfig())
+}
+
+// handleForcedVariations gets (GET, with the userId and experimentKey query parameters), sets (PUT) or removes
+// (DELETE) the forced variation of a user
+func (s *Server) handleForcedVariations(w http.ResponseWriter, r *http.Request, cached *cachedClient) {
+	var forcedVariation ForcedVariation
+	switch r.Method {
+	case http.MethodGet:
+		forcedVariation.UserID = r.URL.Query().Get("userId")
+		forcedVariation.ExperimentKey = r.URL.Query().Get("experimentKey")
+	case http.MethodPut, http.MethodDelete:
+		if !readJSON(w, r, &forcedVariation) {
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	if forcedVariation.UserID == "" || forcedVariation.ExperimentKey == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("userId and experimentKey are required"))
+		return
+	}
+	overrideKey := decision.ExperimentOverrideKey{ExperimentKey: forcedVariation.ExperimentKey, UserID: forcedVariation.UserID}
+
+	switch r.Method {
+	case http.MethodGet:
+		variationKey, ok := cached.overrideStore.GetVariation(overrideKey)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no forced variation"))
+			return
+		}
+		forcedVariation.VariationKey = variationKey
+		writeJSON(w, http.StatusOK, forcedVariation)
+	case http.MethodPut:
+		projectConfig, _ := cached.client.ConfigManager.GetConfig()
+		experiment, err := projectConfig.GetExperimentByKey(forcedVariation.ExperimentKey)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, ok := experiment.VariationKeyToIDMap[forcedVariation.VariationKey]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf(`variation "%s" not found in experiment "%s"`, forcedVariation.VariationKey, experiment.Key))
+			return
+		}
+		cached.overrideStore.SetVariation(overrideKey, forcedVariation.VariationKey)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		cached.overrideStore.RemoveVariation(overrideKey)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Unable to write the response.", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,210 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package sidecar serves the decisions of the OptimizelyClient over HTTP, for services which can't embed the SDK
+package sidecar
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/logging"
+)
+
+var logger = logging.GetLogger("Sidecar")
+
+// SDKKeyHeader is the request header holding the SDK key of the project
+const SDKKeyHeader = "X-Optimizely-SDK-Key"
+
+// ErrClosed is returned for requests received once the server is closed
+var ErrClosed = errors.New("sidecar is closed")
+
+// ErrUnknownSDKKey is returned for requests with an SDK key which was not set with WithSDKKeys
+var ErrUnknownSDKKey = errors.New("unknown SDK key")
+
+// ClientFactory returns a client for the project of the SDK key. The client must decide experiments with the given
+// override store, which holds the forced variations.
+type ClientFactory func(sdkKey string, overrideStore decision.ExperimentOverrideStore) (*client.OptimizelyClient, error)
+
+// OptionFunc is used to configure the Server
+type OptionFunc func(s *Server)
+
+// WithClientFactory sets the factory of the clients, instead of one polling the datafile of the SDK key
+func WithClientFactory(clientFactory ClientFactory) OptionFunc {
+	return func(s *Server) {
+		s.clientFactory = clientFactory
+	}
+}
+
+// WithClientOptions adds options to the clients of the default client factory
+func WithClientOptions(options ...client.OptionFunc) OptionFunc {
+	return func(s *Server) {
+		s.clientOptions = append(s.clientOptions, options...)
+	}
+}
+
+// WithSDKKeys sets the SDK keys the server serves and is ready for, once their project configs are loaded. Their
+// clients are created by Preload. Requests with other SDK keys are rejected, unless WithAnySDKKey is set.
+func WithSDKKeys(sdkKeys ...string) OptionFunc {
+	return func(s *Server) {
+		s.sdkKeys = append(s.sdkKeys, sdkKeys...)
+	}
+}
+
+// WithAnySDKKey makes the server serve any SDK key, creating a client for every SDK key it is requested for
+func WithAnySDKKey() OptionFunc {
+	return func(s *Server) {
+		s.anySDKKey = true
+	}
+}
+
+// WithClientCacheOptions adds options to the cache of the clients
+func WithClientCacheOptions(options ...client.ClientCacheOptionFunc) OptionFunc {
+	return func(s *Server) {
+		s.cacheOptions = append(s.cacheOptions, options...)
+	}
+}
+
+// cachedClient is the client of an SDK key, with the override store holding its forced variations. The client must
+// be released once the request is served.
+type cachedClient struct {
+	client        *client.OptimizelyClient
+	overrideStore *decision.MapExperimentOverridesStore
+	release       func()
+}
+
+// Server serves the decisions of a client per SDK key
+type Server struct {
+	clientFactory ClientFactory
+	clientOptions []client.OptionFunc
+	cacheOptions  []client.ClientCacheOptionFunc
+	sdkKeys       []string
+	anySDKKey     bool
+	allowed       map[string]bool
+	mux           *http.ServeMux
+	cache         *client.OptimizelyClientCache
+
+	mutex          sync.Mutex
+	overrideStores map[string]*decision.MapExperimentOverridesStore
+	closed         bool
+}
+
+// NewServer returns a server creating the client of an SDK key on its first request
+func NewServer(options ...OptionFunc) *Server {
+	s := &Server{overrideStores: map[string]*decision.MapExperimentOverridesStore{}}
+	for _, opt := range options {
+		opt(s)
+	}
+	if s.clientFactory == nil {
+		s.clientFactory = s.newPollingClient
+	}
+	s.allowed = make(map[string]bool, len(s.sdkKeys))
+	for _, sdkKey := range s.sdkKeys {
+		s.allowed[sdkKey] = true
+	}
+	cacheOptions := append([]client.ClientCacheOptionFunc{client.WithClientConstructor(s.newClient)}, s.cacheOptions...)
+	s.cache = client.NewOptimizelyClientCache(cacheOptions...)
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("/ready", s.handleReady)
+	s.mux.HandleFunc("/v1/activate", s.withClient(http.MethodPost, s.handleActivate))
+	s.mux.HandleFunc("/v1/feature-enabled", s.withClient(http.MethodPost, s.handleFeatureEnabled))
+	s.mux.HandleFunc("/v1/variables", s.withClient(http.MethodPost, s.handleVariables))
+	s.mux.HandleFunc("/v1/track", s.withClient(http.MethodPost, s.handleTrack))
+	s.mux.HandleFunc("/v1/config", s.withClient(http.MethodGet, s.handleConfig))
+	s.mux.HandleFunc("/v1/forced-variations", s.withClient("", s.handleForcedVariations))
+	return s
+}
+
+// ServeHTTP serves a request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Preload creates the clients of the SDK keys set with WithSDKKeys
+func (s *Server) Preload() error {
+	for _, sdkKey := range s.sdkKeys {
+		cached, err := s.getClient(sdkKey)
+		if err != nil {
+			return fmt.Errorf(`unable to create the client of SDK key "%s": %v`, sdkKey, err)
+		}
+		cached.release()
+	}
+	return nil
+}
+
+// EvictIdle closes the clients unused for the idle timeout of the cache. Their forced variations are kept for the
+// clients created again on the next requests.
+func (s *Server) EvictIdle() int {
+	return s.cache.EvictIdle()
+}
+
+// Close closes the clients, flushing their queued events. Requests received afterwards are rejected.
+func (s *Server) Close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+
+	logger.Debug(fmt.Sprintf("Closing the clients of SDK keys %v.", s.cache.SDKKeys()))
+	s.cache.Close()
+}
+
+// getClient returns the client of the SDK key, creating it on the first call. Only the SDK keys set with WithSDKKeys
+// are served, unless WithAnySDKKey is set.
+func (s *Server) getClient(sdkKey string) (*cachedClient, error) {
+	if !s.anySDKKey && !s.allowed[sdkKey] {
+		return nil, ErrUnknownSDKKey
+	}
+	optlyClient, release, err := s.cache.Get(sdkKey)
+	if err == client.ErrClientCacheClosed {
+		return nil, ErrClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cachedClient{client: optlyClient, overrideStore: s.overrideStore(sdkKey), release: release}, nil
+}
+
+// overrideStore returns the override store of the SDK key, which outlives the evictions of its client
+func (s *Server) overrideStore(sdkKey string) *decision.MapExperimentOverridesStore {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	overrideStore, ok := s.overrideStores[sdkKey]
+	if !ok {
+		overrideStore = decision.NewMapExperimentOverridesStore()
+		s.overrideStores[sdkKey] = overrideStore
+	}
+	return overrideStore
+}
+
+func (s *Server) newClient(sdkKey string) (*client.OptimizelyClient, error) {
+	optlyClient, err := s.clientFactory(sdkKey, s.overrideStore(sdkKey))
+	if err == nil && optlyClient == nil {
+		err = errors.New("client factory returned no client")
+	}
+	return optlyClient, err
+}
+
+func (s *Server) newPollingClient(sdkKey string, overrideStore decision.ExperimentOverrideStore) (*client.OptimizelyClient, error) {
+	factory := client.OptimizelyFactory{SDKKey: sdkKey}
+	options := append([]client.OptionFunc{client.WithExperimentOverrides(overrideStore)}, s.clientOptions...)
+	return factory.Client(options...)
+}
@@ -0,0 +1,297 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package sidecar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/go-sdk/pkg/client"
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/event"
+	"github.com/optimizely/go-sdk/pkg/notification"
+)
+
+const testSDKKey = "sidecar_sdk_key"
+
+type recordingProcessor struct {
+	mutex  sync.Mutex
+	events []event.UserEvent
+}
+
+func (p *recordingProcessor) ProcessEvent(userEvent event.UserEvent) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.events = append(p.events, userEvent)
+	return true
+}
+
+func (p *recordingProcessor) OnEventDispatch(callback func(logEvent event.LogEvent)) (int, error) {
+	return 0, nil
+}
+
+func (p *recordingProcessor) RemoveOnEventDispatch(id int) error {
+	return nil
+}
+
+func (p *recordingProcessor) count() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.events)
+}
+
+// failingConfigManager never loads its project config
+type failingConfigManager struct {
+	config.ProjectConfigManager
+}
+
+func (failingConfigManager) GetConfig() (config.ProjectConfig, error) {
+	return nil, errors.New("datafile not fetched")
+}
+
+type testSidecar struct {
+	*httptest.Server
+	sidecar   *Server
+	processor *recordingProcessor
+	created   int
+}
+
+func newTestSidecar(t *testing.T, options ...OptionFunc) *testSidecar {
+	datafile, err := ioutil.ReadFile("testdata/datafile.json")
+	require.NoError(t, err)
+
+	s := &testSidecar{processor: &recordingProcessor{}}
+	clientFactory := func(sdkKey string, overrideStore decision.ExperimentOverrideStore) (*client.OptimizelyClient, error) {
+		s.created++
+		var configManager config.ProjectConfigManager = failingConfigManager{}
+		if sdkKey == testSDKKey {
+			configManager, err = config.NewStaticProjectConfigManagerFromPayload(datafile)
+			if err != nil {
+				return nil, err
+			}
+		}
+		factory := client.OptimizelyFactory{SDKKey: sdkKey}
+		return factory.Client(
+			client.WithConfigManager(configManager),
+			client.WithExperimentOverrides(overrideStore),
+			client.WithEventProcessor(s.processor),
+			client.WithNotificationCenter(notification.NewNotificationCenter()),
+		)
+	}
+
+	s.sidecar = NewServer(append([]OptionFunc{WithClientFactory(clientFactory), WithSDKKeys(testSDKKey)}, options...)...)
+	s.Server = httptest.NewServer(s.sidecar)
+	return s
+}
+
+func (s *testSidecar) close() {
+	s.Close()
+	s.sidecar.Close()
+}
+
+func (s *testSidecar) do(t *testing.T, method, path, sdkKey string, body, response interface{}) int {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	request, err := http.NewRequest(method, s.URL+path, reader)
+	require.NoError(t, err)
+	if sdkKey != "" {
+		request.Header.Set(SDKKeyHeader, sdkKey)
+	}
+
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if response != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(response))
+	}
+	return resp.StatusCode
+}
+
+func TestActivate(t *testing.T) {
+	s := newTestSidecar(t)
+	defer s.close()
+	var response ActivateResponse
+	status := s.do(t, http.MethodPost, "/v1/activate", testSDKKey,
+		ActivateRequest{UserRequest: UserRequest{UserID: "u4"}, ExperimentKey: "split_test"}, &response)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ActivateResponse{ExperimentKey: "split_test", VariationKey: "control"}, response)
+	assert.Equal(t, 1, s.processor.count())
+
+	// like the client, an unknown experiment decides no variation
+	status = s.do(t, http.MethodPost, "/v1/activate", testSDKKey,
+		ActivateRequest{UserRequest: UserRequest{UserID: "u4"}, ExperimentKey: "unknown"}, &response)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ActivateResponse{ExperimentKey: "unknown"}, response)
+
+	status = s.do(t, http.MethodPost, "/v1/activate", testSDKKey, "not an object", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestFeatureEnabledAndVariables(t *testing.T) {
+	s := newTestSidecar(t)
+	defer s.close()
+	user := UserRequest{UserID: "u1", UserAttributes: map[string]interface{}{"country": "us"}}
+
+	var enabled FeatureEnabledResponse
+	status := s.do(t, http.MethodPost, "/v1/feature-enabled", testSDKKey, FeatureRequest{UserRequest: user, FeatureKey: "checkout"}, &enabled)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, FeatureEnabledResponse{FeatureKey: "checkout", Enabled: true}, enabled)
+
+	var variable VariableResponse
+	status = s.do(t, http.MethodPost, "/v1/variables", testSDKKey, FeatureRequest{UserRequest: user, FeatureKey: "checkout", VariableKey: "count"}, &variable)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, VariableResponse{FeatureKey: "checkout", VariableKey: "count", Type: "integer", Value: float64(3)}, variable)
+
+	var variables VariablesResponse
+	status = s.do(t, http.MethodPost, "/v1/variables", testSDKKey, FeatureRequest{UserRequest: user, FeatureKey: "checkout"}, &variables)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, variables.Enabled)
+	assert.Equal(t, map[string]interface{}{"count": float64(3), "title": "Checkout"}, variables.Variables)
+
+	status = s.do(t, http.MethodPost, "/v1/variables", testSDKKey, FeatureRequest{UserRequest: user, FeatureKey: "checkout", VariableKey: "unknown"}, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestTrack(t *testing.T) {
+	s := newTestSidecar(t)
+	defer s.close()
+	status := s.do(t, http.MethodPost, "/v1/track", testSDKKey,
+		TrackRequest{UserRequest: UserRequest{UserID: "u1"}, EventKey: "purchase", EventTags: map[string]interface{}{"revenue": 100}}, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Equal(t, 1, s.processor.count())
+}
+
+func TestConfig(t *testing.T) {
+	s := newTestSidecar(t)
+	defer s.close()
+	var optimizelyConfig config.OptimizelyConfig
+	status := s.do(t, http.MethodGet, "/v1/config", testSDKKey, nil, &optimizelyConfig)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "7", optimizelyConfig.Revision)
+	assert.Contains(t, optimizelyConfig.FeaturesMap, "checkout")
+
+	status = s.do(t, http.MethodPost, "/v1/config", testSDKKey, nil, nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+}
+
+func TestForcedVariations(t *testing.T) {
+	s := newTestSidecar(t)
+	defer s.close()
+	forcedVariation := ForcedVariation{UserID: "u4", ExperimentKey: "split_test", VariationKey: "treatment"}
+	assert.Equal(t, http.StatusNoContent, s.do(t, http.MethodPut, "/v1/forced-variations", testSDKKey, forcedVariation, nil))
+
+	var got ForcedVariation
+	status := s.do(t, http.MethodGet, "/v1/forced-variations?userId=u4&experimentKey=split_test", testSDKKey, nil, &got)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, forcedVariation, got)
+
+	var response ActivateResponse
+	s.do(t, http.MethodPost, "/v1/activate", testSDKKey, ActivateRequest{UserRequest: UserRequest{UserID: "u4"}, ExperimentKey: "split_test"}, &response)
+	assert.Equal(t, "treatment", response.VariationKey)
+
+	assert.Equal(t, http.StatusNoContent, s.do(t, http.MethodDelete, "/v1/forced-variations", testSDKKey, forcedVariation, nil))
+	status = s.do(t, http.MethodGet, "/v1/forced-variations?userId=u4&experimentKey=split_test", testSDKKey, nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	s.do(t, http.MethodPost, "/v1/activate", testSDKKey, ActivateRequest{UserRequest: UserRequest{UserID: "u4"}, ExperimentKey: "split_test"}, &response)
+	assert.Equal(t, "control", response.VariationKey)
+
+	forcedVariation.VariationKey = "unknown"
+	assert.Equal(t, http.StatusBadRequest, s.do(t, http.MethodPut, "/v1/forced-variations", testSDKKey, forcedVariation, nil))
+}
+
+func TestClientCachePerSDKKey(t *testing.T) {
+	s := newTestSidecar(t, WithAnySDKKey())
+	defer s.close()
+	for i := 0; i < 3; i++ {
+		s.do(t, http.MethodGet, "/v1/config", testSDKKey, nil, nil)
+	}
+	assert.Equal(t, 1, s.created)
+
+	assert.Equal(t, http.StatusBadRequest, s.do(t, http.MethodGet, "/v1/config", "", nil, nil))
+
+	var errorResponse ErrorResponse
+	assert.Equal(t, http.StatusServiceUnavailable, s.do(t, http.MethodGet, "/v1/config", "other_key", nil, &errorResponse))
+	assert.Equal(t, "project config not loaded: datafile not fetched", errorResponse.Error)
+	assert.Equal(t, 2, s.created)
+}
+
+func TestUnknownSDKKeysAreRejected(t *testing.T) {
+	s := newTestSidecar(t)
+	defer s.close()
+
+	var errorResponse ErrorResponse
+	assert.Equal(t, http.StatusForbidden, s.do(t, http.MethodGet, "/v1/config", "other_key", nil, &errorResponse))
+	assert.Equal(t, ErrUnknownSDKKey.Error(), errorResponse.Error)
+	assert.Equal(t, http.StatusForbidden, s.do(t, http.MethodGet, "/ready", "other_key", nil, nil))
+	assert.Equal(t, 0, s.created)
+	assert.Empty(t, s.sidecar.cache.SDKKeys())
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	s := newTestSidecar(t, WithSDKKeys(testSDKKey))
+	defer s.close()
+	assert.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/health", "", nil, nil))
+	assert.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/ready", "", nil, nil))
+	assert.Equal(t, http.StatusForbidden, s.do(t, http.MethodGet, "/ready", "other_key", nil, nil))
+
+	require.NoError(t, s.sidecar.Preload())
+	s.sidecar.Close()
+	assert.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/health", "", nil, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, s.do(t, http.MethodGet, "/ready", "", nil, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, s.do(t, http.MethodGet, "/v1/config", testSDKKey, nil, nil))
+	assert.Error(t, s.sidecar.Preload())
+}
+
+func TestNotReadyWithoutConfig(t *testing.T) {
+	s := newTestSidecar(t, WithSDKKeys("other_key"))
+	defer s.close()
+	var errorResponse ErrorResponse
+	assert.Equal(t, http.StatusServiceUnavailable, s.do(t, http.MethodGet, "/ready", "", nil, &errorResponse))
+	assert.Contains(t, errorResponse.Error, `project config of SDK key "other_key" not loaded`)
+}
+
+func TestForcedVariationsOutliveEvictedClients(t *testing.T) {
+	s := newTestSidecar(t, WithClientCacheOptions(client.WithIdleTimeout(time.Nanosecond)))
+	defer s.close()
+	forcedVariation := ForcedVariation{UserID: "u4", ExperimentKey: "split_test", VariationKey: "treatment"}
+	assert.Equal(t, http.StatusNoContent, s.do(t, http.MethodPut, "/v1/forced-variations", testSDKKey, forcedVariation, nil))
+
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, 1, s.sidecar.EvictIdle())
+
+	var response ActivateResponse
+	s.do(t, http.MethodPost, "/v1/activate", testSDKKey, ActivateRequest{UserRequest: UserRequest{UserID: "u4"}, ExperimentKey: "split_test"}, &response)
+	assert.Equal(t, "treatment", response.VariationKey)
+	assert.Equal(t, 2, s.created)
+}
@@ -0,0 +1,118 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package simulation
+
+import (
+	"math"
+	"sort"
+)
+
+// ChiSquare returns the Pearson chi-square statistic of the observed counts against the expected counts, and its
+// degrees of freedom. Categories without expected counts are skipped; if any of them was observed the statistic is
+// infinite.
+func ChiSquare(observed, expected map[string]float64) (statistic float64, degreesOfFreedom int) {
+	// summed in the order of the categories so that the statistic does not depend on the map order
+	keys := make([]string, 0, len(expected))
+	for category := range expected {
+		keys = append(keys, category)
+	}
+	sort.Strings(keys)
+
+	categories := 0
+	for _, category := range keys {
+		expectedCount := expected[category]
+		if expectedCount <= 0 {
+			continue
+		}
+		categories++
+		diff := observed[category] - expectedCount
+		statistic += diff * diff / expectedCount
+	}
+	for category, observedCount := range observed {
+		if observedCount > 0 && expected[category] <= 0 {
+			return math.Inf(1), categories
+		}
+	}
+	if categories > 0 {
+		degreesOfFreedom = categories - 1
+	}
+	return statistic, degreesOfFreedom
+}
+
+// ChiSquarePValue returns the probability of a chi-square statistic at least as large as the given one
+func ChiSquarePValue(statistic float64, degreesOfFreedom int) float64 {
+	if degreesOfFreedom <= 0 {
+		if statistic > 0 {
+			return 0
+		}
+		return 1
+	}
+	if math.IsInf(statistic, 1) {
+		return 0
+	}
+	return upperIncompleteGamma(float64(degreesOfFreedom)/2, statistic/2)
+}
+
+// upperIncompleteGamma returns the regularized upper incomplete gamma function Q(a, x)
+func upperIncompleteGamma(a, x float64) float64 {
+	const epsilon = 1e-14
+	const maxIterations = 1000
+	if x <= 0 {
+		return 1
+	}
+	lgamma, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lgamma)
+
+	if x < a+1 {
+		// series expansion of the lower function P(a, x)
+		sum, term := 1/a, 1/a
+		for n := 1; n < maxIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*prefix)
+	}
+
+	// continued fraction of Q(a, x), evaluated with the modified Lentz method
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return prefix * h
+}
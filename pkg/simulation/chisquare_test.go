@@ -0,0 +1,60 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package simulation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChiSquare(t *testing.T) {
+	statistic, degreesOfFreedom := ChiSquare(
+		map[string]float64{"a": 60, "b": 40},
+		map[string]float64{"a": 50, "b": 50},
+	)
+	assert.InDelta(t, 4.0, statistic, 1e-9)
+	assert.Equal(t, 1, degreesOfFreedom)
+
+	// categories expected to stay empty don't count as degrees of freedom
+	statistic, degreesOfFreedom = ChiSquare(
+		map[string]float64{"a": 50, "b": 50, "c": 0},
+		map[string]float64{"a": 50, "b": 50, "c": 0},
+	)
+	assert.Equal(t, 0.0, statistic)
+	assert.Equal(t, 1, degreesOfFreedom)
+
+	statistic, _ = ChiSquare(
+		map[string]float64{"a": 50, "b": 49, "c": 1},
+		map[string]float64{"a": 50, "b": 50, "c": 0},
+	)
+	assert.True(t, math.IsInf(statistic, 1))
+}
+
+func TestChiSquarePValue(t *testing.T) {
+	// critical values of the chi-square distribution at the 0.05 and 0.001 significance levels
+	assert.InDelta(t, 0.05, ChiSquarePValue(3.841, 1), 1e-4)
+	assert.InDelta(t, 0.05, ChiSquarePValue(5.991, 2), 1e-4)
+	assert.InDelta(t, 0.05, ChiSquarePValue(18.307, 10), 1e-4)
+	assert.InDelta(t, 0.001, ChiSquarePValue(10.828, 1), 1e-5)
+	assert.InDelta(t, 0.001, ChiSquarePValue(29.588, 10), 1e-5)
+
+	assert.Equal(t, 1.0, ChiSquarePValue(0, 3))
+	assert.Equal(t, 1.0, ChiSquarePValue(0, 0))
+	assert.Equal(t, 0.0, ChiSquarePValue(math.Inf(1), 2))
+}
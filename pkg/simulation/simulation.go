@@ -0,0 +1,374 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package simulation runs synthetic users through the decision service to audit the traffic allocations of a
+// project before launching it.
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/decision/reasons"
+	"github.com/optimizely/go-sdk/pkg/entities"
+	"github.com/optimizely/go-sdk/pkg/logging"
+)
+
+var logger = logging.GetLogger("Simulation")
+
+// NoVariation is the key counting the eligible users bucketed into no variation
+const NoVariation = "(none)"
+
+// DefaultUsers holds the default number of synthetic users
+const DefaultUsers = 10000
+
+// DefaultUserIDPrefix holds the default prefix of the synthetic user IDs, followed by the index of the user
+const DefaultUserIDPrefix = "user_"
+
+const maxTrafficValue = 10000
+
+// AttributeGenerator returns the value of an attribute for a synthetic user
+type AttributeGenerator func(r *rand.Rand) interface{}
+
+// Choice returns a generator picking one of the values uniformly
+func Choice(values ...interface{}) AttributeGenerator {
+	return func(r *rand.Rand) interface{} {
+		return values[r.Intn(len(values))]
+	}
+}
+
+// IntBetween returns a generator picking an integer between min and max, inclusive
+func IntBetween(min, max int) AttributeGenerator {
+	return func(r *rand.Rand) interface{} {
+		return min + r.Intn(max-min+1)
+	}
+}
+
+// Bool returns a generator returning true with the given probability
+func Bool(probability float64) AttributeGenerator {
+	return func(r *rand.Rand) interface{} {
+		return r.Float64() < probability
+	}
+}
+
+// OptionFunc is used to configure the Simulator
+type OptionFunc func(s *Simulator)
+
+// WithUsers sets the number of synthetic users
+func WithUsers(users int) OptionFunc {
+	return func(s *Simulator) {
+		s.users = users
+	}
+}
+
+// WithUserIDPrefix sets the prefix of the synthetic user IDs
+func WithUserIDPrefix(prefix string) OptionFunc {
+	return func(s *Simulator) {
+		s.userIDPrefix = prefix
+	}
+}
+
+// WithAttribute generates the attribute for every synthetic user
+func WithAttribute(key string, generator AttributeGenerator) OptionFunc {
+	return func(s *Simulator) {
+		s.attributes[key] = generator
+	}
+}
+
+// WithSeed sets the seed of the attribute generators
+func WithSeed(seed int64) OptionFunc {
+	return func(s *Simulator) {
+		s.seed = seed
+	}
+}
+
+// WithDecisionService sets the decision service making the decisions, instead of a default CompositeService
+func WithDecisionService(decisionService decision.Service) OptionFunc {
+	return func(s *Simulator) {
+		s.decisionService = decisionService
+	}
+}
+
+// Report holds the results of a simulation
+type Report struct {
+	Users       int
+	Experiments []AllocationReport
+	Rollouts    []AllocationReport
+	Groups      []GroupReport
+}
+
+// AllocationReport holds the distribution of the users meeting the audience conditions of an experiment or of a
+// rollout rule, and its chi-square deviation from the traffic allocation
+type AllocationReport struct {
+	ID               string
+	Key              string
+	FeatureKey       string             // feature of the rollout rule, empty for experiments
+	Eligible         int                // users meeting the audience conditions
+	Counts           map[string]int     // users per variation key, and NoVariation
+	Expected         map[string]float64 // users expected per variation key according to the traffic allocation
+	ChiSquare        float64
+	DegreesOfFreedom int
+	PValue           float64
+
+	shares map[string]float64
+}
+
+// Deviates returns whether the distribution deviates from the traffic allocation at the given significance level
+func (r AllocationReport) Deviates(alpha float64) bool {
+	return r.Eligible > 0 && r.PValue < alpha
+}
+
+// MarshalJSON encodes the report, with an infinite chi-square statistic as the string "+Inf" since JSON has no
+// infinite numbers. The statistic is infinite when users get a variation they are not allocated to.
+func (r AllocationReport) MarshalJSON() ([]byte, error) {
+	type report AllocationReport
+	if !math.IsInf(r.ChiSquare, 1) {
+		return json.Marshal(report(r))
+	}
+	return json.Marshal(struct {
+		report
+		ChiSquare string
+	}{report: report(r), ChiSquare: "+Inf"})
+}
+
+// GroupReport holds the users bucketed into the experiments of a mutually exclusive group
+type GroupReport struct {
+	ID       string
+	Policy   string
+	Counts   map[string]int // users bucketed into a variation per experiment key
+	Overlaps int            // users bucketed into variations of more than one experiment of the group
+}
+
+// Simulator runs synthetic users through the decision service
+type Simulator struct {
+	projectConfig   config.ProjectConfig
+	decisionService decision.Service
+	users           int
+	userIDPrefix    string
+	attributes      map[string]AttributeGenerator
+	seed            int64
+}
+
+// NewSimulator returns a simulator of the given project
+func NewSimulator(projectConfig config.ProjectConfig, options ...OptionFunc) *Simulator {
+	s := &Simulator{
+		projectConfig: projectConfig,
+		users:         DefaultUsers,
+		userIDPrefix:  DefaultUserIDPrefix,
+		attributes:    map[string]AttributeGenerator{},
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	if s.decisionService == nil {
+		s.decisionService = decision.NewCompositeService("simulation")
+	}
+	return s
+}
+
+// Run decides every experiment and feature rollout of the project for every synthetic user
+func (s *Simulator) Run() Report {
+	report := Report{Users: s.users}
+
+	experiments := s.projectConfig.GetExperimentList()
+	sort.Slice(experiments, func(i, j int) bool { return experiments[i].Key < experiments[j].Key })
+	groupIndexes := map[string]int{}
+	for _, experiment := range experiments {
+		var group entities.Group
+		if experiment.GroupID != "" {
+			group, _ = s.projectConfig.GetGroupByID(experiment.GroupID)
+			if _, ok := groupIndexes[experiment.GroupID]; !ok {
+				groupIndexes[experiment.GroupID] = len(report.Groups)
+				report.Groups = append(report.Groups, GroupReport{ID: experiment.GroupID, Policy: group.Policy, Counts: map[string]int{}})
+			}
+		}
+		report.Experiments = append(report.Experiments, newAllocationReport(experiment, group, ""))
+	}
+
+	var features []entities.Feature
+	for _, feature := range s.projectConfig.GetFeatureList() {
+		if feature.Rollout.ID != "" && len(feature.Rollout.Experiments) > 0 {
+			features = append(features, feature)
+		}
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i].Key < features[j].Key })
+	for _, feature := range features {
+		report.Rollouts = append(report.Rollouts, newAllocationReport(feature.Rollout.Experiments[0], entities.Group{}, feature.Key))
+	}
+
+	r := rand.New(rand.NewSource(s.seed))
+	for i := 0; i < s.users; i++ {
+		userContext := entities.UserContext{ID: s.userIDPrefix + strconv.Itoa(i), Attributes: s.generateAttributes(r)}
+		s.decideExperiments(userContext, experiments, &report, groupIndexes)
+		s.decideRollouts(userContext, features, &report)
+	}
+
+	for i := range report.Experiments {
+		report.Experiments[i].evaluate()
+	}
+	for i := range report.Rollouts {
+		report.Rollouts[i].evaluate()
+	}
+	return report
+}
+
+func (s *Simulator) decideExperiments(userContext entities.UserContext, experiments []entities.Experiment, report *Report, groupIndexes map[string]int) {
+	bucketedGroups := map[string]int{}
+	for i := range experiments {
+		experiment := experiments[i]
+		decisionContext := decision.ExperimentDecisionContext{Experiment: &experiment, ProjectConfig: s.projectConfig}
+		experimentDecision, err := s.decisionService.GetExperimentDecision(decisionContext, userContext)
+		if err != nil {
+			logger.Debug(fmt.Sprintf(`Unable to decide experiment "%s": %v`, experiment.Key, err))
+			continue
+		}
+		if experimentDecision.Reason == reasons.FailedAudienceTargeting {
+			continue
+		}
+
+		report.Experiments[i].count(experimentDecision.Variation)
+		if experimentDecision.Variation != nil && experiment.GroupID != "" {
+			bucketedGroups[experiment.GroupID]++
+			report.Groups[groupIndexes[experiment.GroupID]].Counts[experiment.Key]++
+		}
+	}
+
+	for groupID, bucketed := range bucketedGroups {
+		if bucketed > 1 {
+			report.Groups[groupIndexes[groupID]].Overlaps++
+		}
+	}
+}
+
+func (s *Simulator) decideRollouts(userContext entities.UserContext, features []entities.Feature, report *Report) {
+	for i := range features {
+		feature := features[i]
+		decisionContext := decision.FeatureDecisionContext{Feature: &feature, ProjectConfig: s.projectConfig}
+		featureDecision, err := s.decisionService.GetFeatureDecision(decisionContext, userContext)
+		if err != nil {
+			logger.Debug(fmt.Sprintf(`Unable to decide feature "%s": %v`, feature.Key, err))
+			continue
+		}
+		// users bucketed into a feature test or failing the rollout targeting never reach the rollout bucketing
+		if featureDecision.Source != decision.Rollout || featureDecision.Reason == reasons.FailedRolloutTargeting {
+			continue
+		}
+		report.Rollouts[i].count(featureDecision.Variation)
+	}
+}
+
+func (s *Simulator) generateAttributes(r *rand.Rand) map[string]interface{} {
+	keys := make([]string, 0, len(s.attributes))
+	for key := range s.attributes {
+		keys = append(keys, key)
+	}
+	// generate the attributes in a fixed order, so that a seed always produces the same users
+	sort.Strings(keys)
+
+	attributes := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		attributes[key] = s.attributes[key](r)
+	}
+	return attributes
+}
+
+func newAllocationReport(experiment entities.Experiment, group entities.Group, featureKey string) AllocationReport {
+	report := AllocationReport{
+		ID:         experiment.ID,
+		Key:        experiment.Key,
+		FeatureKey: featureKey,
+		Counts:     map[string]int{NoVariation: 0},
+		shares:     expectedShares(experiment, group),
+	}
+	for _, variation := range experiment.Variations {
+		report.Counts[variation.Key] = 0
+	}
+	return report
+}
+
+func (r *AllocationReport) count(variation *entities.Variation) {
+	r.Eligible++
+	if variation == nil {
+		r.Counts[NoVariation]++
+		return
+	}
+	r.Counts[variation.Key]++
+}
+
+func (r *AllocationReport) evaluate() {
+	r.Expected = make(map[string]float64, len(r.shares))
+	for key, share := range r.shares {
+		r.Expected[key] = share * float64(r.Eligible)
+	}
+	if r.Eligible == 0 {
+		r.PValue = 1
+		return
+	}
+
+	observed := make(map[string]float64, len(r.Counts))
+	for key, count := range r.Counts {
+		observed[key] = float64(count)
+	}
+	r.ChiSquare, r.DegreesOfFreedom = ChiSquare(observed, r.Expected)
+	r.PValue = ChiSquarePValue(r.ChiSquare, r.DegreesOfFreedom)
+}
+
+// expectedShares returns the share of the eligible users expected in each variation of the experiment, and in
+// NoVariation
+func expectedShares(experiment entities.Experiment, group entities.Group) map[string]float64 {
+	groupShare := 1.0
+	if experiment.GroupID != "" && group.Policy == "random" {
+		groupShare = rangeShare(group.TrafficAllocation, experiment.ID)
+	}
+
+	shares := map[string]float64{}
+	allocated := 0.0
+	for variationID, share := range rangeShares(experiment.TrafficAllocation) {
+		if variation, ok := experiment.Variations[variationID]; ok {
+			shares[variation.Key] += share * groupShare
+			allocated += share * groupShare
+		}
+	}
+	shares[NoVariation] = 1 - allocated
+	return shares
+}
+
+func rangeShare(trafficAllocation []entities.Range, entityID string) float64 {
+	return rangeShares(trafficAllocation)[entityID]
+}
+
+// rangeShares returns the share of the bucketing values falling into each entity of the traffic allocation
+func rangeShares(trafficAllocation []entities.Range) map[string]float64 {
+	shares := map[string]float64{}
+	startOfRange := 0
+	for _, trafficRange := range trafficAllocation {
+		endOfRange := trafficRange.EndOfRange
+		if endOfRange > maxTrafficValue {
+			endOfRange = maxTrafficValue
+		}
+		if endOfRange > startOfRange {
+			shares[trafficRange.EntityID] += float64(endOfRange-startOfRange) / maxTrafficValue
+			startOfRange = endOfRange
+		}
+	}
+	return shares
+}
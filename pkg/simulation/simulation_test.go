@@ -0,0 +1,167 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package simulation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/go-sdk/pkg/config"
+	"github.com/optimizely/go-sdk/pkg/config/datafileprojectconfig"
+	"github.com/optimizely/go-sdk/pkg/decision"
+	"github.com/optimizely/go-sdk/pkg/entities"
+)
+
+func loadProjectConfig(t *testing.T) config.ProjectConfig {
+	datafile, err := ioutil.ReadFile("testdata/datafile.json")
+	require.NoError(t, err)
+	projectConfig, err := datafileprojectconfig.NewDatafileProjectConfig(datafile)
+	require.NoError(t, err)
+	return projectConfig
+}
+
+func findReport(reports []AllocationReport, key string) AllocationReport {
+	for _, report := range reports {
+		if report.Key == key {
+			return report
+		}
+	}
+	return AllocationReport{}
+}
+
+func TestRun(t *testing.T) {
+	simulator := NewSimulator(loadProjectConfig(t), WithUsers(20000), WithSeed(7),
+		WithAttribute("country", Choice("us", "ca", "uk", "fr")))
+	report := simulator.Run()
+	assert.Equal(t, 20000, report.Users)
+	require.Len(t, report.Experiments, 4)
+	assert.Equal(t, "group_test_a", report.Experiments[0].Key)
+
+	split := findReport(report.Experiments, "split_test")
+	assert.Equal(t, 20000, split.Eligible)
+	assert.Equal(t, map[string]float64{"control": 10000, "treatment": 10000, NoVariation: 0}, split.Expected)
+	assert.Equal(t, 0, split.Counts[NoVariation])
+	assert.Equal(t, 1, split.DegreesOfFreedom)
+	assert.False(t, split.Deviates(0.001))
+
+	// a quarter of the users are in the audience, and half of them in the experiment
+	targeted := findReport(report.Experiments, "targeted_test")
+	assert.InDelta(t, 5000, targeted.Eligible, 300)
+	assert.InDelta(t, 0.25*float64(targeted.Eligible), targeted.Expected["control"], 1e-9)
+	assert.InDelta(t, 0.5*float64(targeted.Eligible), targeted.Expected[NoVariation], 1e-9)
+	assert.Equal(t, 2, targeted.DegreesOfFreedom)
+	assert.False(t, targeted.Deviates(0.001))
+
+	// the group allocates 40% of the users to group_test_a, 60% to group_test_b
+	groupA := findReport(report.Experiments, "group_test_a")
+	assert.InDelta(t, 8000, groupA.Expected["a"], 1e-9)
+	assert.False(t, groupA.Deviates(0.001))
+	groupB := findReport(report.Experiments, "group_test_b")
+	assert.InDelta(t, 6000, groupB.Expected["b1"], 1e-9)
+	assert.False(t, groupB.Deviates(0.001))
+
+	require.Len(t, report.Rollouts, 1)
+	rollout := report.Rollouts[0]
+	assert.Equal(t, "rollout_feature", rollout.FeatureKey)
+	assert.Equal(t, 20000, rollout.Eligible)
+	assert.InDelta(t, 16000, rollout.Expected["on"], 1e-9)
+	assert.False(t, rollout.Deviates(0.001))
+}
+
+func TestRunGroupExclusivity(t *testing.T) {
+	report := NewSimulator(loadProjectConfig(t), WithUsers(5000)).Run()
+	require.Len(t, report.Groups, 1)
+	group := report.Groups[0]
+	assert.Equal(t, "500", group.ID)
+	assert.Equal(t, "random", group.Policy)
+	assert.Equal(t, 0, group.Overlaps)
+	assert.Equal(t, 5000, group.Counts["group_test_a"]+group.Counts["group_test_b"])
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	projectConfig := loadProjectConfig(t)
+	options := []OptionFunc{WithUsers(1000), WithSeed(42), WithAttribute("country", Choice("us", "ca"))}
+	first := NewSimulator(projectConfig, options...).Run()
+	second := NewSimulator(projectConfig, options...).Run()
+	assert.Equal(t, first, second)
+}
+
+// constantDecisionService decides the variation keyed "control" for every user
+type constantDecisionService struct {
+	decision.Service
+}
+
+func (constantDecisionService) GetExperimentDecision(decisionContext decision.ExperimentDecisionContext, userContext entities.UserContext) (decision.ExperimentDecision, error) {
+	for _, variation := range decisionContext.Experiment.Variations {
+		if variation.Key == "control" {
+			v := variation
+			return decision.ExperimentDecision{Variation: &v}, nil
+		}
+	}
+	return decision.ExperimentDecision{}, nil
+}
+
+func (constantDecisionService) GetFeatureDecision(decision.FeatureDecisionContext, entities.UserContext) (decision.FeatureDecision, error) {
+	return decision.FeatureDecision{}, nil
+}
+
+func TestRunDeviatingAllocation(t *testing.T) {
+	report := NewSimulator(loadProjectConfig(t), WithUsers(1000), WithDecisionService(constantDecisionService{})).Run()
+	split := findReport(report.Experiments, "split_test")
+	assert.Equal(t, 1000, split.Counts["control"])
+	assert.True(t, split.Deviates(0.001))
+
+	// no user lands in the 40% of group_test_a allocated by its group
+	groupA := findReport(report.Experiments, "group_test_a")
+	assert.Equal(t, 1000, groupA.Counts[NoVariation])
+	assert.True(t, groupA.Deviates(0.001))
+	assert.Equal(t, 0, report.Rollouts[0].Eligible)
+	assert.False(t, report.Rollouts[0].Deviates(0.001))
+}
+
+func TestAllocationReportJSON(t *testing.T) {
+	report := AllocationReport{
+		Key:              "split_test",
+		Eligible:         10,
+		Counts:           map[string]int{"control": 10},
+		Expected:         map[string]float64{"treatment": 10},
+		ChiSquare:        math.Inf(1),
+		DegreesOfFreedom: 0,
+		PValue:           0,
+	}
+	payload, err := json.Marshal(Report{Experiments: []AllocationReport{report}})
+	require.NoError(t, err)
+
+	var decoded struct {
+		Experiments []map[string]interface{}
+	}
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, "+Inf", decoded.Experiments[0]["ChiSquare"])
+	assert.Equal(t, 0.0, decoded.Experiments[0]["PValue"])
+	assert.Equal(t, "split_test", decoded.Experiments[0]["Key"])
+
+	report.ChiSquare = 1.5
+	payload, err = json.Marshal(report)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(payload, &decoded.Experiments[0]))
+	assert.Equal(t, 1.5, decoded.Experiments[0]["ChiSquare"])
+}
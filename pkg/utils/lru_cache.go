@@ -0,0 +1,142 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package utils //
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRUCache is a bounded cache evicting its least recently used entries. Entries expire after the time to live of the
+// cache, if any. It is safe for concurrent use.
+type LRUCache struct {
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	lock    sync.Mutex
+	entries *list.List
+	items   map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewLRUCache returns a cache holding at most capacity entries, each expiring ttl after it was set.
+// A ttl of zero disables expiration.
+func NewLRUCache(capacity int, ttl time.Duration) *LRUCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRUCache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// Get returns the value of the key, if it is cached and has not expired
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if c.expired(entry) {
+		c.removeElement(element)
+		return nil, false
+	}
+	c.entries.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set caches the value of the key, evicting the least recently used entry if the cache is full
+func (c *LRUCache) Set(key string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.set(key, value)
+}
+
+// Add caches the value of the key unless the key is cached and has not expired. It returns whether the value was
+// added, atomically, so that concurrent callers can use the cache to elect the first of them.
+func (c *LRUCache) Add(key string, value interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.items[key]; ok && !c.expired(element.Value.(*lruEntry)) {
+		c.entries.MoveToFront(element)
+		return false
+	}
+	c.set(key, value)
+	return true
+}
+
+func (c *LRUCache) set(key string, value interface{}) {
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = c.now().Add(c.ttl)
+	}
+
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.entries.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.entries.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	if c.entries.Len() > c.capacity {
+		c.removeElement(c.entries.Back())
+	}
+}
+
+// Remove removes the key from the cache
+func (c *LRUCache) Remove(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// Len returns the number of cached entries, including the expired ones which were not evicted yet
+func (c *LRUCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.entries.Len()
+}
+
+func (c *LRUCache) expired(entry *lruEntry) bool {
+	return !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt)
+}
+
+func (c *LRUCache) removeElement(element *list.Element) {
+	c.entries.Remove(element)
+	delete(c.items, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,99 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package utils //
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUCache(2, 0)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	// reading "a" makes "b" the least recently used entry
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	cache.Set("c", 3)
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	value, ok = cache.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+}
+
+func TestLRUCacheUpdatesValue(t *testing.T) {
+	cache := NewLRUCache(2, 0)
+	cache.Set("a", 1)
+	cache.Set("a", 2)
+
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestLRUCacheExpiresEntries(t *testing.T) {
+	now := time.Now()
+	cache := NewLRUCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.Set("a", 1)
+
+	now = now.Add(59 * time.Second)
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestLRUCacheRemove(t *testing.T) {
+	cache := NewLRUCache(2, 0)
+	cache.Set("a", 1)
+	cache.Remove("a")
+	cache.Remove("missing")
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestLRUCacheAdd(t *testing.T) {
+	now := time.Now()
+	cache := NewLRUCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	assert.True(t, cache.Add("a", 1))
+	assert.False(t, cache.Add("a", 2))
+	value, _ := cache.Get("a")
+	assert.Equal(t, 1, value)
+
+	// expired entries are replaced
+	now = now.Add(time.Minute)
+	assert.True(t, cache.Add("a", 3))
+	value, _ = cache.Get("a")
+	assert.Equal(t, 3, value)
+}
@@ -0,0 +1,112 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package utils //
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a rate limiter allowing bursts of up to burst operations, its tokens refilling at rate per second.
+// It is safe for concurrent use.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full bucket of burst tokens refilling at rate tokens per second.
+// A rate of zero or less disables the limit.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		now:    time.Now,
+		tokens: float64(burst),
+	}
+}
+
+// Allow takes a token if one is available
+func (b *TokenBucket) Allow() bool {
+	if b.rate <= 0 {
+		return true
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait takes a token, blocking until it is available or the context is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token ahead of time and returns how long to wait until it is available
+func (b *TokenBucket) reserve() time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill()
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *TokenBucket) refill() {
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
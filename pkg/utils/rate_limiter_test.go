@@ -0,0 +1,75 @@
+/****************************************************************************
+ * Copyright 2020, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package utils //
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucketAllowsBurstThenRefills(t *testing.T) {
+	now := time.Unix(0, 0)
+	bucket := NewTokenBucket(2, 3)
+	bucket.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.Allow())
+	}
+	assert.False(t, bucket.Allow())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, bucket.Allow())
+	assert.False(t, bucket.Allow())
+
+	// tokens never exceed the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.Allow())
+	}
+	assert.False(t, bucket.Allow())
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	bucket := NewTokenBucket(4, 1)
+	bucket.now = func() time.Time { return now }
+
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, 250*time.Millisecond, bucket.reserve())
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve())
+}
+
+func TestTokenBucketWaitIsCanceled(t *testing.T) {
+	bucket := NewTokenBucket(0.001, 1)
+	assert.NoError(t, bucket.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, bucket.Wait(ctx))
+}
+
+func TestTokenBucketWithoutRate(t *testing.T) {
+	bucket := NewTokenBucket(0, 1)
+	for i := 0; i < 100; i++ {
+		assert.True(t, bucket.Allow())
+	}
+	assert.NoError(t, bucket.Wait(context.Background()))
+}
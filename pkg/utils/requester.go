@@ -19,6 +19,7 @@ package utils
 
 import (
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
@@ -74,11 +75,20 @@ func Headers(headers ...Header) func(r *HTTPRequester) {
 	}
 }
 
+// GzipThreshold enables gzip compression of request bodies of at least minBytes bytes.
+// Compression is disabled when minBytes is zero or negative.
+func GzipThreshold(minBytes int) func(r *HTTPRequester) {
+	return func(r *HTTPRequester) {
+		r.gzipThreshold = minBytes
+	}
+}
+
 // HTTPRequester contains main info
 type HTTPRequester struct {
-	client  http.Client
-	retries int
-	headers []Header
+	client        http.Client
+	retries       int
+	headers       []Header
+	gzipThreshold int
 }
 
 // NewHTTPRequester makes Requester with api and parameters. Sets defaults
@@ -144,7 +154,19 @@ func (r HTTPRequester) Do(url, method string, body io.Reader, headers []Header)
 			}
 		}()
 
-		if response, err = ioutil.ReadAll(resp.Body); err != nil {
+		var reader io.Reader = resp.Body
+		// the transport only decompresses transparently when it asked for gzip itself
+		if resp.Header.Get("Content-Encoding") == "gzip" {
+			gzipReader, gzErr := gzip.NewReader(resp.Body)
+			if gzErr != nil {
+				requesterLogger.Error("failed to decompress body", gzErr)
+				return nil, resp.Header, resp.StatusCode, gzErr
+			}
+			defer gzipReader.Close()
+			reader = gzipReader
+		}
+
+		if response, err = ioutil.ReadAll(reader); err != nil {
 			requesterLogger.Error("failed to read body", err)
 			return nil, resp.Header, resp.StatusCode, err
 		}
@@ -158,6 +180,14 @@ func (r HTTPRequester) Do(url, method string, body io.Reader, headers []Header)
 	}
 
 	requesterLogger.Debug(fmt.Sprintf("request %s", url))
+	compressed := false
+	if body != nil && r.gzipThreshold > 0 {
+		if body, compressed, err = r.compress(body); err != nil {
+			requesterLogger.Error(fmt.Sprintf("failed to compress request %s", url), err)
+			return nil, nil, 0, err
+		}
+	}
+
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		requesterLogger.Error(fmt.Sprintf("failed to make request %s", url), err)
@@ -165,6 +195,9 @@ func (r HTTPRequester) Do(url, method string, body io.Reader, headers []Header)
 	}
 
 	r.addHeaders(req, headers)
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 
 	for i := 0; i < r.retries; i++ {
 
@@ -187,6 +220,27 @@ func (r HTTPRequester) Do(url, method string, body io.Reader, headers []Header)
 	return response, responseHeaders, code, err
 }
 
+// compress gzips the body if it reaches the threshold and reports whether it did
+func (r HTTPRequester) compress(body io.Reader) (io.Reader, bool, error) {
+	raw, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(raw) < r.gzipThreshold {
+		return bytes.NewReader(raw), false, nil
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err = writer.Write(raw); err != nil {
+		return nil, false, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, false, err
+	}
+	return &buffer, true, nil
+}
+
 func (r HTTPRequester) addHeaders(req *http.Request, headers []Header) *http.Request {
 	for _, h := range r.headers {
 		req.Header.Add(h.Name, h.Value)
@@ -17,8 +17,11 @@
 package utils
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -251,6 +254,74 @@ func TestGetRetry(t *testing.T) {
 	assert.Equal(t, 1, called, "called 1 retries")
 }
 
+func gzipBytes(t *testing.T, raw []byte) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write(raw)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestPostGzip(t *testing.T) {
+
+	type body struct {
+		Fld1 string
+		Fld2 int
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(bytes.NewReader(raw))
+			assert.NoError(t, err)
+			raw, err = ioutil.ReadAll(reader)
+			assert.NoError(t, err)
+		}
+		fmt.Fprintf(w, "%s:%s", r.Header.Get("Content-Encoding"), raw)
+	}))
+	defer ts.Close()
+
+	b := body{"one", 1}
+	httpreq := NewHTTPRequester(GzipThreshold(10))
+	resp, _, code, err := httpreq.Post(ts.URL, b)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `gzip:{"Fld1":"one","Fld2":1}`, string(resp))
+
+	// below the threshold the body is sent as is
+	httpreq = NewHTTPRequester(GzipThreshold(1000))
+	resp, _, _, err = httpreq.Post(ts.URL, b)
+	assert.NoError(t, err)
+	assert.Equal(t, `:{"Fld1":"one","Fld2":1}`, string(resp))
+
+	httpreq = NewHTTPRequester()
+	resp, _, _, err = httpreq.Post(ts.URL, b)
+	assert.NoError(t, err)
+	assert.Equal(t, `:{"Fld1":"one","Fld2":1}`, string(resp))
+}
+
+func TestGetGzipResponse(t *testing.T) {
+	datafile := []byte(`{"revision":"42"}`)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipBytes(t, datafile))
+	}))
+	defer ts.Close()
+
+	// decompressed by the transport
+	resp, _, code, err := NewHTTPRequester().Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, datafile, resp)
+
+	// decompressed by the requester when gzip is explicitly accepted
+	resp, _, _, err = NewHTTPRequester().Get(ts.URL, Header{"Accept-Encoding", "gzip"})
+	assert.NoError(t, err)
+	assert.Equal(t, datafile, resp)
+}
+
 func TestString(t *testing.T) {
 	assert.Equal(t, "{timeout: 5s, retries: 1}", NewHTTPRequester().String())
 	assert.Equal(t, "{timeout: 19s, retries: 10}",